@@ -1,51 +1,153 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/IamNewInThis/odoo-quickpass-sync/internal/config"
-	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
 	"github.com/IamNewInThis/odoo-quickpass-sync/internal/server"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tracing"
 )
 
+var logger = logging.For("api")
+
+// fatal registra el error y termina el proceso
+func fatal(message string, err error) {
+	logger.Error(message, "error", err)
+	os.Exit(1)
+}
+
 func main() {
-	// Cargar variables de entorno
-	if err := config.LoadEnv(); err != nil {
-		log.Fatalf("❌ Error al cargar las variables de entorno: %v", err)
+	// Configuración: valores por defecto, archivo YAML, variables de entorno (.env) y flags
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	cfg, err := config.Load(flags)
+	if err != nil {
+		fatal("❌ Configuración inválida", err)
+	}
+	if flags.PrintConfig() {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("❌ Error mostrando la configuración", err)
+		}
+		return
+	}
+
+	// Logs estructurados (JSON en producción) sin RUT, emails ni credenciales en claro (Ley 19.628)
+	if err := logging.Setup(os.Stderr, cfg.LogFormat); err != nil {
+		fatal("❌ Configuración de logs inválida", err)
+	}
+	if err := logging.SetLevels(cfg.LogLevel, cfg.LogLevels); err != nil {
+		fatal("❌ Configuración de logs inválida", err)
 	}
 
-	// Configurar cliente de Odoo
-	odooConfig, err := odoo.NewConfigFromEnv()
+	// Trazas OpenTelemetry (OTLP/HTTP); deshabilitadas solo se propaga el traceparent recibido
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.Environment)
 	if err != nil {
-		log.Printf("⚠️ Error configurando Odoo: %v", err)
-		log.Println("ℹ️ El servidor iniciará sin conexión a Odoo")
+		fatal("❌ Error configurando las trazas", err)
 	}
 
-	var odooClient *odoo.Client
-	if odooConfig != nil {
-		odooClient = odoo.NewClient(odooConfig)
+	// Secretos de los webhooks entrantes (los del tenant por defecto cuando no hay TENANTS_FILE)
+	if cfg.Webhooks.OdooSecret.IsZero() {
+		logger.Info("ℹ️ WEBHOOK_SECRET no configurado: el webhook de Odoo está deshabilitado")
+	}
+	if cfg.Webhooks.QuickpassSecret.IsZero() {
+		logger.Info("ℹ️ QUICKPASS_WEBHOOK_SECRET no configurado: el webhook de Quickpass está deshabilitado")
+	}
+
+	// Clientes (tenants) con sus credenciales de Odoo y Quickpass: TENANTS_FILE o variables de entorno
+	tenants, err := tenant.Load(cfg.TenantsFile, &cfg.Webhooks)
+	if err != nil {
+		fatal("❌ Error configurando los tenants", err)
+	}
 
-		// Intentar autenticar al inicio
-		if err := odooClient.Authenticate(); err != nil {
-			log.Printf("⚠️ Error autenticando con Odoo: %v", err)
-			log.Println("ℹ️ El servidor iniciará, pero la conexión a Odoo no está disponible")
+	// Abrir base de datos y aplicar migraciones pendientes
+	var repo repository.Repository
+	store, err := repository.Open(cfg.Database.Repository())
+	if err != nil {
+		logger.Warn("⚠️ Error abriendo la base de datos", "error", err)
+	} else if n, err := store.Migrator().Up(context.Background()); err != nil {
+		logger.Warn("⚠️ Error aplicando migraciones", "error", err)
+		store.Close()
+		store = nil
+	} else {
+		if n > 0 {
+			logger.Info("🗄️ Migraciones aplicadas", "count", n)
 		}
+		repo = store
+	}
+	if repo == nil {
+		logger.Info("ℹ️ El servidor iniciará sin persistencia del estado de sincronización")
 	}
 
-	// Obtener puerto del entorno o usar 8081 por defecto
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081"
+	// Crear e iniciar servidor; la configuración se recarga con SIGHUP o al modificar sus archivos
+	configs := config.NewReloader(cfg, flags)
+	configs.OnReload(func(next *config.Config) (func() []string, error) {
+		apply, err := logging.PrepareLevels(next.LogLevel, next.LogLevels)
+		if err != nil {
+			return nil, err
+		}
+		return func() []string {
+			apply()
+			logger.Info("📝 Niveles de log aplicados", "levels", logging.Levels())
+			return nil
+		}, nil
+	})
+	srv, err := server.NewServer(configs, tenants, repo)
+	if err != nil {
+		fatal("❌ Error creando servidor", err)
 	}
 
-	// Crear e iniciar servidor
-	srv := server.NewServer(port, odooClient)
+	// SIGTERM (Kubernetes, Docker) o SIGINT (Ctrl+C) inician el apagado ordenado
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go configs.Watch(ctx, config.DefaultWatchInterval)
+
+	logger.Info("🎯 Odoo Quickpass Service - Middleware Odoo/Quickpass", "addr", cfg.Server.Addr(), "environment", cfg.Environment)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Start()
+	}()
 
-	log.Printf("🎯 Odoo Quickpass Service - Middleware Odoo/Quickpass")
-	log.Printf("🌐 Escuchando en puerto %s", port)
+	select {
+	case err := <-errs:
+		if err != nil {
+			fatal("❌ Error iniciando servidor", err)
+		}
+		return
+	case <-ctx.Done():
+	}
+	// Una segunda señal termina el proceso de inmediato
+	stop()
 
-	if err := srv.Start(); err != nil {
-		log.Fatalf("❌ Error iniciando servidor: %v", err)
+	timeout := configs.Current().Server.ShutdownTimeout
+	logger.Info("🛑 Apagando el servidor", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	exitCode := 0
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("⚠️ Apagado incompleto", "error", err)
+		exitCode = 1
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("⚠️ Error enviando las trazas pendientes", "error", err)
+	}
+	if store != nil {
+		if err := store.Close(); err != nil {
+			logger.Warn("⚠️ Error cerrando la base de datos", "error", err)
+			exitCode = 1
+		}
+	}
+	if exitCode == 0 {
+		logger.Info("👋 Servidor detenido")
 	}
+	os.Exit(exitCode)
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/config"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+const usage = `Uso: migrate <comando> [argumentos]
+
+Comandos:
+  up          Aplica todas las migraciones pendientes
+  down [n]    Revierte las últimas n migraciones (por defecto 1)
+  status      Muestra el estado de cada migración`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	// Configuración: valores por defecto, archivo YAML (CONFIG_FILE) y variables de entorno (.env)
+	cfg, err := config.Load(nil)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.LogFormat); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	dbConfig := cfg.Database.Repository()
+
+	store, err := repository.Open(dbConfig)
+	if err != nil {
+		log.Fatalf("❌ Error abriendo la base de datos: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	migrator := store.Migrator()
+
+	switch os.Args[1] {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ %d migraciones aplicadas (%s)", n, dbConfig.Driver)
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				log.Fatalf("❌ Número de pasos inválido: %s", os.Args[2])
+			}
+		}
+		n, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ %d migraciones revertidas (%s)", n, dbConfig.Driver)
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		for _, s := range status {
+			state := "pendiente"
+			if s.AppliedAt != nil {
+				state = "aplicada " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/config"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+const usage = `Uso: secrets <comando> [argumentos]
+
+Administra el keystore cifrado SECRETS_KEYSTORE con la clave maestra SECRETS_MASTER_KEY
+(o SECRETS_MASTER_KEY_FILE). Los valores nunca se muestran.
+
+Comandos:
+  generate-key   Genera una clave aleatoria (base64) para SECRETS_MASTER_KEY o TENANTS_ENCRYPTION_KEY
+  init           Crea un keystore vacío
+  set NOMBRE     Guarda un secreto leído de la entrada estándar (ej: ODOO_API_KEY)
+  delete NOMBRE  Elimina un secreto
+  list           Lista los nombres de los secretos guardados
+  rewrap         Vuelve a cifrar el keystore con SECRETS_NEW_MASTER_KEY (rotación de la clave maestra)`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	// Cargar variables de entorno
+	if err := config.LoadEnv(); err != nil {
+		log.Fatalf("❌ Error al cargar las variables de entorno: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "generate-key":
+		key, err := secret.GenerateKey()
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Println(key)
+
+	case "init":
+		path, master := keystoreSettings()
+		keystore, err := secret.CreateKeystore(path, master)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if err := keystore.Save(); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ Keystore creado en %s", path)
+
+	case "set":
+		name := nameArg()
+		keystore := openKeystore()
+		value, err := readValue(os.Stdin)
+		if err != nil {
+			log.Fatalf("❌ Error leyendo el valor: %v", err)
+		}
+		if value.IsZero() {
+			log.Fatalf("❌ El valor de %s está vacío", name)
+		}
+		if err := keystore.Set(name, value); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if err := keystore.Save(); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ Secreto %s guardado", name)
+
+	case "delete":
+		name := nameArg()
+		keystore := openKeystore()
+		if !keystore.Delete(name) {
+			log.Fatalf("❌ El secreto %s no existe", name)
+		}
+		if err := keystore.Save(); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ Secreto %s eliminado", name)
+
+	case "list":
+		for _, name := range openKeystore().Names() {
+			fmt.Println(name)
+		}
+
+	case "rewrap":
+		keystore := openKeystore()
+		next, ok, err := secret.NewPlainChainFromEnv().Lookup("SECRETS_NEW_MASTER_KEY")
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if !ok {
+			log.Fatalf("❌ Defina SECRETS_NEW_MASTER_KEY (o SECRETS_NEW_MASTER_KEY_FILE) con la clave maestra nueva")
+		}
+		if err := keystore.Rewrap(next); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if err := keystore.Save(); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ Keystore cifrado con la clave maestra nueva: reemplace SECRETS_MASTER_KEY por SECRETS_NEW_MASTER_KEY")
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
+
+// keystoreSettings devuelve la ruta del keystore y la clave maestra
+func keystoreSettings() (string, secret.Value) {
+	path := os.Getenv("SECRETS_KEYSTORE")
+	if path == "" {
+		log.Fatalf("❌ SECRETS_KEYSTORE no está configurado")
+	}
+	master, ok, err := secret.NewPlainChainFromEnv().Lookup("SECRETS_MASTER_KEY")
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if !ok {
+		log.Fatalf("❌ SECRETS_MASTER_KEY no está configurado (genere una con: secrets generate-key)")
+	}
+	return path, master
+}
+
+func openKeystore() *secret.Keystore {
+	keystore, err := secret.OpenKeystore(keystoreSettings())
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return keystore
+}
+
+// nameArg devuelve el nombre del secreto indicado en la línea de comandos
+func nameArg() string {
+	if len(os.Args) < 3 || strings.TrimSpace(os.Args[2]) == "" {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+	return strings.TrimSpace(os.Args[2])
+}
+
+// readValue lee el secreto de la entrada estándar (no de los argumentos, que quedan en el historial del shell)
+func readValue(r io.Reader) (secret.Value, error) {
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Valor: ")
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return secret.Value{}, err
+	}
+	return secret.New(strings.TrimRight(line, "\r\n")), nil
+}
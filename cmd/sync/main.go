@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/config"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+func main() {
+	flowName := flag.String("flow", syncer.FlowEmployees, "Flujo a ejecutar")
+	dryRun := flag.Bool("dry-run", false, "Calcula el plan sin escribir en Odoo, Quickpass ni en la base de datos")
+	asJSON := flag.Bool("json", false, "Muestra el plan en formato JSON (solo con -dry-run)")
+	tenantID := flag.String("tenant", "", "Tenant a sincronizar (por defecto, el tenant por defecto de TENANTS_FILE o \"default\")")
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	// Configuración: valores por defecto, archivo YAML, variables de entorno (.env) y flags
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if flags.PrintConfig() {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Los mensajes de log (también los de los clientes de Odoo y Quickpass) usan el formato y los niveles configurados
+	if err := logging.Setup(os.Stderr, cfg.LogFormat); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := logging.SetLevels(cfg.LogLevel, cfg.LogLevels); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	tenants, err := tenant.Load(cfg.TenantsFile, &cfg.Webhooks)
+	if err != nil {
+		log.Fatalf("❌ Error configurando los tenants: %v", err)
+	}
+
+	store, err := repository.Open(cfg.Database.Repository())
+	if err != nil {
+		log.Fatalf("❌ Error abriendo la base de datos: %v", err)
+	}
+	defer store.Close()
+
+	// La simulación no escribe en la base de datos: no aplica migraciones, exige que estén al día
+	ctx := context.Background()
+	if *dryRun {
+		pending, err := store.Migrator().Pending(ctx)
+		if err != nil {
+			log.Fatalf("❌ Error leyendo las migraciones: %v", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("❌ Hay %d migraciones pendientes (desde %04d_%s); aplíquelas con cmd/migrate antes de usar -dry-run",
+				len(pending), pending[0].Version, pending[0].Name)
+		}
+	} else if _, err := store.Migrator().Up(ctx); err != nil {
+		log.Fatalf("❌ Error aplicando migraciones: %v", err)
+	}
+
+	registry := tenant.NewRegistry(store, nil, &cfg.Webhooks)
+	// Este comando ejecuta un solo flujo y termina: la sincronización periódica (SYNC_INTERVAL) es del servidor
+	registry.SetSyncDefaults(cfg.Sync.RetryPolicy(), cfg.Sync.Flows(), 0)
+	if err := registry.Load(tenants); err != nil {
+		log.Fatalf("❌ Error cargando los tenants: %v", err)
+	}
+	if err := registry.LoadStored(ctx); err != nil {
+		log.Fatalf("❌ Error cargando los tenants: %v", err)
+	}
+	selected := registry.Default()
+	if *tenantID != "" {
+		t, ok := registry.Get(*tenantID)
+		if !ok {
+			log.Fatalf("❌ Tenant desconocido: %s", *tenantID)
+		}
+		selected = t
+	}
+	if selected.Odoo == nil || selected.Quickpass == nil {
+		log.Fatalf("❌ El tenant %s no tiene configurados Odoo y Quickpass", selected.ID)
+	}
+	engine := selected.Engine
+
+	if *dryRun {
+		plan, err := engine.Plan(ctx, selected.ID, *flowName)
+		if err != nil {
+			log.Fatalf("❌ Error calculando el plan: %v", err)
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(plan); err != nil {
+				log.Fatalf("❌ Error generando JSON: %v", err)
+			}
+			return
+		}
+		fmt.Print(plan.Text())
+		return
+	}
+
+	run, err := engine.Execute(ctx, selected.ID, *flowName)
+	if err != nil {
+		log.Fatalf("❌ Sincronización fallida: %v", err)
+	}
+	log.Printf("✅ Ejecución #%d: creados %d, actualizados %d, omitidos %d, fallidos %d",
+		run.ID, run.Created, run.Updated, run.Skipped, run.Failed)
+}
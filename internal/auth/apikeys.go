@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// keyPrefix identifica las claves generadas por el servicio
+const keyPrefix = "oqs_"
+
+// touchInterval evita escribir en la base de datos en cada petición solo para registrar el uso
+const touchInterval = time.Minute
+
+var (
+	// ErrMissingCredentials se devuelve cuando la petición no trae clave ni token
+	ErrMissingCredentials = errors.New("faltan credenciales: use el header X-API-Key o Authorization: Bearer")
+	// ErrInvalidKey se devuelve cuando la clave no existe, fue revocada o expiró
+	ErrInvalidKey = errors.New("clave de API inválida, revocada o expirada")
+	// ErrKeyRevoked se devuelve al rotar una clave revocada
+	ErrKeyRevoked = errors.New("la clave está revocada")
+)
+
+// GenerateKey genera una clave nueva y devuelve el valor (que solo se muestra una vez),
+// el prefijo visible y el hash que se guarda
+func GenerateKey() (key, prefix, hash string) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("error generando clave: %v", err))
+	}
+	key = keyPrefix + hex.EncodeToString(b)
+	return key, key[:len(keyPrefix)+8], HashKey(key)
+}
+
+// HashKey devuelve el hash SHA-256 de una clave
+// Las claves son aleatorias y largas, así que no necesitan un hash lento como las contraseñas
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator valida claves de API contra el almacén de claves y la clave de arranque
+type Authenticator struct {
+	store         repository.APIKeyStore
+	config        *Config
+	bootstrapHash string
+	tenant        string
+	jwt           *JWTVerifier
+}
+
+// NewAuthenticator crea un autenticador; store puede ser nil si no hay base de datos
+// La clave de arranque queda asociada a defaultTenant
+func NewAuthenticator(store repository.APIKeyStore, config *Config, defaultTenant string) *Authenticator {
+	if config == nil {
+		config = &Config{RotationOverlap: DefaultRotationOverlap, JWKSCacheTTL: DefaultJWKSCacheTTL}
+	}
+	a := &Authenticator{store: store, config: config, tenant: defaultTenant, jwt: NewJWTVerifier(config)}
+	if !config.BootstrapKey.IsZero() {
+		a.bootstrapHash = HashKey(config.BootstrapKey.Reveal())
+	}
+	return a
+}
+
+// Enabled indica si hay alguna forma de autenticarse configurada
+func (a *Authenticator) Enabled() bool {
+	return a.bootstrapHash != "" || a.store != nil || a.jwt != nil
+}
+
+// AuthenticateBearer valida un JWT y devuelve el principal de sus claims
+// Los tokens de empleado reciben solo el scope "self"; los de servicio, los scopes válidos de su claim scope
+// (admin solo se concede a claves de API) y deben indicar su tenant
+func (a *Authenticator) AuthenticateBearer(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrMissingCredentials
+	}
+	if a.jwt == nil {
+		return nil, fmt.Errorf("%w: autenticación JWT no configurada", ErrInvalidToken)
+	}
+
+	claims, err := a.jwt.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{
+		Method:          "jwt",
+		Name:            claims.Subject,
+		Tenant:          claims.Tenant,
+		EmployeeID:      claims.EmployeeID,
+		QuickpassUserID: claims.QuickpassUserID,
+	}
+	if principal.IsEmployee() {
+		if principal.Tenant == "" {
+			principal.Tenant = a.tenant
+		}
+		principal.Scopes = []string{ScopeSelf}
+		return principal, nil
+	}
+
+	// Tokens de servicio: JWT_SECRET lo comparten otros sistemas (el portal de Quickpass), por lo que
+	// un token no puede ser operador ni quedar en el tenant por defecto sin pedirlo
+	if principal.Tenant == "" {
+		return nil, fmt.Errorf("%w: el token de servicio debe indicar el tenant (claim tenant)", ErrInvalidToken)
+	}
+	for _, scope := range strings.Fields(claims.Scope) {
+		if scope == ScopeAdmin || strings.HasPrefix(scope, ScopeAdmin+":") {
+			return nil, fmt.Errorf("%w: el scope %s solo se concede a claves de API", ErrInvalidToken, scope)
+		}
+		if ValidateScopes([]string{scope}) == nil {
+			principal.Scopes = append(principal.Scopes, scope)
+		}
+	}
+	if len(principal.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: el token no identifica un empleado ni trae scopes", ErrInvalidToken)
+	}
+	return principal, nil
+}
+
+// Authenticate valida una clave y devuelve el principal correspondiente
+func (a *Authenticator) Authenticate(ctx context.Context, key string) (*Principal, error) {
+	if key == "" {
+		return nil, ErrMissingCredentials
+	}
+	hash := HashKey(key)
+
+	if a.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.bootstrapHash)) == 1 {
+		return &Principal{Method: "api_key", Name: "API_KEY", Tenant: a.tenant, Scopes: []string{ScopeAdmin}}, nil
+	}
+	if a.store == nil {
+		return nil, ErrInvalidKey
+	}
+
+	// La búsqueda es por hash: comparar el hash no revela información útil sobre la clave
+	stored, err := a.store.GetAPIKeyByHash(ctx, hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if stored.RevokedAt != nil || (stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt)) {
+		return nil, ErrInvalidKey
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= touchInterval {
+		if err := a.store.TouchAPIKey(context.WithoutCancel(ctx), stored.ID, now); err != nil {
+			// logging depende de este paquete (a través de redact): se usa el logger por defecto que configura
+			slog.WarnContext(ctx, "⚠️ Error registrando el uso de la clave de API", "component", "auth", "key_id", stored.ID, "error", err)
+		}
+	}
+
+	return &Principal{
+		Method: "api_key",
+		KeyID:  stored.ID,
+		Name:   stored.Name,
+		Tenant: stored.Tenant,
+		Scopes: stored.Scopes,
+	}, nil
+}
+
+// Create genera y guarda una clave nueva; devuelve el valor en claro, que no se puede recuperar después
+func (a *Authenticator) Create(ctx context.Context, tenant, name string, scopes []string, expiresAt *time.Time) (*repository.APIKey, string, error) {
+	return a.create(ctx, &repository.APIKey{
+		Tenant:    tenant,
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// create completa el prefijo y hash de una clave nueva y la guarda
+func (a *Authenticator) create(ctx context.Context, stored *repository.APIKey) (*repository.APIKey, string, error) {
+	if a.store == nil {
+		return nil, "", fmt.Errorf("base de datos no configurada")
+	}
+	if err := ValidateScopes(stored.Scopes); err != nil {
+		return nil, "", err
+	}
+
+	key, prefix, hash := GenerateKey()
+	stored.Prefix = prefix
+	stored.KeyHash = hash
+	if err := a.store.CreateAPIKey(ctx, stored); err != nil {
+		return nil, "", err
+	}
+	return stored, key, nil
+}
+
+// Rotate reemplaza una clave por una nueva con el mismo nombre y scopes
+// La clave anterior sigue siendo válida durante overlap (o RotationOverlap si es nil),
+// para que los clientes alcancen a cambiarla sin cortes
+func (a *Authenticator) Rotate(ctx context.Context, old *repository.APIKey, overlap *time.Duration) (*repository.APIKey, string, error) {
+	if old.RevokedAt != nil {
+		return nil, "", fmt.Errorf("%w: %d", ErrKeyRevoked, old.ID)
+	}
+	if overlap == nil {
+		overlap = &a.config.RotationOverlap
+	}
+
+	rotated, key, err := a.create(ctx, &repository.APIKey{
+		Tenant:      old.Tenant,
+		Name:        old.Name,
+		Scopes:      old.Scopes,
+		RotatedFrom: &old.ID,
+		ExpiresAt:   old.ExpiresAt,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	expiresAt := time.Now().UTC().Add(*overlap)
+	if old.ExpiresAt == nil || expiresAt.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &expiresAt
+		if err := a.store.UpdateAPIKey(ctx, old); err != nil {
+			return nil, "", err
+		}
+	}
+	return rotated, key, nil
+}
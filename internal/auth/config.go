@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// DefaultJWKSCacheTTL es cuánto se guardan en memoria las claves del JWKS
+const DefaultJWKSCacheTTL = time.Hour
+
+// DefaultRotationOverlap es cuánto sigue siendo válida una clave después de rotarla
+const DefaultRotationOverlap = 24 * time.Hour
+
+// Config contiene la configuración de autenticación de la API (ver config.Config)
+type Config struct {
+	// BootstrapKey es una clave con scope admin definida por entorno (API_KEY), útil para
+	// crear las primeras claves o para operar sin base de datos; vacía la deshabilita
+	BootstrapKey secret.Value `yaml:"api_key"`
+	// RotationOverlap es el período en que conviven la clave rotada y la nueva (API_KEY_ROTATION_OVERLAP, segundos)
+	RotationOverlap time.Duration `yaml:"api_key_rotation_overlap"`
+
+	// JWTSecret valida tokens HS256 (JWT_SECRET); vacío deshabilita HS256
+	JWTSecret secret.Value `yaml:"jwt_secret"`
+	// JWKSURL es la URL del JWKS con las claves públicas para tokens RS256 (JWT_JWKS_URL)
+	JWKSURL string `yaml:"jwks_url"`
+	// JWKSCacheTTL es cuánto se guardan las claves del JWKS (JWT_JWKS_CACHE_TTL, segundos)
+	JWKSCacheTTL time.Duration `yaml:"jwks_cache_ttl"`
+	// JWTIssuer es el emisor aceptado (JWT_ISSUER); vacío no valida el emisor
+	JWTIssuer string `yaml:"jwt_issuer"`
+	// JWTAudience es la audiencia que deben incluir los tokens (JWT_AUDIENCE); vacío no la valida
+	JWTAudience string `yaml:"jwt_audience"`
+}
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwtLeeway es la diferencia de reloj tolerada al validar exp, nbf e iat
+const jwtLeeway = time.Minute
+
+// jwksMinRefresh evita consultar el JWKS en cada token con un kid desconocido
+const jwksMinRefresh = 30 * time.Second
+
+// ErrInvalidToken se devuelve cuando el JWT no es válido (firma, formato, expiración, emisor o audiencia)
+var ErrInvalidToken = errors.New("token JWT inválido")
+
+// Audience es el claim "aud", que puede venir como texto o como lista
+type Audience []string
+
+// UnmarshalJSON acepta "aud": "x" y "aud": ["x", "y"]
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Claims son los claims reconocidos de un JWT
+// Un token de empleado trae employee_id (hr.employee de Odoo) o quickpass_user_id;
+// un token de servicio no trae ninguno y sus permisos vienen en scope
+type Claims struct {
+	Subject         string   `json:"sub"`
+	Issuer          string   `json:"iss"`
+	Audience        Audience `json:"aud"`
+	ExpiresAt       float64  `json:"exp"`
+	NotBefore       float64  `json:"nbf"`
+	IssuedAt        float64  `json:"iat"`
+	Tenant          string   `json:"tenant"`
+	EmployeeID      int      `json:"employee_id"`
+	QuickpassUserID string   `json:"quickpass_user_id"`
+	Scope           string   `json:"scope"` // Scopes separados por espacio
+}
+
+// jwtHeader es el encabezado de un JWT
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// JWTVerifier valida JWT firmados con HS256 (secreto compartido) o RS256 (claves publicadas en un JWKS)
+type JWTVerifier struct {
+	secret   []byte
+	jwks     *JWKSCache
+	issuer   string
+	audience string
+}
+
+// NewJWTVerifier crea un verificador; devuelve nil si no hay JWT_SECRET ni JWT_JWKS_URL
+func NewJWTVerifier(config *Config) *JWTVerifier {
+	if config.JWTSecret.IsZero() && config.JWKSURL == "" {
+		return nil
+	}
+	v := &JWTVerifier{issuer: config.JWTIssuer, audience: config.JWTAudience}
+	if !config.JWTSecret.IsZero() {
+		v.secret = []byte(config.JWTSecret.Reveal())
+	}
+	if config.JWKSURL != "" {
+		v.jwks = NewJWKSCache(config.JWKSURL, config.JWKSCacheTTL)
+	}
+	return v
+}
+
+// Verify valida la firma y los claims temporales, de emisor y de audiencia de un token
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: formato incorrecto", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: encabezado ilegible", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: firma ilegible", ErrInvalidToken)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	// El algoritmo determina la clave: un token HS256 nunca se valida con una clave pública
+	// ni viceversa, lo que evita ataques de confusión de algoritmo
+	switch header.Algorithm {
+	case "HS256":
+		if v.secret == nil {
+			return nil, fmt.Errorf("%w: HS256 no habilitado", ErrInvalidToken)
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return nil, fmt.Errorf("%w: firma incorrecta", ErrInvalidToken)
+		}
+
+	case "RS256":
+		if v.jwks == nil {
+			return nil, fmt.Errorf("%w: RS256 no habilitado", ErrInvalidToken)
+		}
+		key, err := v.jwks.Key(ctx, header.KeyID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, fmt.Errorf("%w: firma incorrecta", ErrInvalidToken)
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: algoritmo %q no soportado", ErrInvalidToken, header.Algorithm)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims ilegibles", ErrInvalidToken)
+	}
+	if err := v.validateClaims(&claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// validateClaims verifica exp (obligatorio), nbf, iat, iss y aud
+func (v *JWTVerifier) validateClaims(claims *Claims, now time.Time) error {
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: falta exp", ErrInvalidToken)
+	}
+	if now.Add(-jwtLeeway).After(unixTime(claims.ExpiresAt)) {
+		return fmt.Errorf("%w: token expirado", ErrInvalidToken)
+	}
+	if claims.NotBefore != 0 && now.Add(jwtLeeway).Before(unixTime(claims.NotBefore)) {
+		return fmt.Errorf("%w: token aún no válido", ErrInvalidToken)
+	}
+	if claims.IssuedAt != 0 && now.Add(jwtLeeway).Before(unixTime(claims.IssuedAt)) {
+		return fmt.Errorf("%w: iat en el futuro", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: emisor %q no aceptado", ErrInvalidToken, claims.Issuer)
+	}
+	if v.audience != "" {
+		found := false
+		for _, aud := range claims.Audience {
+			if aud == v.audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: audiencia no aceptada", ErrInvalidToken)
+		}
+	}
+	return nil
+}
+
+// unixTime convierte un NumericDate de JWT (segundos, puede traer decimales) a time.Time
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// decodeSegment decodifica un segmento base64url de un JWT como JSON
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// JWKSCache obtiene y guarda en memoria las claves públicas RSA publicadas en un JWKS
+// Las claves se refrescan al vencer el TTL o al recibir un kid desconocido (rotación de claves del emisor)
+type JWKSCache struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewJWKSCache crea el caché de claves de un JWKS
+func NewJWKSCache(url string, ttl time.Duration) *JWKSCache {
+	return &JWKSCache{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Key devuelve la clave pública con el kid indicado
+// Si el token no trae kid y el JWKS tiene una sola clave, se usa esa
+func (c *JWKSCache) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, found := c.lookup(kid)
+	stale := time.Since(c.fetchedAt) > c.ttl
+	if (stale || !found) && time.Since(c.lastAttempt) >= jwksMinRefresh {
+		if err := c.refresh(ctx); err != nil && c.keys == nil {
+			return nil, err
+		}
+		key, found = c.lookup(kid)
+	}
+	if !found {
+		return nil, fmt.Errorf("clave %q no encontrada en el JWKS", kid)
+	}
+	return key, nil
+}
+
+// lookup busca una clave en el caché (debe llamarse con c.mu tomado)
+func (c *JWKSCache) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// refresh descarga el JWKS; si falla se conservan las claves anteriores (debe llamarse con c.mu tomado)
+func (c *JWKSCache) refresh(ctx context.Context) error {
+	c.lastAttempt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("error creando petición al JWKS: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error obteniendo JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error obteniendo JWKS: HTTP %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("error leyendo JWKS: %w", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+const testJWTSecret = "jwt-secret-compartido"
+
+// signHS256 arma un JWT firmado con el secreto indicado
+func signHS256(t *testing.T, alg, key string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegments(t, map[string]interface{}{"alg": alg, "typ": "JWT"}, claims)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRS256 arma un JWT firmado con la clave privada RSA indicada
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegments(t, map[string]interface{}{"alg": "RS256", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeSegments(t *testing.T, header, claims map[string]interface{}) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+}
+
+// jwksServer publica la clave pública con el kid indicado
+func jwksServer(t *testing.T, key *rsa.PublicKey, kid string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJWTVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := jwksServer(t, &rsaKey.PublicKey, "k1")
+	verifier := NewJWTVerifier(&Config{
+		JWTSecret:    secret.New(testJWTSecret),
+		JWKSURL:      jwks.URL,
+		JWKSCacheTTL: time.Hour,
+		JWTIssuer:    "quickpass",
+		JWTAudience:  "odoo-sync",
+	})
+	rsaOnly := NewJWTVerifier(&Config{JWKSURL: jwks.URL, JWKSCacheTTL: time.Hour})
+
+	now := time.Now().Unix()
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "bi", "iss": "quickpass", "aud": "odoo-sync", "exp": now + 300, "scope": "employees:read"}
+		for key, value := range changes {
+			if value == nil {
+				delete(c, key)
+			} else {
+				c[key] = value
+			}
+		}
+		return c
+	}
+	valid := signHS256(t, "HS256", testJWTSecret, claims(nil))
+	publicKey, err := json.Marshal(rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		verifier *JWTVerifier
+		token    string
+		valid    bool
+	}{
+		{"HS256 válido", verifier, valid, true},
+		{"RS256 válido", verifier, signRS256(t, rsaKey, "k1", claims(nil)), true},
+		{"audiencia en lista", verifier, signHS256(t, "HS256", testJWTSecret, claims(map[string]interface{}{"aud": []string{"otro", "odoo-sync"}})), true},
+		{"expiración dentro de la tolerancia", verifier, signHS256(t, "HS256", testJWTSecret, claims(map[string]interface{}{"exp": now - 30})), true},
+		{"firma de otro secreto", verifier, signHS256(t, "HS256", "otro-secreto", claims(nil)), false},
+		{"claims modificados", verifier, valid[:len(valid)-4] + "AAAA", false},
+		{"alg none", verifier, encodeSegments(t, map[string]interface{}{"alg": "none"}, claims(nil)) + ".", false},
+		{"HS256 firmado con la clave pública", rsaOnly, signHS256(t, "HS256", string(publicKey), claims(nil)), false},
+		{"RS256 firmado con el secreto HS256", verifier, signHS256(t, "RS256", testJWTSecret, claims(nil)), false},
+		{"kid desconocido", verifier, signRS256(t, rsaKey, "k2", claims(nil)), false},
+		{"expirado", verifier, signHS256(t, "HS256", testJWTSecret, claims(map[string]interface{}{"exp": now - 120})), false},
+		{"sin exp", verifier, signHS256(t, "HS256", testJWTSecret, claims(map[string]interface{}{"exp": nil})), false},
+		{"nbf en el futuro", verifier, signHS256(t, "HS256", testJWTSecret, claims(map[string]interface{}{"nbf": now + 600})), false},
+		{"otra audiencia", verifier, signHS256(t, "HS256", testJWTSecret, claims(map[string]interface{}{"aud": "otro"})), false},
+		{"sin audiencia", verifier, signHS256(t, "HS256", testJWTSecret, claims(map[string]interface{}{"aud": nil})), false},
+		{"otro emisor", verifier, signHS256(t, "HS256", testJWTSecret, claims(map[string]interface{}{"iss": "otro"})), false},
+		{"formato incorrecto", verifier, "a.b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.verifier.Verify(context.Background(), tt.token)
+			if tt.valid && err != nil {
+				t.Fatalf("Verify() = %v, se esperaba un token válido", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Verify() = %v, se esperaba ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateBearer(t *testing.T) {
+	authenticator := NewAuthenticator(nil, &Config{JWTSecret: secret.New(testJWTSecret), JWKSCacheTTL: time.Hour}, "default")
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		tenant string
+		scopes []string
+	}{
+		{"empleado sin tenant usa el por defecto", map[string]interface{}{"employee_id": 7}, "default", []string{ScopeSelf}},
+		{"empleado no recibe los scopes del token", map[string]interface{}{"employee_id": 7, "tenant": "acme", "scope": "admin"}, "acme", []string{ScopeSelf}},
+		{"servicio con tenant", map[string]interface{}{"tenant": "acme", "scope": "employees:read pii:read"}, "acme", []string{ScopeEmployeesRead, ScopePIIRead}},
+		{"se ignoran los scopes desconocidos", map[string]interface{}{"tenant": "acme", "scope": "employees:read unknown"}, "acme", []string{ScopeEmployeesRead}},
+		{"servicio sin tenant", map[string]interface{}{"scope": "employees:read"}, "", nil},
+		{"servicio con admin", map[string]interface{}{"tenant": "default", "scope": "employees:read admin"}, "", nil},
+		{"servicio con admin:*", map[string]interface{}{"tenant": "acme", "scope": "admin:tenants"}, "", nil},
+		{"servicio sin scopes válidos", map[string]interface{}{"tenant": "acme", "scope": "unknown"}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["exp"] = exp
+			principal, err := authenticator.AuthenticateBearer(context.Background(), signHS256(t, "HS256", testJWTSecret, tt.claims))
+			if tt.scopes == nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("AuthenticateBearer() = %+v, %v; se esperaba ErrInvalidToken", principal, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal.Tenant != tt.tenant || principal.HasScope(ScopeAdmin) || len(principal.Scopes) != len(tt.scopes) {
+				t.Fatalf("principal %+v, se esperaba tenant %s y scopes %v", principal, tt.tenant, tt.scopes)
+			}
+			for i, scope := range tt.scopes {
+				if principal.Scopes[i] != scope {
+					t.Fatalf("scopes %v, se esperaban %v", principal.Scopes, tt.scopes)
+				}
+			}
+		})
+	}
+}
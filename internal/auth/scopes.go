@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Scopes que puede tener una clave de API
+const (
+	ScopeEmployeesRead   = "employees:read"   // Consultar empleados
+	ScopeEmployeesWrite  = "employees:write"  // Sincronizar empleados
+	ScopeAttendanceWrite = "attendance:write" // Sincronizar asistencias
+	ScopePIIRead         = "pii:read"         // Ver datos personales sin redactar (RUT, fecha de nacimiento, contacto privado)
+	ScopeAdmin           = "admin"            // Administración; incluye todos los demás scopes
+
+	// ScopeSelf lo reciben los tokens de empleado: solo permite consultar sus propios registros
+	// No se puede asignar a una clave de API
+	ScopeSelf = "self"
+)
+
+// Scopes son los scopes que se pueden asignar a claves de API y tokens de servicio
+var Scopes = []string{ScopeEmployeesRead, ScopeEmployeesWrite, ScopeAttendanceWrite, ScopePIIRead, ScopeAdmin}
+
+// ValidateScopes verifica que la lista no esté vacía y que todos los scopes existan
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("debe indicar al menos un scope (%s)", strings.Join(Scopes, ", "))
+	}
+	for _, scope := range scopes {
+		known := false
+		for _, valid := range Scopes {
+			if scope == valid {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("scope desconocido: %q", scope)
+		}
+	}
+	return nil
+}
+
+// Principal es quien hace la petición, ya autenticado
+type Principal struct {
+	Method string   // Mecanismo con que se autenticó: "api_key" o "jwt"
+	KeyID  int64    // Clave de API usada (0 para la clave de arranque API_KEY o un JWT)
+	Name   string   // Nombre descriptivo de la clave o "sub" del token
+	Tenant string   // Tenant al que pertenece la clave o el token
+	Scopes []string // Scopes concedidos
+
+	// Identidad del empleado en tokens de empleado (al menos uno de los dos)
+	EmployeeID      int    // hr.employee de Odoo
+	QuickpassUserID string // Usuario de Quickpass, se traduce a empleado con el mapeo de identidad
+}
+
+// IsEmployee indica si el principal es un empleado, que solo puede ver sus propios registros
+func (p *Principal) IsEmployee() bool {
+	return p.EmployeeID != 0 || p.QuickpassUserID != ""
+}
+
+// HasScope indica si el principal tiene alguno de los scopes indicados; admin los incluye todos
+func (p *Principal) HasScope(scopes ...string) bool {
+	for _, granted := range p.Scopes {
+		if granted == ScopeAdmin {
+			return true
+		}
+		for _, scope := range scopes {
+			if granted == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal agrega el principal autenticado al contexto de la petición
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext devuelve el principal autenticado de la petición, o nil si no hay
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
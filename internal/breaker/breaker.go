@@ -0,0 +1,125 @@
+// Package breaker implementa un circuit breaker para las llamadas a Odoo y Quickpass
+//
+// Tras Threshold fallas seguidas del servicio (errores de red o respuestas 5xx) el circuito se abre y
+// las llamadas fallan de inmediato con ErrOpen durante Cooldown, en lugar de esperar el timeout de un
+// servicio caído. Pasado ese tiempo queda semiabierto: se deja pasar una sola llamada de prueba, que
+// lo cierra si funciona o lo vuelve a abrir si falla
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Valores por defecto de New
+const (
+	DefaultThreshold = 5
+	DefaultCooldown  = 30 * time.Second
+)
+
+// ErrOpen indica que la llamada no se hizo porque el circuito está abierto
+var ErrOpen = errors.New("circuit breaker abierto: el servicio falló repetidamente, se reintentará más tarde")
+
+// State es el estado del circuito; su valor numérico es el de la métrica circuit_breaker_state
+type State int
+
+const (
+	Closed   State = 0 // Las llamadas pasan normalmente
+	HalfOpen State = 1 // Se está probando si el servicio se recuperó
+	Open     State = 2 // Las llamadas fallan con ErrOpen sin llegar al servicio
+)
+
+func (s State) String() string {
+	switch s {
+	case HalfOpen:
+		return "half_open"
+	case Open:
+		return "open"
+	}
+	return "closed"
+}
+
+// Breaker es un circuit breaker seguro para uso concurrente
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int       // Fallas seguidas con el circuito cerrado
+	openedAt time.Time // Momento en que se abrió el circuito
+	probing  bool      // Hay una llamada de prueba en curso con el circuito semiabierto
+}
+
+// New crea un circuito cerrado que se abre tras threshold fallas seguidas y prueba de nuevo tras cooldown
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	return &Breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow indica si una llamada puede hacerse; devuelve ErrOpen si el circuito está abierto
+// Cada llamada permitida debe informar su resultado con Done
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.current() {
+	case Open:
+		return ErrOpen
+	case HalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.state = HalfOpen
+		b.probing = true
+	}
+	return nil
+}
+
+// Done registra el resultado de una llamada permitida por Allow
+// failed indica una falla del servicio; los errores del llamador (ej: 4xx, cancelación) no cuentan
+func (b *Breaker) Done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := b.probing
+	b.probing = false
+	switch {
+	case !failed:
+		b.state = Closed
+		b.failures = 0
+	case probe:
+		b.open()
+	default:
+		b.failures++
+		if b.state == Closed && b.failures >= b.threshold {
+			b.open()
+		}
+	}
+}
+
+// State devuelve el estado actual del circuito
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current()
+}
+
+// current calcula el estado considerando si ya pasó el tiempo de espera; requiere b.mu
+func (b *Breaker) current() State {
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.cooldown {
+		return HalfOpen
+	}
+	return b.state
+}
+
+func (b *Breaker) open() {
+	b.state = Open
+	b.failures = 0
+	b.openedAt = b.now()
+}
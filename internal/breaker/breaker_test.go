@@ -0,0 +1,82 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestBreaker recorre el ciclo del circuito: se abre tras las fallas seguidas, rechaza llamadas durante
+// la espera, deja pasar una sola llamada de prueba y se cierra o se vuelve a abrir según su resultado
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC)
+	b := New(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	call := func(failed bool) error {
+		if err := b.Allow(); err != nil {
+			return err
+		}
+		b.Done(failed)
+		return nil
+	}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		failed  bool
+		wantErr bool
+		want    State
+	}{
+		{"primera falla", 0, true, false, Closed},
+		{"un éxito reinicia la cuenta", 0, false, false, Closed},
+		{"falla 1 de 3", 0, true, false, Closed},
+		{"falla 2 de 3", 0, true, false, Closed},
+		{"falla 3 de 3 abre el circuito", 0, true, false, Open},
+		{"abierto rechaza la llamada", 30 * time.Second, false, true, Open},
+		{"la prueba falla y se vuelve a abrir", 30 * time.Second, true, false, Open},
+		{"la espera se cuenta desde la nueva apertura", 30 * time.Second, false, true, Open},
+		{"la prueba funciona y se cierra", 30 * time.Second, false, false, Closed},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		err := call(step.failed)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: error = %v, se esperaba error: %v", step.name, err, step.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrOpen) {
+			t.Fatalf("%s: error = %v, se esperaba %v", step.name, err, ErrOpen)
+		}
+		if got := b.State(); got != step.want {
+			t.Fatalf("%s: estado = %s, se esperaba %s", step.name, got, step.want)
+		}
+	}
+}
+
+// TestBreakerSingleProbe verifica que con el circuito semiabierto solo pase una llamada a la vez
+func TestBreakerSingleProbe(t *testing.T) {
+	now := time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC)
+	b := New(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	if err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.Done(true)
+	now = now.Add(time.Minute)
+	if got := b.State(); got != HalfOpen {
+		t.Fatalf("estado = %s, se esperaba %s", got, HalfOpen)
+	}
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("la llamada de prueba fue rechazada: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("Allow() con una prueba en curso = %v, se esperaba %v", err, ErrOpen)
+	}
+	b.Done(false)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() con el circuito cerrado = %v", err)
+	}
+	b.Done(false)
+}
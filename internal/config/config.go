@@ -1,12 +1,202 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tracing"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/webhook"
+)
+
+// Entornos de ejecución (ENVIRONMENT)
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
 )
 
+// DefaultSQLitePath es el archivo de la base de datos SQLite cuando no se indica DATABASE_URL
+const DefaultSQLitePath = "data/odoo-quickpass-sync.db"
+
+// logLevels son los niveles aceptados en LOG_LEVEL
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// Config es la configuración de la aplicación
+// Cada valor se resuelve en este orden (el último gana): valor por defecto, archivo YAML
+// (--config o CONFIG_FILE), variable de entorno y flag de línea de comandos
+// Los secretos (DATABASE_URL, WEBHOOK_SECRET, API_KEY, JWT_SECRET...) también se leen de sus fuentes de
+// secretos (ver secret.Get); las credenciales de Odoo y Quickpass son de cada tenant (ver tenant.Load)
+type Config struct {
+	Environment string `yaml:"environment"`
+	LogLevel    string `yaml:"log_level"`
+	// LogLevels reemplaza LogLevel en algunos paquetes (ej: odoo: debug)
+	LogLevels map[string]string `yaml:"log_levels,omitempty"`
+	// LogFormat es json o text; por defecto json en producción y text en los demás entornos
+	LogFormat string         `yaml:"log_format"`
+	Server    ServerConfig   `yaml:"server"`
+	Database  DatabaseConfig `yaml:"database"`
+	// TenantsFile es el archivo JSON de tenants (vacío: un solo tenant con las variables ODOO_* y QUICKPASS_*)
+	TenantsFile string     `yaml:"tenants_file"`
+	Sync        SyncConfig `yaml:"sync"`
+	// Webhooks son los secretos de los webhooks entrantes del tenant por defecto y la tolerancia de las firmas
+	Webhooks webhook.Config `yaml:"webhooks"`
+	// Auth es la autenticación de /api/v1: clave de arranque y validación de JWT
+	Auth auth.Config `yaml:"auth"`
+	// Notify son los reintentos y el timeout de las notificaciones a suscriptores
+	Notify  notify.Config  `yaml:"notify"`
+	Tracing tracing.Config `yaml:"tracing"`
+
+	// file es el archivo YAML del que se leyó (vacío si no hay)
+	file string
+}
+
+// ServerConfig es la dirección en la que escucha el servidor HTTP
+type ServerConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// ShutdownTimeout es el plazo para terminar las peticiones y sincronizaciones en curso al apagarse
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// DatabaseConfig es la base de datos del estado de sincronización
+type DatabaseConfig struct {
+	Driver string       `yaml:"driver"` // sqlite | postgres
+	URL    secret.Value `yaml:"url"`    // Ruta del archivo (sqlite) o cadena de conexión (postgres, incluye la contraseña)
+}
+
+// SyncConfig son los valores globales de sincronización; cada tenant los puede reemplazar (ver tenant.SyncSettings)
+type SyncConfig struct {
+	// Interval es cada cuánto se sincronizan automáticamente los flujos habilitados (0: solo a pedido y por webhooks)
+	Interval       time.Duration `yaml:"interval"`
+	MaxRetries     int           `yaml:"max_retries"`
+	RetryDelay     time.Duration `yaml:"retry_delay"`
+	EmployeeSync   bool          `yaml:"employee_sync"`
+	AttendanceSync bool          `yaml:"attendance_sync"`
+	// TimeOffSync habilita la publicación de las ausencias de Odoo y las solicitudes de tiempo libre de Quickpass
+	TimeOffSync bool `yaml:"time_off_sync"`
+	// PayrollSync habilita la consulta de liquidaciones de sueldo de Odoo en el portal del empleado
+	PayrollSync bool `yaml:"payroll_sync"`
+}
+
+// ValidationError reúne todos los problemas encontrados en la configuración
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "configuración inválida:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (e *ValidationError) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// Flags son las opciones de línea de comandos, que tienen prioridad sobre el archivo y el entorno
+type Flags struct {
+	fs          *flag.FlagSet
+	file        string
+	printConfig bool
+	host        string
+	port        int
+	environment string
+	logLevel    string
+	driver      string
+	tenantsFile string
+}
+
+// RegisterFlags agrega al FlagSet las opciones de configuración; se leen en Load después de fs.Parse
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.file, "config", "", "Archivo de configuración YAML (también CONFIG_FILE)")
+	fs.BoolVar(&f.printConfig, "print-config", false, "Muestra la configuración efectiva (secretos enmascarados) y termina")
+	fs.StringVar(&f.host, "host", "", "Dirección en la que escucha el servidor (SERVER_HOST)")
+	fs.IntVar(&f.port, "port", 0, "Puerto del servidor (SERVER_PORT)")
+	fs.StringVar(&f.environment, "environment", "", "Entorno: development, staging o production (ENVIRONMENT)")
+	fs.StringVar(&f.logLevel, "log-level", "", "Nivel de log: debug, info, warn o error (LOG_LEVEL)")
+	fs.StringVar(&f.driver, "database-driver", "", "Base de datos: sqlite o postgres (DATABASE_DRIVER)")
+	fs.StringVar(&f.tenantsFile, "tenants-file", "", "Archivo JSON de tenants (TENANTS_FILE)")
+	return f
+}
+
+// PrintConfig indica si se pidió --print-config
+func (f *Flags) PrintConfig() bool {
+	return f != nil && f.printConfig
+}
+
+// apply copia a la configuración solo las flags indicadas explícitamente
+func (f *Flags) apply(c *Config) {
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "host":
+			c.Server.Host = f.host
+		case "port":
+			c.Server.Port = f.port
+		case "environment":
+			c.Environment = f.environment
+		case "log-level":
+			c.LogLevel = f.logLevel
+		case "database-driver":
+			c.Database.Driver = f.driver
+		case "tenants-file":
+			c.TenantsFile = f.tenantsFile
+		}
+	})
+}
+
+// Default devuelve la configuración por defecto
+func Default() *Config {
+	retry := syncer.DefaultRetryPolicy()
+	return &Config{
+		Environment: EnvDevelopment,
+		LogLevel:    "info",
+		Server: ServerConfig{
+			Host:            "0.0.0.0",
+			Port:            8080,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver: repository.DriverSQLite,
+		},
+		Sync: SyncConfig{
+			MaxRetries:     retry.MaxAttempts,
+			RetryDelay:     retry.BaseDelay,
+			EmployeeSync:   true,
+			AttendanceSync: true,
+			TimeOffSync:    true,
+			PayrollSync:    true,
+		},
+		Webhooks: webhook.Config{
+			Tolerance: webhook.DefaultTolerance,
+		},
+		Auth: auth.Config{
+			RotationOverlap: auth.DefaultRotationOverlap,
+			JWKSCacheTTL:    auth.DefaultJWKSCacheTTL,
+		},
+		Notify: *notify.DefaultConfig(),
+		Tracing: tracing.Config{
+			ServiceName: "odoo-quickpass-sync",
+			SampleRatio: 1,
+		},
+	}
+}
+
 // LoadEnv carga las variables de entorno desde el archivo .env
 func LoadEnv() error {
 	// Intentar cargar .env, pero no fallar si no existe
@@ -19,3 +209,340 @@ func LoadEnv() error {
 	}
 	return nil
 }
+
+// Load carga el archivo .env y arma la configuración desde el archivo YAML, el entorno y las flags
+// flags puede ser nil en los comandos que no las registran
+// Si algún valor es inválido devuelve un *ValidationError con todos los problemas a la vez
+func Load(flags *Flags) (*Config, error) {
+	if err := LoadEnv(); err != nil {
+		return nil, err
+	}
+
+	config := Default()
+	path := os.Getenv("CONFIG_FILE")
+	if flags != nil && flags.file != "" {
+		path = flags.file
+	}
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, err
+		}
+		config.file = path
+	}
+
+	problems := &ValidationError{}
+	config.loadEnv(problems)
+	if flags != nil {
+		flags.apply(config)
+	}
+	config.validate(problems)
+	if len(problems.Problems) > 0 {
+		return nil, problems
+	}
+	return config, nil
+}
+
+// loadFile lee el archivo YAML; las claves desconocidas son un error para detectar errores de tipeo
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error al leer el archivo de configuración: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error al leer el archivo de configuración %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv aplica las variables de entorno definidas; los valores que no se pueden leer se agregan a problems
+func (c *Config) loadEnv(problems *ValidationError) {
+	envString("ENVIRONMENT", &c.Environment)
+	envString("LOG_LEVEL", &c.LogLevel)
+	if value, ok := os.LookupEnv("LOG_LEVELS"); ok {
+		if levels, err := logging.ParseLevels(value); err != nil {
+			problems.add("LOG_LEVELS: %v", err)
+		} else {
+			c.LogLevels = levels
+		}
+	}
+	envString("LOG_FORMAT", &c.LogFormat)
+	envString("SERVER_HOST", &c.Server.Host)
+	// PORT se mantiene por compatibilidad con instalaciones anteriores; SERVER_PORT tiene prioridad
+	envInt(problems, "PORT", &c.Server.Port)
+	envInt(problems, "SERVER_PORT", &c.Server.Port)
+	envSeconds(problems, "SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	envString("DATABASE_DRIVER", &c.Database.Driver)
+	envSecret(problems, "DATABASE_URL", &c.Database.URL)
+	envString("TENANTS_FILE", &c.TenantsFile)
+	envSeconds(problems, "SYNC_INTERVAL", &c.Sync.Interval)
+	envInt(problems, "MAX_RETRIES", &c.Sync.MaxRetries)
+	envSeconds(problems, "RETRY_DELAY", &c.Sync.RetryDelay)
+	envBool(problems, "ENABLE_EMPLOYEE_SYNC", &c.Sync.EmployeeSync)
+	envBool(problems, "ENABLE_ATTENDANCE_SYNC", &c.Sync.AttendanceSync)
+	envBool(problems, "ENABLE_TIMEOFF_SYNC", &c.Sync.TimeOffSync)
+	envBool(problems, "ENABLE_PAYROLL_SYNC", &c.Sync.PayrollSync)
+	envSecret(problems, "WEBHOOK_SECRET", &c.Webhooks.OdooSecret)
+	envSecret(problems, "QUICKPASS_WEBHOOK_SECRET", &c.Webhooks.QuickpassSecret)
+	envSeconds(problems, "QUICKPASS_WEBHOOK_TOLERANCE", &c.Webhooks.Tolerance)
+	envSecret(problems, "API_KEY", &c.Auth.BootstrapKey)
+	envSeconds(problems, "API_KEY_ROTATION_OVERLAP", &c.Auth.RotationOverlap)
+	envSecret(problems, "JWT_SECRET", &c.Auth.JWTSecret)
+	envString("JWT_JWKS_URL", &c.Auth.JWKSURL)
+	envSeconds(problems, "JWT_JWKS_CACHE_TTL", &c.Auth.JWKSCacheTTL)
+	envString("JWT_ISSUER", &c.Auth.JWTIssuer)
+	envString("JWT_AUDIENCE", &c.Auth.JWTAudience)
+	envInt(problems, "NOTIFY_MAX_ATTEMPTS", &c.Notify.MaxAttempts)
+	envSeconds(problems, "NOTIFY_RETRY_DELAY", &c.Notify.RetryDelay)
+	envSeconds(problems, "NOTIFY_TIMEOUT", &c.Notify.Timeout)
+	envBool(problems, "TRACING_ENABLED", &c.Tracing.Enabled)
+	// Variables estándar de OpenTelemetry
+	envString("OTEL_EXPORTER_OTLP_ENDPOINT", &c.Tracing.Endpoint)
+	envString("OTEL_SERVICE_NAME", &c.Tracing.ServiceName)
+	envFloat(problems, "TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+	// OTEL_SDK_DISABLED=true deshabilita las trazas aunque TRACING_ENABLED sea true
+	disabled := false
+	envBool(problems, "OTEL_SDK_DISABLED", &disabled)
+	if disabled {
+		c.Tracing.Enabled = false
+	}
+}
+
+// validate completa los valores derivados y agrega a problems cada valor inválido
+func (c *Config) validate(problems *ValidationError) {
+	switch c.Environment {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		problems.add("ENVIRONMENT inválido: %q (use development, staging o production)", c.Environment)
+	}
+	if !contains(logLevels, c.LogLevel) {
+		problems.add("LOG_LEVEL inválido: %q (use %s)", c.LogLevel, strings.Join(logLevels, ", "))
+	}
+	for pkg, level := range c.LogLevels {
+		if !contains(logLevels, level) {
+			problems.add("LOG_LEVELS inválido para %s: %q (use %s)", pkg, level, strings.Join(logLevels, ", "))
+		}
+	}
+	if c.LogFormat == "" {
+		c.LogFormat = logging.FormatText
+		if c.Environment == EnvProduction {
+			c.LogFormat = logging.FormatJSON
+		}
+	}
+	if c.LogFormat != logging.FormatJSON && c.LogFormat != logging.FormatText {
+		problems.add("LOG_FORMAT inválido: %q (use json o text)", c.LogFormat)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problems.add("SERVER_PORT inválido: %d (use un puerto entre 1 y 65535)", c.Server.Port)
+	}
+	if c.Server.ShutdownTimeout < time.Second {
+		problems.add("SHUTDOWN_TIMEOUT inválido: %v (mínimo 1 segundo)", c.Server.ShutdownTimeout)
+	}
+
+	switch c.Database.Driver {
+	case repository.DriverSQLite:
+		if c.Database.URL.IsZero() {
+			c.Database.URL = secret.New(DefaultSQLitePath)
+		}
+	case repository.DriverPostgres:
+		if c.Database.URL.IsZero() {
+			problems.add("DATABASE_URL es obligatorio con DATABASE_DRIVER=postgres")
+		}
+	default:
+		problems.add("DATABASE_DRIVER no soportado: %q (use sqlite o postgres)", c.Database.Driver)
+	}
+
+	if c.TenantsFile != "" {
+		if _, err := os.Stat(c.TenantsFile); err != nil {
+			problems.add("TENANTS_FILE: %v", err)
+		}
+	}
+
+	if c.Sync.Interval != 0 && c.Sync.Interval < time.Minute {
+		problems.add("SYNC_INTERVAL inválido: %v (mínimo 1 minuto, o 0 para deshabilitar la sincronización periódica)", c.Sync.Interval)
+	}
+	if c.Sync.MaxRetries < 1 {
+		problems.add("MAX_RETRIES inválido: %d (mínimo 1)", c.Sync.MaxRetries)
+	}
+	if c.Sync.RetryDelay < time.Second {
+		problems.add("RETRY_DELAY inválido: %v (mínimo 1 segundo)", c.Sync.RetryDelay)
+	}
+
+	if c.Webhooks.Tolerance < time.Second {
+		problems.add("QUICKPASS_WEBHOOK_TOLERANCE inválido: %v (mínimo 1 segundo)", c.Webhooks.Tolerance)
+	}
+
+	if c.Auth.RotationOverlap < 0 {
+		problems.add("API_KEY_ROTATION_OVERLAP inválido: %v (no puede ser negativo)", c.Auth.RotationOverlap)
+	}
+	if c.Auth.JWKSCacheTTL < time.Second {
+		problems.add("JWT_JWKS_CACHE_TTL inválido: %v (mínimo 1 segundo)", c.Auth.JWKSCacheTTL)
+	}
+	if c.Auth.JWKSURL != "" {
+		if u, err := url.Parse(c.Auth.JWKSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems.add("JWT_JWKS_URL inválido: %q (use una URL http(s) absoluta)", c.Auth.JWKSURL)
+		}
+	}
+
+	if c.Notify.MaxAttempts < 1 {
+		problems.add("NOTIFY_MAX_ATTEMPTS inválido: %d (mínimo 1)", c.Notify.MaxAttempts)
+	}
+	if c.Notify.RetryDelay < time.Second {
+		problems.add("NOTIFY_RETRY_DELAY inválido: %v (mínimo 1 segundo)", c.Notify.RetryDelay)
+	}
+	if c.Notify.MaxDelay < c.Notify.RetryDelay {
+		problems.add("notify.max_delay inválido: %v (no puede ser menor que NOTIFY_RETRY_DELAY)", c.Notify.MaxDelay)
+	}
+	if c.Notify.Timeout < time.Second {
+		problems.add("NOTIFY_TIMEOUT inválido: %v (mínimo 1 segundo)", c.Notify.Timeout)
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems.add("TRACING_SAMPLE_RATIO inválido: %v (use un valor entre 0 y 1)", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems.add("OTEL_EXPORTER_OTLP_ENDPOINT inválido: %q (use una URL como http://localhost:4318)", c.Tracing.Endpoint)
+		}
+	}
+	if c.Tracing.Enabled && c.Tracing.ServiceName == "" {
+		problems.add("OTEL_SERVICE_NAME no puede estar vacío con TRACING_ENABLED=true")
+	}
+}
+
+// Print escribe la configuración efectiva en YAML; los secretos se muestran enmascarados
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return fmt.Errorf("error al generar la configuración: %w", err)
+	}
+	return encoder.Close()
+}
+
+// Addr devuelve la dirección host:puerto del servidor
+func (s ServerConfig) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// Repository devuelve la configuración para abrir la base de datos (ver repository.Open)
+func (d DatabaseConfig) Repository() *repository.Config {
+	return &repository.Config{Driver: d.Driver, DSN: d.URL}
+}
+
+// RetryPolicy devuelve la política de reintentos de los elementos fallidos
+func (s SyncConfig) RetryPolicy() syncer.RetryPolicy {
+	policy := syncer.DefaultRetryPolicy()
+	policy.MaxAttempts = s.MaxRetries
+	policy.BaseDelay = s.RetryDelay
+	return policy
+}
+
+// Flows devuelve los flujos habilitados para los tenants que no definen los suyos
+func (s SyncConfig) Flows() []string {
+	flows := []string{}
+	if s.EmployeeSync {
+		flows = append(flows, syncer.FlowEmployees)
+	}
+	if s.AttendanceSync {
+		flows = append(flows, syncer.FlowAttendance)
+	}
+	if s.TimeOffSync {
+		flows = append(flows, syncer.FlowTimeOff)
+	}
+	return flows
+}
+
+// envString reemplaza el valor si la variable está definida y no está vacía
+func envString(key string, dst *string) {
+	if value := envValue(key); value != "" {
+		*dst = value
+	}
+}
+
+// envSecret reemplaza el secreto si está configurado en alguna de sus fuentes (ver secret.Get)
+func envSecret(problems *ValidationError, key string, dst *secret.Value) {
+	value, err := secret.Get(key)
+	if err != nil {
+		problems.add("%s: %v", key, err)
+		return
+	}
+	if !value.IsZero() {
+		*dst = value
+	}
+}
+
+func envInt(problems *ValidationError, key string, dst *int) {
+	value := envValue(key)
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		problems.add("%s inválido: %q (debe ser un número entero)", key, value)
+		return
+	}
+	*dst = n
+}
+
+func envBool(problems *ValidationError, key string, dst *bool) {
+	value := envValue(key)
+	if value == "" {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		problems.add("%s inválido: %q (use true o false)", key, value)
+		return
+	}
+	*dst = b
+}
+
+func envFloat(problems *ValidationError, key string, dst *float64) {
+	value := envValue(key)
+	if value == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		problems.add("%s inválido: %q (debe ser un número)", key, value)
+		return
+	}
+	*dst = f
+}
+
+// envSeconds acepta segundos (300) o una duración de Go (5m)
+func envSeconds(problems *ValidationError, key string, dst *time.Duration) {
+	value := envValue(key)
+	if value == "" {
+		return
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		*dst = time.Duration(n) * time.Second
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		problems.add("%s inválido: %q (use segundos o una duración como 30s)", key, value)
+		return
+	}
+	*dst = d
+}
+
+// envValue lee una variable de entorno ignorando comentarios al final de la línea
+func envValue(key string) string {
+	value, _, _ := strings.Cut(os.Getenv(key), "#")
+	return strings.TrimSpace(value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
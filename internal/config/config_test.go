@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoadReportsAllProblems verifica que los valores inválidos de todas las secciones se informen juntos
+func TestLoadReportsAllProblems(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	for name, value := range map[string]string{
+		"SYNC_INTERVAL":               "30",
+		"ENABLE_PAYROLL_SYNC":         "quizás",
+		"QUICKPASS_WEBHOOK_TOLERANCE": "0",
+		"JWT_JWKS_URL":                "auth.example.com/jwks",
+		"NOTIFY_MAX_ATTEMPTS":         "0",
+	} {
+		t.Setenv(name, value)
+	}
+
+	_, err := Load(nil)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Load() = %v, se esperaba un *ValidationError", err)
+	}
+	for _, key := range []string{"SYNC_INTERVAL", "ENABLE_PAYROLL_SYNC", "QUICKPASS_WEBHOOK_TOLERANCE", "JWT_JWKS_URL", "NOTIFY_MAX_ATTEMPTS"} {
+		if !strings.Contains(invalid.Error(), key) {
+			t.Errorf("no se informó el problema de %s:\n%v", key, invalid)
+		}
+	}
+}
+
+// TestLoadSecretsAreMasked verifica que los secretos de webhooks y autenticación se lean del entorno
+// y que --print-config los muestre enmascarados
+func TestLoadSecretsAreMasked(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	secrets := map[string]string{
+		"WEBHOOK_SECRET":           "odoo-webhook-secret",
+		"QUICKPASS_WEBHOOK_SECRET": "quickpass-webhook-secret",
+		"API_KEY":                  "bootstrap-api-key",
+		"JWT_SECRET":               "portal-jwt-secret",
+	}
+	for name, value := range secrets {
+		t.Setenv(name, value)
+	}
+	t.Setenv("SYNC_INTERVAL", "300")
+	t.Setenv("ENABLE_PAYROLL_SYNC", "false")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Sync.Interval != 5*time.Minute || cfg.Sync.PayrollSync {
+		t.Errorf("sync = %+v, se esperaba interval 5m y payroll_sync false", cfg.Sync)
+	}
+	if cfg.Webhooks.OdooSecret.Reveal() != secrets["WEBHOOK_SECRET"] || cfg.Auth.BootstrapKey.Reveal() != secrets["API_KEY"] {
+		t.Error("no se leyeron los secretos del entorno")
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range secrets {
+		if strings.Contains(out.String(), value) {
+			t.Errorf("--print-config muestra %s sin enmascarar", name)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/webhook"
+)
+
+var logger = logging.For("config")
+
+// DefaultWatchInterval es cada cuánto se revisa si cambiaron los archivos de configuración
+const DefaultWatchInterval = 5 * time.Second
+
+// ReloadHook valida una configuración recargada para un componente sin cambiar nada todavía
+// Un error rechaza la recarga; si no, devuelve la función que la aplica (nil si no hay nada que aplicar),
+// que a su vez devuelve los cambios que requieren reiniciar. Las aplicaciones se ejecutan solo cuando
+// todos los hooks validaron, así una recarga rechazada no deja cambios a medias
+type ReloadHook func(next *Config) (apply func() (pending []string), err error)
+
+// Status es el estado de la configuración vigente
+type Status struct {
+	// Version aumenta con cada recarga aplicada (la configuración inicial es la versión 1)
+	Version  int       `json:"version"`
+	Checksum string    `json:"checksum"`
+	LoadedAt time.Time `json:"loaded_at"`
+	File     string    `json:"file,omitempty"`
+	// LastAttemptAt y LastError describen el último intento de recarga (aplicado o rechazado)
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	// PendingRestart son los cambios detectados que solo se aplican al reiniciar
+	PendingRestart []string `json:"pending_restart,omitempty"`
+}
+
+// Reloader mantiene la configuración vigente y la reemplaza de forma atómica al recargarla
+// Solo el nivel de log y la sincronización se aplican en caliente (además de lo que apliquen los hooks);
+// los cambios de entorno, servidor, base de datos, webhooks, autenticación, notificaciones o archivo de
+// tenants quedan pendientes de reinicio
+type Reloader struct {
+	flags   *Flags
+	current atomic.Pointer[Config]
+
+	mu     sync.Mutex
+	hooks  []ReloadHook
+	status Status
+}
+
+// NewReloader crea el recargador con la configuración inicial (ver Load); flags puede ser nil
+func NewReloader(initial *Config, flags *Flags) *Reloader {
+	r := &Reloader{flags: flags}
+	r.current.Store(initial)
+	r.status = Status{
+		Version:  1,
+		Checksum: initial.checksum(),
+		LoadedAt: time.Now().UTC(),
+		File:     initial.file,
+	}
+	return r
+}
+
+// Current devuelve la configuración vigente
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// OnReload registra un hook que se ejecuta en cada recarga, en el orden de registro
+func (r *Reloader) OnReload(hook ReloadHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// Status devuelve la versión y el resultado de la última recarga
+func (r *Reloader) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Reload vuelve a leer el archivo YAML y el entorno, valida y aplica la nueva configuración
+// Si es inválida o un hook la rechaza se mantiene la configuración anterior y se devuelve el error
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := time.Now().UTC()
+	r.status.LastAttemptAt = &attempt
+
+	next, err := Load(r.flags)
+	if err != nil {
+		r.status.LastError = err.Error()
+		return err
+	}
+	pending := next.keepStatic(r.Current())
+	applies := make([]func() []string, 0, len(r.hooks))
+	for _, hook := range r.hooks {
+		apply, err := hook(next)
+		if err != nil {
+			r.status.LastError = err.Error()
+			return err
+		}
+		if apply != nil {
+			applies = append(applies, apply)
+		}
+	}
+	for _, apply := range applies {
+		pending = append(pending, apply()...)
+	}
+
+	r.current.Store(next)
+	r.status.Version++
+	r.status.Checksum = next.checksum()
+	r.status.LoadedAt = attempt
+	r.status.LastError = ""
+	r.status.PendingRestart = pending
+	return nil
+}
+
+// Watch recarga la configuración al recibir SIGHUP o cuando cambia el archivo de configuración
+// o el de tenants (se revisan cada interval); bloquea hasta que ctx termina
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	files := r.watchedFiles()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reloadAndLog("SIGHUP recibido")
+		case <-ticker.C:
+			if files.changed() {
+				r.reloadAndLog("archivo de configuración modificado")
+			}
+		}
+	}
+}
+
+func (r *Reloader) reloadAndLog(reason string) {
+	logger.Info("🔄 Recargando configuración", "reason", reason)
+	if err := r.Reload(); err != nil {
+		logger.Error("❌ Recarga rechazada, se mantiene la configuración anterior", "error", err)
+		return
+	}
+	status := r.Status()
+	logger.Info("✅ Configuración aplicada", "version", status.Version, "checksum", status.Checksum)
+	for _, change := range status.PendingRestart {
+		logger.Warn("⚠️ Requiere reiniciar para aplicarse", "change", change)
+	}
+}
+
+// watchedFiles devuelve los archivos que se revisan: el YAML y el de tenants (sus rutas no se recargan)
+func (r *Reloader) watchedFiles() fileSet {
+	current := r.Current()
+	files := fileSet{}
+	for _, path := range []string{current.file, current.TenantsFile} {
+		if path != "" {
+			files[path] = stat(path)
+		}
+	}
+	return files
+}
+
+// fileSet guarda la fecha de modificación y el tamaño de cada archivo revisado
+type fileSet map[string]fileState
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func stat(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// changed actualiza el estado de los archivos e indica si alguno cambió
+func (f fileSet) changed() bool {
+	changed := false
+	for path, previous := range f {
+		if current := stat(path); current != previous {
+			f[path] = current
+			changed = true
+		}
+	}
+	return changed
+}
+
+// keepStatic conserva los valores que solo se aplican al iniciar y devuelve los que cambiaron
+func (c *Config) keepStatic(current *Config) []string {
+	var pending []string
+	if c.Environment != current.Environment {
+		pending = append(pending, "environment")
+		c.Environment = current.Environment
+	}
+	if c.LogFormat != current.LogFormat {
+		pending = append(pending, "log_format")
+		c.LogFormat = current.LogFormat
+	}
+	if c.Server != current.Server {
+		pending = append(pending, "server")
+		c.Server = current.Server
+	}
+	if c.Tracing != current.Tracing {
+		pending = append(pending, "tracing")
+		c.Tracing = current.Tracing
+	}
+	if c.Database.Driver != current.Database.Driver || c.Database.URL.Reveal() != current.Database.URL.Reveal() {
+		pending = append(pending, "database")
+		c.Database = current.Database
+	}
+	if !sameWebhooks(c.Webhooks, current.Webhooks) {
+		pending = append(pending, "webhooks")
+		c.Webhooks = current.Webhooks
+	}
+	if !sameAuth(c.Auth, current.Auth) {
+		pending = append(pending, "auth")
+		c.Auth = current.Auth
+	}
+	if c.Notify != current.Notify {
+		pending = append(pending, "notify")
+		c.Notify = current.Notify
+	}
+	if c.TenantsFile != current.TenantsFile {
+		pending = append(pending, "tenants_file")
+		c.TenantsFile = current.TenantsFile
+	}
+	if c.file != current.file {
+		pending = append(pending, "config_file")
+		c.file = current.file
+	}
+	return pending
+}
+
+// sameWebhooks compara dos configuraciones de webhooks, incluido el contenido de los secretos
+func sameWebhooks(a, b webhook.Config) bool {
+	return a.OdooSecret.Reveal() == b.OdooSecret.Reveal() && a.QuickpassSecret.Reveal() == b.QuickpassSecret.Reveal() &&
+		a.Tolerance == b.Tolerance
+}
+
+// sameAuth compara dos configuraciones de autenticación, incluido el contenido de los secretos
+func sameAuth(a, b auth.Config) bool {
+	return a.BootstrapKey.Reveal() == b.BootstrapKey.Reveal() && a.JWTSecret.Reveal() == b.JWTSecret.Reveal() &&
+		a.RotationOverlap == b.RotationOverlap && a.JWKSURL == b.JWKSURL && a.JWKSCacheTTL == b.JWKSCacheTTL &&
+		a.JWTIssuer == b.JWTIssuer && a.JWTAudience == b.JWTAudience
+}
+
+// Masked devuelve la configuración como mapa (las mismas claves del YAML) con los secretos enmascarados
+func (c *Config) Masked() (map[string]interface{}, error) {
+	var buf bytes.Buffer
+	if err := c.Print(&buf); err != nil {
+		return nil, err
+	}
+	masked := map[string]interface{}{}
+	if err := yaml.Unmarshal(buf.Bytes(), &masked); err != nil {
+		return nil, err
+	}
+	return masked, nil
+}
+
+// checksum identifica el contenido de la configuración (los secretos no participan: se enmascaran)
+func (c *Config) checksum() string {
+	var buf bytes.Buffer
+	if err := c.Print(&buf); err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:6])
+}
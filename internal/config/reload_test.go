@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestReloadIsAtomic verifica que un hook que rechaza la recarga impida aplicar los demás
+func TestReloadIsAtomic(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	initial, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloader := NewReloader(initial, nil)
+
+	applied := false
+	reloader.OnReload(func(next *Config) (func() []string, error) {
+		return func() []string {
+			applied = true
+			return nil
+		}, nil
+	})
+	rejected := errors.New("archivo de tenants inválido")
+	reject := true
+	reloader.OnReload(func(next *Config) (func() []string, error) {
+		if reject {
+			return nil, rejected
+		}
+		return func() []string { return []string{"tenant acme agregado"} }, nil
+	})
+
+	if err := reloader.Reload(); !errors.Is(err, rejected) {
+		t.Fatalf("Reload() = %v, se esperaba %v", err, rejected)
+	}
+	status := reloader.Status()
+	if applied {
+		t.Error("la recarga rechazada aplicó el primer hook")
+	}
+	if status.Version != 1 || status.LastError != rejected.Error() {
+		t.Errorf("estado tras el rechazo: versión %d, error %q", status.Version, status.LastError)
+	}
+	if reloader.Current() != initial {
+		t.Error("la recarga rechazada reemplazó la configuración vigente")
+	}
+
+	reject = false
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	status = reloader.Status()
+	if !applied {
+		t.Error("la recarga aceptada no aplicó el primer hook")
+	}
+	if status.Version != 2 || status.LastError != "" || len(status.PendingRestart) != 1 {
+		t.Errorf("estado tras la recarga: %+v", status)
+	}
+}
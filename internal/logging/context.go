@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDKey struct{}
+
+type attrsKey struct{}
+
+// WithRequestID guarda en el contexto el identificador de la petición (X-Request-ID)
+// Todos los mensajes que se registran con ese contexto lo incluyen como request_id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID devuelve el identificador de la petición guardado en el contexto
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// With agrega atributos a todos los mensajes que se registren con el contexto devuelto
+// (ej: el tenant y la ejecución de una sincronización, para las llamadas a Odoo que haga)
+func With(ctx context.Context, args ...any) context.Context {
+	record := slog.Record{}
+	record.Add(args...)
+	added, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	attrs := append([]slog.Attr(nil), added...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+// contextAttrs devuelve el request_id, la traza y el span en curso y los atributos agregados con With
+func contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	var attrs []slog.Attr
+	if id := RequestID(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		attrs = append(attrs, slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	added, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return append(attrs, added...)
+}
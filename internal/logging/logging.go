@@ -0,0 +1,190 @@
+// Package logging configura log/slog para todo el servicio: JSON en producción, niveles por paquete
+// que se pueden cambiar en caliente, el identificador de petición tomado del contexto y redacción de
+// credenciales y datos personales
+//
+// Cada paquete obtiene su logger con For("odoo") al declararlo como variable de paquete; los mensajes
+// del paquete log (log.Printf) pasan por el mismo handler con el nivel global
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/redact"
+)
+
+// Formatos de salida
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+var (
+	// root es el handler que escribe los mensajes; For lo consulta en cada mensaje, así los loggers
+	// declarados antes de Setup (variables de paquete) usan la configuración final
+	root atomic.Pointer[slog.Handler]
+	// global es el nivel de los paquetes que no tienen uno propio (LOG_LEVEL)
+	global = new(slog.LevelVar)
+	// levels son los niveles por paquete (LOG_LEVELS)
+	levels atomic.Pointer[map[string]slog.Level]
+)
+
+func init() {
+	var h slog.Handler = slog.NewTextHandler(redact.NewWriter(log.Writer()), handlerOptions())
+	root.Store(&h)
+	levels.Store(&map[string]slog.Level{})
+}
+
+// Setup define el formato (json o text) y la salida de todos los loggers, incluido el paquete log
+func Setup(out io.Writer, format string) error {
+	var h slog.Handler
+	switch format {
+	case FormatJSON:
+		h = slog.NewJSONHandler(redact.NewWriter(out), handlerOptions())
+	case FormatText, "":
+		h = slog.NewTextHandler(redact.NewWriter(out), handlerOptions())
+	default:
+		return fmt.Errorf("formato de log inválido: %s (use json o text)", format)
+	}
+	root.Store(&h)
+	slog.SetDefault(slog.New(&handler{}))
+	return nil
+}
+
+// handlerOptions deja pasar todos los niveles (los filtra handler) y aplica la redacción
+func handlerOptions() *slog.HandlerOptions {
+	return &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: redactAttr}
+}
+
+// ParseLevel interpreta debug, info, warn (o warning) y error
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("nivel de log inválido: %s (use debug, info, warn o error)", value)
+}
+
+// ParseLevels interpreta "odoo=debug,syncer=warn" (el formato de LOG_LEVELS)
+func ParseLevels(value string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pkg, level, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(pkg) == "" {
+			return nil, fmt.Errorf("nivel por paquete inválido: %q (use paquete=nivel)", entry)
+		}
+		parsed[strings.TrimSpace(pkg)] = strings.TrimSpace(level)
+	}
+	return parsed, nil
+}
+
+// SetLevels cambia el nivel global y los niveles por paquete (los paquetes que no aparecen usan el global)
+// Se puede llamar en cualquier momento: los loggers existentes lo aplican desde el próximo mensaje
+func SetLevels(level string, perPackage map[string]string) error {
+	apply, err := PrepareLevels(level, perPackage)
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// PrepareLevels valida los niveles sin aplicarlos y devuelve la función que los aplica (ver SetLevels)
+func PrepareLevels(level string, perPackage map[string]string) (func(), error) {
+	parsedGlobal, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	parsed := make(map[string]slog.Level, len(perPackage))
+	for pkg, value := range perPackage {
+		l, err := ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pkg, err)
+		}
+		parsed[pkg] = l
+	}
+	return func() {
+		global.Set(parsedGlobal)
+		levels.Store(&parsed)
+	}, nil
+}
+
+// Levels describe los niveles vigentes (ej: "info odoo=debug"), para mostrarlos al recargar
+func Levels() string {
+	current := *levels.Load()
+	parts := []string{strings.ToLower(global.Level().String())}
+	pkgs := make([]string, 0, len(current))
+	for pkg := range current {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		parts = append(parts, pkg+"="+strings.ToLower(current[pkg].String()))
+	}
+	return strings.Join(parts, " ")
+}
+
+// For devuelve el logger de un paquete: sus mensajes llevan component=<pkg> y respetan el nivel del paquete
+func For(pkg string) *slog.Logger {
+	return slog.New(&handler{pkg: pkg})
+}
+
+// handler filtra por el nivel del paquete, agrega los atributos del contexto y delega en root
+type handler struct {
+	pkg string
+	ops []func(slog.Handler) slog.Handler // WithAttrs y WithGroup, en orden
+}
+
+func (h *handler) level() slog.Level {
+	if l, ok := (*levels.Load())[h.pkg]; ok && h.pkg != "" {
+		return l
+	}
+	return global.Level()
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	next := *root.Load()
+	if h.pkg != "" {
+		next = next.WithAttrs([]slog.Attr{slog.String("component", h.pkg)})
+	}
+	if attrs := contextAttrs(ctx); len(attrs) > 0 {
+		next = next.WithAttrs(attrs)
+	}
+	for _, op := range h.ops {
+		next = op(next)
+	}
+	return next.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *handler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := append(append([]func(slog.Handler) slog.Handler(nil), h.ops...), op)
+	return &handler{pkg: h.pkg, ops: ops}
+}
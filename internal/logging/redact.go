@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// hidden reemplaza el valor de los atributos con credenciales o datos personales
+const hidden = "***"
+
+// sensitive son fragmentos de nombres de atributos cuyo valor nunca se escribe: credenciales y datos
+// personales que no se pueden enmascarar parcialmente. Los RUT y emails que aparezcan en cualquier
+// mensaje o atributo los enmascara además redact.Writer en la salida
+var sensitive = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "cookie",
+	"credential", "private_key", "master_key",
+	"birthday", "phone", "street", "address",
+}
+
+// redactAttr oculta los atributos sensibles; los secret.Value ya se enmascaran solos (LogValuer)
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, fragment := range sensitive {
+		if strings.Contains(key, fragment) {
+			return slog.String(attr.Key, hidden)
+		}
+	}
+	return attr
+}
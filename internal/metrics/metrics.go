@@ -0,0 +1,274 @@
+// Package metrics expone métricas en el formato de texto de Prometheus sin dependencias externas
+//
+// Cada paquete declara sus métricas como variables de paquete (NewCounterVec, NewHistogramVec,
+// NewGaugeVec) y Handler las escribe todas en GET /metrics
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets son los límites (en segundos) de los histogramas de latencia de peticiones
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector es una métrica registrada que se escribe en /metrics
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registered = map[string]bool{}
+	collectors []collector
+)
+
+// register agrega una métrica; un nombre repetido es un error de programación
+func register(name string, c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registered[name] {
+		panic(fmt.Sprintf("metrics: la métrica %s ya está registrada", name))
+	}
+	registered[name] = true
+	collectors = append(collectors, c)
+}
+
+// WriteTo escribe todas las métricas registradas en el formato de texto de Prometheus
+func WriteTo(w io.Writer) error {
+	registryMu.Lock()
+	current := append([]collector(nil), collectors...)
+	registryMu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for _, c := range current {
+		c.write(buf)
+	}
+	return buf.Flush()
+}
+
+// Handler responde GET /metrics con todas las métricas registradas
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteTo(w)
+	})
+}
+
+// desc describe una métrica y sus etiquetas
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, strings.ReplaceAll(d.help, "\n", " "), d.name, d.kind)
+}
+
+// key identifica una serie por los valores de sus etiquetas
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s espera %d etiquetas y recibió %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs arma {a="x",b="y"}; extra agrega una etiqueta al final (ej: le en los histogramas)
+func (d desc) labelPairs(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(values)+1)
+	for i, label := range d.labels {
+		pairs = append(pairs, label+`="`+escape(values[i])+`"`)
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+`="`+escape(extra[1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys devuelve las claves ordenadas para que la salida sea estable entre lecturas
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// valueSeries es una serie de un contador o un gauge
+type valueSeries struct {
+	labels []string
+	value  float64
+}
+
+// CounterVec es un contador con etiquetas (solo aumenta)
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*valueSeries
+}
+
+// NewCounterVec crea y registra un contador
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, kind: "counter", labels: labels}, series: map[string]*valueSeries{}}
+	register(name, c)
+	return c
+}
+
+// Inc suma 1 a la serie con los valores de etiquetas indicados (en el orden de NewCounterVec)
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add suma delta (no negativo) a la serie indicada
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: el contador %s no puede disminuir", c.name))
+	}
+	key := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &valueSeries{labels: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.value += delta
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(s.labels), formatFloat(s.value))
+	}
+}
+
+// GaugeVec es un valor con etiquetas que puede subir y bajar
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*valueSeries
+}
+
+// NewGaugeVec crea y registra un gauge
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, series: map[string]*valueSeries{}}
+	register(name, g)
+	return g
+}
+
+// Set fija el valor de la serie indicada
+func (g *GaugeVec) Set(value float64, values ...string) {
+	key := g.key(values)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.series[key] = &valueSeries{labels: append([]string(nil), values...), value: value}
+}
+
+// Reset elimina todas las series (ej: antes de volver a calcularlas al leer /metrics)
+func (g *GaugeVec) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.series = map[string]*valueSeries{}
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	for _, key := range sortedKeys(g.series) {
+		s := g.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelPairs(s.labels), formatFloat(s.value))
+	}
+}
+
+// histogramSeries guarda la cantidad de observaciones por bucket (no acumulada), la suma y el total
+type histogramSeries struct {
+	labels []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec cuenta observaciones (ej: duraciones en segundos) en buckets con etiquetas
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// NewHistogramVec crea y registra un histograma con los límites de buckets indicados (ordenados)
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: append([]float64(nil), buckets...),
+		series:  map[string]*histogramSeries{},
+	}
+	sort.Float64s(h.buckets)
+	register(name, h)
+	return h
+}
+
+// Observe registra un valor en la serie indicada
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labels: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(s.labels, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(s.labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(s.labels), s.count)
+	}
+}
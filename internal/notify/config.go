@@ -0,0 +1,37 @@
+package notify
+
+import "time"
+
+// Config define los reintentos y el timeout de las entregas a suscriptores (ver config.Config)
+type Config struct {
+	// MaxAttempts es el número máximo de intentos por entrega (NOTIFY_MAX_ATTEMPTS)
+	MaxAttempts int `yaml:"max_attempts"`
+	// RetryDelay es la espera antes del primer reintento; se duplica en cada intento (NOTIFY_RETRY_DELAY, segundos)
+	RetryDelay time.Duration `yaml:"retry_delay"`
+	// MaxDelay es la espera máxima entre reintentos
+	MaxDelay time.Duration `yaml:"max_delay"`
+	// Timeout es el tiempo máximo de espera de la respuesta del suscriptor (NOTIFY_TIMEOUT, segundos)
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// DefaultConfig devuelve la configuración por defecto (6 intentos desde 30 segundos, timeout de 10 segundos)
+func DefaultConfig() *Config {
+	return &Config{
+		MaxAttempts: 6,
+		RetryDelay:  30 * time.Second,
+		MaxDelay:    time.Hour,
+		Timeout:     10 * time.Second,
+	}
+}
+
+// Backoff devuelve la espera antes del intento siguiente al número "attempt" (1, 2, 3...)
+func (c *Config) Backoff(attempt int) time.Duration {
+	delay := c.RetryDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= c.MaxDelay {
+			return c.MaxDelay
+		}
+	}
+	return delay
+}
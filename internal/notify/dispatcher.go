@@ -0,0 +1,286 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+var logger = logging.For("notify")
+
+// pollInterval es cada cuánto se revisan las entregas con reintento vencido
+const pollInterval = 5 * time.Second
+
+// maxErrorBody es cuánto de la respuesta de un suscriptor se guarda como error
+const maxErrorBody = 512
+
+// Dispatcher registra y envía los eventos a las suscripciones que coinciden
+// Cada entrega se guarda antes de enviarse, así un reinicio no pierde notificaciones pendientes
+type Dispatcher struct {
+	repo   repository.SubscriptionStore
+	config *Config
+	client *http.Client
+
+	wake   chan struct{}
+	stop   chan struct{}
+	cancel context.CancelFunc // Cancela el lote en curso (ver Shutdown)
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher crea un despachador de notificaciones
+func NewDispatcher(repo repository.SubscriptionStore, config *Config) *Dispatcher {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Dispatcher{
+		repo:   repo,
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Start inicia el envío en segundo plano de las entregas pendientes
+func (d *Dispatcher) Start() {
+	d.stop = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			d.ProcessDue(ctx)
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			case <-d.wake:
+			}
+		}
+	}()
+}
+
+// Stop detiene el envío; las entregas pendientes se retoman en el próximo inicio
+func (d *Dispatcher) Stop() {
+	if d.stop == nil {
+		return
+	}
+	close(d.stop)
+	d.wg.Wait()
+	d.cancel()
+}
+
+// Shutdown detiene el envío dejando terminar el lote en curso; si ctx vence antes, las entregas
+// que falten quedan pendientes y se retoman en el próximo inicio
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	if d.stop == nil {
+		return nil
+	}
+	close(d.stop)
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	defer d.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Publish registra una entrega por cada suscripción activa del tenant interesada en el evento
+func (d *Dispatcher) Publish(ctx context.Context, tenant, eventType string, data interface{}) error {
+	subs, err := d.repo.ListSubscriptions(ctx, tenant)
+	if err != nil {
+		return err
+	}
+
+	event := NewEvent(tenant, eventType, data)
+	var payload []byte
+	queued := 0
+	for _, sub := range subs {
+		if !sub.Active || !Matches(sub.Events, eventType) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("error serializando evento: %w", err)
+			}
+		}
+		if _, err := d.enqueue(ctx, sub, event, payload); err != nil {
+			return err
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		logger.InfoContext(ctx, "📣 Evento encolado", "tenant", tenant, "event_type", eventType, "event_id", event.ID, "subscribers", queued)
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Ping envía de inmediato un evento de prueba a una suscripción y devuelve el resultado
+// Se envía aunque la suscripción esté inactiva o no tenga "ping" entre sus eventos
+func (d *Dispatcher) Ping(ctx context.Context, sub *repository.Subscription) (*repository.Delivery, error) {
+	event := NewEvent(sub.Tenant, Ping, map[string]interface{}{
+		"subscription_id": sub.ID,
+		"message":         "Notificación de prueba",
+	})
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando evento: %w", err)
+	}
+
+	delivery, err := d.enqueue(ctx, sub, event, payload)
+	if err != nil {
+		return nil, err
+	}
+	d.attempt(ctx, sub, delivery)
+	return delivery, nil
+}
+
+// enqueue guarda una entrega pendiente lista para enviarse
+func (d *Dispatcher) enqueue(ctx context.Context, sub *repository.Subscription, event *Event, payload []byte) (*repository.Delivery, error) {
+	next := time.Now().UTC()
+	delivery := &repository.Delivery{
+		SubscriptionID: sub.ID,
+		Tenant:         sub.Tenant,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        payload,
+		Status:         repository.DeliveryPending,
+		NextAttemptAt:  &next,
+	}
+	if err := d.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ProcessDue envía las entregas cuyo próximo intento ya venció
+func (d *Dispatcher) ProcessDue(ctx context.Context) {
+	deliveries, err := d.repo.DueDeliveries(ctx, time.Now().UTC(), 100)
+	if err != nil {
+		logger.WarnContext(ctx, "⚠️ Error obteniendo entregas pendientes", "error", err)
+		return
+	}
+
+	subs := map[int64]*repository.Subscription{}
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			// Las entregas que faltan siguen pendientes
+			return
+		}
+		sub, ok := subs[delivery.SubscriptionID]
+		if !ok {
+			sub, err = d.repo.GetSubscription(ctx, delivery.SubscriptionID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				logger.WarnContext(ctx, "⚠️ Error obteniendo suscripción", "tenant", delivery.Tenant, "subscription", delivery.SubscriptionID, "error", err)
+				continue
+			}
+			subs[delivery.SubscriptionID] = sub
+		}
+
+		if sub == nil || !sub.Active {
+			// La suscripción se eliminó o desactivó después de encolar el evento
+			delivery.Status = repository.DeliveryFailed
+			delivery.Error = "la suscripción no existe o está inactiva"
+			delivery.NextAttemptAt = nil
+			d.save(delivery)
+			continue
+		}
+		d.attempt(ctx, sub, delivery)
+	}
+}
+
+// attempt envía una entrega y guarda el resultado; si falla se programa un reintento con backoff
+func (d *Dispatcher) attempt(ctx context.Context, sub *repository.Subscription, delivery *repository.Delivery) {
+	delivery.Attempts++
+	status, err := d.send(ctx, sub, delivery)
+	delivery.ResponseStatus = status
+
+	if err == nil {
+		deliveredAt := time.Now().UTC()
+		delivery.Status = repository.DeliveryDelivered
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &deliveredAt
+		d.save(delivery)
+		return
+	}
+
+	delivery.Error = err.Error()
+	if delivery.Attempts < d.config.MaxAttempts && delivery.EventType != Ping {
+		next := time.Now().UTC().Add(d.config.Backoff(delivery.Attempts))
+		delivery.Status = repository.DeliveryPending
+		delivery.NextAttemptAt = &next
+		logger.WarnContext(ctx, "⚠️ Entrega fallida; se reintentará", "tenant", delivery.Tenant, "delivery", delivery.ID,
+			"url", sub.URL, "attempt", delivery.Attempts, "next_attempt_at", next.Format(time.RFC3339), "error", err)
+	} else {
+		delivery.Status = repository.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		logger.ErrorContext(ctx, "❌ Entrega fallida definitivamente", "tenant", delivery.Tenant, "delivery", delivery.ID,
+			"url", sub.URL, "attempts", delivery.Attempts, "error", err)
+	}
+	d.save(delivery)
+}
+
+// send hace el POST firmado al suscriptor y devuelve el código HTTP recibido
+func (d *Dispatcher) send(ctx context.Context, sub *repository.Subscription, delivery *repository.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creando petición: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "odoo-quickpass-sync")
+	req.Header.Set("X-Sync-Event", delivery.EventType)
+	req.Header.Set("X-Sync-Event-Id", delivery.EventID)
+	req.Header.Set("X-Sync-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Sync-Timestamp", timestamp)
+	req.Header.Set("X-Sync-Signature", "sha256="+Sign(sub.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error enviando evento: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("el suscriptor respondió HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// save guarda el resultado de una entrega
+func (d *Dispatcher) save(delivery *repository.Delivery) {
+	if err := d.repo.UpdateDelivery(context.Background(), delivery); err != nil {
+		logger.Warn("⚠️ Error actualizando entrega", "tenant", delivery.Tenant, "delivery", delivery.ID, "error", err)
+	}
+}
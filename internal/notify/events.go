@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tipos de evento que se notifican a los suscriptores
+const (
+	EmployeeCreated    = "employee.created"    // Empleado creado en Quickpass por la sincronización
+	EmployeeUpdated    = "employee.updated"    // Datos del empleado sincronizados con cambios
+	EmployeeArchived   = "employee.archived"   // Empleado desactivado al dejar de estar activo en Odoo
+	ContractUpdated    = "contract.updated"    // Contrato creado o modificado en Odoo
+	LeaveRequested     = "leave.requested"     // Solicitud de tiempo libre de Quickpass registrada en Odoo
+	LeaveUpdated       = "leave.updated"       // Ausencia creada o modificada en Odoo
+	AttendanceRecorded = "attendance.recorded" // Marcación de Quickpass registrada en hr.attendance
+	Ping               = "ping"                // Evento de prueba enviado a pedido de un administrador
+)
+
+// EventTypes son los tipos de evento a los que se puede suscribir
+var EventTypes = []string{
+	EmployeeCreated, EmployeeUpdated, EmployeeArchived,
+	ContractUpdated,
+	LeaveRequested, LeaveUpdated,
+	AttendanceRecorded,
+}
+
+// Event es el cuerpo JSON que se envía a los suscriptores
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Tenant     string      `json:"tenant"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// NewEvent crea un evento con un ID único
+func NewEvent(tenant, eventType string, data interface{}) *Event {
+	return &Event{
+		ID:         "evt_" + randomHex(12),
+		Type:       eventType,
+		Tenant:     tenant,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// Matches indica si un tipo de evento coincide con alguno de los filtros de una suscripción
+// Un filtro puede ser un tipo exacto ("employee.updated"), un grupo ("employee.*") o "*"
+func Matches(filters []string, eventType string) bool {
+	for _, filter := range filters {
+		switch {
+		case filter == "*", filter == eventType:
+			return true
+		case strings.HasSuffix(filter, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(filter, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateFilters verifica que cada filtro corresponda a algún tipo de evento conocido
+func ValidateFilters(filters []string) error {
+	if len(filters) == 0 {
+		return fmt.Errorf("debe indicar al menos un evento")
+	}
+	for _, filter := range filters {
+		known := false
+		for _, eventType := range EventTypes {
+			if Matches([]string{filter}, eventType) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("evento desconocido: %q", filter)
+		}
+	}
+	return nil
+}
+
+// Sign firma "<timestamp>.<body>" con HMAC-SHA256 (mismo esquema que los webhooks de Quickpass)
+// Incluir el timestamp permite al suscriptor rechazar reenvíos de entregas antiguas
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret genera un secreto aleatorio para firmar las entregas de una suscripción
+func GenerateSecret() string {
+	return "whsec_" + randomHex(24)
+}
+
+// randomHex devuelve n bytes aleatorios en hexadecimal
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("error generando valor aleatorio: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
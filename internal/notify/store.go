@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// ErrNoEncryptionKey se devuelve al guardar el secreto de una suscripción sin clave de cifrado
+var ErrNoEncryptionKey = errors.New("las suscripciones requieren TENANTS_ENCRYPTION_KEY para guardar su secreto cifrado")
+
+// SealedStore guarda cifrado el secreto con que se firman las entregas de cada suscripción
+// Las suscripciones guardadas sin cifrar por versiones anteriores se siguen leyendo; SealStored las cifra
+type SealedStore struct {
+	repository.SubscriptionStore
+	sealer *secret.Sealer // nil sin TENANTS_ENCRYPTION_KEY: no se pueden crear ni editar suscripciones
+}
+
+// NewSealedStore cifra con sealer los secretos de las suscripciones guardadas en store
+func NewSealedStore(store repository.SubscriptionStore, sealer *secret.Sealer) *SealedStore {
+	return &SealedStore{SubscriptionStore: store, sealer: sealer}
+}
+
+// CreateSubscription guarda la suscripción con su secreto cifrado; sub conserva el secreto sin cifrar
+func (s *SealedStore) CreateSubscription(ctx context.Context, sub *repository.Subscription) error {
+	return s.withSealedSecret(sub, func() error { return s.SubscriptionStore.CreateSubscription(ctx, sub) })
+}
+
+// UpdateSubscription actualiza la suscripción con su secreto cifrado; sub conserva el secreto sin cifrar
+func (s *SealedStore) UpdateSubscription(ctx context.Context, sub *repository.Subscription) error {
+	return s.withSealedSecret(sub, func() error { return s.SubscriptionStore.UpdateSubscription(ctx, sub) })
+}
+
+// GetSubscription obtiene una suscripción con su secreto descifrado
+func (s *SealedStore) GetSubscription(ctx context.Context, id int64) (*repository.Subscription, error) {
+	sub, err := s.SubscriptionStore.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.open(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ListSubscriptions lista las suscripciones de un tenant con sus secretos descifrados
+func (s *SealedStore) ListSubscriptions(ctx context.Context, tenant string) ([]*repository.Subscription, error) {
+	subs, err := s.SubscriptionStore.ListSubscriptions(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		if err := s.open(sub); err != nil {
+			return nil, err
+		}
+	}
+	return subs, nil
+}
+
+// SealStored cifra los secretos de las suscripciones del tenant guardadas sin cifrar y devuelve cuántas cifró
+func (s *SealedStore) SealStored(ctx context.Context, tenant string) (int, error) {
+	if s.sealer == nil {
+		return 0, nil
+	}
+	subs, err := s.SubscriptionStore.ListSubscriptions(ctx, tenant)
+	if err != nil {
+		return 0, err
+	}
+	sealed := 0
+	for _, sub := range subs {
+		if secret.IsSealed(sub.Secret) {
+			continue
+		}
+		if err := s.UpdateSubscription(ctx, sub); err != nil {
+			return sealed, err
+		}
+		sealed++
+	}
+	return sealed, nil
+}
+
+// withSealedSecret ejecuta save con el secreto de sub cifrado y luego lo restaura
+func (s *SealedStore) withSealedSecret(sub *repository.Subscription, save func() error) error {
+	if s.sealer == nil {
+		return ErrNoEncryptionKey
+	}
+	plaintext := sub.Secret
+	sealed, err := s.sealer.Seal([]byte(plaintext), additionalData(sub.Tenant))
+	if err != nil {
+		return fmt.Errorf("error al cifrar el secreto de la suscripción: %w", err)
+	}
+	sub.Secret = sealed
+	defer func() { sub.Secret = plaintext }()
+	return save()
+}
+
+// open descifra el secreto de sub; los guardados sin cifrar se devuelven tal cual
+func (s *SealedStore) open(sub *repository.Subscription) error {
+	if !secret.IsSealed(sub.Secret) {
+		return nil
+	}
+	if s.sealer == nil {
+		return fmt.Errorf("suscripción %d: su secreto está cifrado pero TENANTS_ENCRYPTION_KEY no está configurada", sub.ID)
+	}
+	plaintext, err := s.sealer.Open(sub.Secret, additionalData(sub.Tenant))
+	if err != nil {
+		return fmt.Errorf("suscripción %d: %w", sub.ID, err)
+	}
+	sub.Secret = string(plaintext)
+	return nil
+}
+
+// additionalData autentica el tenant junto al secreto, así no se puede copiar a una suscripción de otro tenant
+func additionalData(tenant string) string {
+	return "subscription:" + tenant
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+func newTestStore(t *testing.T) (*SealedStore, repository.Repository) {
+	t.Helper()
+	repo, err := repository.NewSQLite(filepath.Join(t.TempDir(), "sync.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.Migrator().Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sealer, err := secret.NewSealerFromKey(secret.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSealedStore(repo, sealer), repo
+}
+
+// TestSealedStore verifica que el secreto se guarde cifrado y se lea descifrado
+func TestSealedStore(t *testing.T) {
+	ctx := context.Background()
+	store, repo := newTestStore(t)
+
+	sub := &repository.Subscription{Tenant: "acme", URL: "https://example.com/hooks", Events: []string{"*"}, Secret: "0123456789abcdef", Active: true}
+	if err := store.CreateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	if sub.Secret != "0123456789abcdef" {
+		t.Errorf("CreateSubscription() dejó el secreto cifrado en la suscripción: %q", sub.Secret)
+	}
+	stored, err := repo.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !secret.IsSealed(stored.Secret) {
+		t.Fatalf("el secreto se guardó sin cifrar: %q", stored.Secret)
+	}
+
+	sub.Secret = "fedcba9876543210"
+	if err := store.UpdateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Secret != "fedcba9876543210" {
+		t.Errorf("GetSubscription() secreto = %q, se esperaba el editado", got.Secret)
+	}
+
+	// Un secreto cifrado copiado a una suscripción de otro tenant no se descifra
+	stored, _ = repo.GetSubscription(ctx, sub.ID)
+	other := &repository.Subscription{Tenant: "beta", URL: "https://example.com/hooks", Events: []string{"*"}, Secret: stored.Secret, Active: true}
+	if err := repo.CreateSubscription(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetSubscription(ctx, other.ID); err == nil {
+		t.Error("se descifró el secreto copiado desde otro tenant")
+	}
+}
+
+// TestSealedStoreLegacy verifica que las suscripciones guardadas sin cifrar se sigan leyendo y que
+// SealStored las cifre
+func TestSealedStoreLegacy(t *testing.T) {
+	ctx := context.Background()
+	store, repo := newTestStore(t)
+
+	legacy := &repository.Subscription{Tenant: "acme", URL: "https://example.com/hooks", Events: []string{"*"}, Secret: "plaintext-secret-1", Active: true}
+	if err := repo.CreateSubscription(ctx, legacy); err != nil {
+		t.Fatal(err)
+	}
+	subs, err := store.ListSubscriptions(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].Secret != "plaintext-secret-1" {
+		t.Fatalf("ListSubscriptions() = %+v, se esperaba la suscripción sin cifrar", subs)
+	}
+
+	for _, want := range []int{1, 0} {
+		sealed, err := store.SealStored(ctx, "acme")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sealed != want {
+			t.Errorf("SealStored() cifró %d suscripciones, se esperaba %d", sealed, want)
+		}
+	}
+	stored, _ := repo.GetSubscription(ctx, legacy.ID)
+	if !secret.IsSealed(stored.Secret) {
+		t.Errorf("SealStored() no cifró el secreto: %q", stored.Secret)
+	}
+	got, err := store.GetSubscription(ctx, legacy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Secret != "plaintext-secret-1" {
+		t.Errorf("GetSubscription() secreto = %q, se esperaba el original", got.Secret)
+	}
+}
+
+// TestSealedStoreWithoutKey verifica que sin clave no se guarden secretos pero se lean los antiguos
+func TestSealedStoreWithoutKey(t *testing.T) {
+	ctx := context.Background()
+	_, repo := newTestStore(t)
+	store := NewSealedStore(repo, nil)
+
+	sub := &repository.Subscription{Tenant: "acme", URL: "https://example.com/hooks", Events: []string{"*"}, Secret: "plaintext-secret-1", Active: true}
+	if err := store.CreateSubscription(ctx, sub); !errors.Is(err, ErrNoEncryptionKey) {
+		t.Fatalf("CreateSubscription() = %v, se esperaba %v", err, ErrNoEncryptionKey)
+	}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.GetSubscription(ctx, sub.ID); err != nil || got.Secret != "plaintext-secret-1" {
+		t.Errorf("GetSubscription() = %+v, %v; se esperaba la suscripción sin cifrar", got, err)
+	}
+	if sealed, err := store.SealStored(ctx, "acme"); err != nil || sealed != 0 {
+		t.Errorf("SealStored() sin clave = %d, %v", sealed, err)
+	}
+}
@@ -2,10 +2,39 @@ package odoo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/breaker"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/metrics"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tracing"
+)
+
+var (
+	logger = logging.For("odoo")
+	tracer = tracing.Tracer("odoo")
+)
+
+var (
+	rpcRequests = metrics.NewCounterVec("odoo_rpc_requests_total",
+		"Llamadas execute_kw a Odoo por modelo y método", "model", "method")
+	rpcErrors = metrics.NewCounterVec("odoo_rpc_errors_total",
+		"Llamadas execute_kw a Odoo que fallaron, por modelo, método y tipo (transport, http o rpc)", "model", "method", "kind")
+	rpcDuration = metrics.NewHistogramVec("odoo_rpc_duration_seconds",
+		"Duración de las llamadas execute_kw a Odoo", metrics.DefaultBuckets, "model", "method")
+	authentications = metrics.NewCounterVec("odoo_authentications_total",
+		"Intentos de autenticación con Odoo por resultado (success o failure)", "result")
 )
 
 // Servicio para integración con Odoo ERP usando JSON-RPC
@@ -14,16 +43,18 @@ type Client struct {
 	URL      string
 	Database string
 	Username string
-	Password string
-	APIKey   string // API Key para autenticación
+	password secret.Value
+	apiKey   secret.Value // API Key para autenticación
 
 	// Información del cliente (multi-tenant)
 	ClientID   string
 	ClientName string
 
-	// Estado de autenticación
-	UID        int
+	// Estado de autenticación: uid es atómico porque la sonda de disponibilidad y las peticiones
+	// pueden autenticar mientras una sincronización lo lee
+	uid        atomic.Int64
 	httpClient *http.Client
+	breaker    *breaker.Breaker
 }
 
 // Inicializa el servicio con configuración de un cliente específico
@@ -32,21 +63,32 @@ func NewClient(config *Config) *Client {
 		URL:        config.URL,
 		Database:   config.Database,
 		Username:   config.Username,
-		Password:   config.Password,
-		APIKey:     config.APIKey,
+		password:   config.Password,
+		apiKey:     config.APIKey,
 		ClientID:   config.ClientID,
 		ClientName: config.ClientName,
 		httpClient: &http.Client{},
+		breaker:    breaker.New(breaker.DefaultThreshold, breaker.DefaultCooldown),
 	}
 }
 
-// GetAuthPassword devuelve la contraseña o API Key para autenticación
+// BreakerState devuelve el estado del circuit breaker de las llamadas a Odoo
+func (c *Client) BreakerState() breaker.State {
+	return c.breaker.State()
+}
+
+// UID devuelve el usuario autenticado en Odoo (0 si aún no se autenticó)
+func (c *Client) UID() int {
+	return int(c.uid.Load())
+}
+
+// authPassword devuelve la contraseña o API Key para autenticación
 // Con API Key, Odoo requiere usar la API Key como "password" en las llamadas
-func (c *Client) GetAuthPassword() string {
-	if c.APIKey != "" {
-		return c.APIKey
+func (c *Client) authPassword() string {
+	if !c.apiKey.IsZero() {
+		return c.apiKey.Reveal()
 	}
-	return c.Password
+	return c.password.Reveal()
 }
 
 // jsonRPCRequest representa una petición JSON-RPC a Odoo
@@ -72,13 +114,93 @@ type jsonRPCError struct {
 	Data    map[string]interface{} `json:"data,omitempty"`
 }
 
+// RPCError es un error devuelto por Odoo en una llamada JSON-RPC
+// Name contiene la clase de la excepción (ej: "odoo.exceptions.ValidationError")
+type RPCError struct {
+	Code    int
+	Message string
+	Name    string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("error de Odoo: %s", e.Message)
+}
+
+// HTTPError es una respuesta HTTP distinta de 200 del servidor de Odoo
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("error HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// newRPCError construye un RPCError con el mensaje más descriptivo disponible
+func newRPCError(e *jsonRPCError) *RPCError {
+	rpcErr := &RPCError{Code: e.Code, Message: e.Message}
+	if name, ok := e.Data["name"].(string); ok {
+		rpcErr.Name = name
+	}
+	if message, ok := e.Data["message"].(string); ok && message != "" {
+		rpcErr.Message = message
+	}
+	return rpcErr
+}
+
+// ExecuteKW ejecuta un método de un modelo usando execute_kw
+func (c *Client) ExecuteKW(ctx context.Context, model, method string, args []interface{}, kwargs map[string]interface{}) (interface{}, error) {
+	uid := c.UID()
+	if uid == 0 {
+		return nil, fmt.Errorf("cliente no autenticado")
+	}
+	if kwargs == nil {
+		kwargs = map[string]interface{}{}
+	}
+
+	payload := jsonRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "call",
+		Params: map[string]interface{}{
+			"service": "object",
+			"method":  "execute_kw",
+			"args": []interface{}{
+				c.Database,
+				uid,
+				c.authPassword(),
+				model,
+				method,
+				args,
+				kwargs,
+			},
+		},
+		ID: 1,
+	}
+
+	response, err := c.doRequest(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("error ejecutando %s.%s: %w", model, method, err)
+	}
+	if response.Error != nil {
+		return nil, newRPCError(response.Error)
+	}
+	return response.Result, nil
+}
+
 // Authenticate autentica con Odoo y obtiene el UID
-func (c *Client) Authenticate() error {
-	fmt.Printf("🔑 Intentando autenticar con Odoo (Cliente: %s)...\n", c.ClientName)
+func (c *Client) Authenticate(ctx context.Context) error {
+	if err := c.authenticate(ctx); err != nil {
+		authentications.Inc("failure")
+		return err
+	}
+	authentications.Inc("success")
+	return nil
+}
 
+func (c *Client) authenticate(ctx context.Context) error {
 	// Si tenemos API Key, usarla directamente (método preferido)
-	if c.APIKey != "" {
-		fmt.Println("🔐 Usando autenticación con API Key")
+	if !c.apiKey.IsZero() {
+		logger.DebugContext(ctx, "🔑 Autenticando con Odoo", "tenant", c.ClientID, "auth", "api_key")
 		// Con API Key, debemos hacer authenticate usando el username y API Key como password
 		payload := jsonRPCRequest{
 			JSONRPC: "2.0",
@@ -86,12 +208,12 @@ func (c *Client) Authenticate() error {
 			Params: map[string]interface{}{
 				"service": "common",
 				"method":  "authenticate",
-				"args":    []interface{}{c.Database, c.Username, c.APIKey, map[string]interface{}{}},
+				"args":    []interface{}{c.Database, c.Username, c.apiKey.Reveal(), map[string]interface{}{}},
 			},
 			ID: 1,
 		}
 
-		response, err := c.doRequest(payload)
+		response, err := c.doRequest(ctx, payload)
 		if err != nil {
 			return fmt.Errorf("error en la petición de autenticación con API Key: %w", err)
 		}
@@ -106,25 +228,25 @@ func (c *Client) Authenticate() error {
 			return fmt.Errorf("API Key inválida o respuesta inesperada")
 		}
 
-		c.UID = int(uid)
-		fmt.Printf("✅ Autenticado con API Key. UID: %d (Cliente: %s)\n", c.UID, c.ClientName)
+		c.uid.Store(int64(uid))
+		logger.InfoContext(ctx, "✅ Autenticado con Odoo", "tenant", c.ClientID, "auth", "api_key", "uid", int(uid))
 		return nil
 	}
 
 	// Fallback a autenticación tradicional con usuario/contraseña
-	fmt.Println("🔐 Usando autenticación con usuario/contraseña")
+	logger.DebugContext(ctx, "🔑 Autenticando con Odoo", "tenant", c.ClientID, "auth", "password")
 	payload := jsonRPCRequest{
 		JSONRPC: "2.0",
 		Method:  "call",
 		Params: map[string]interface{}{
 			"service": "common",
 			"method":  "authenticate",
-			"args":    []interface{}{c.Database, c.Username, c.Password, map[string]interface{}{}},
+			"args":    []interface{}{c.Database, c.Username, c.password.Reveal(), map[string]interface{}{}},
 		},
 		ID: 1,
 	}
 
-	response, err := c.doRequest(payload)
+	response, err := c.doRequest(ctx, payload)
 	if err != nil {
 		return fmt.Errorf("error en la petición de autenticación: %w", err)
 	}
@@ -139,25 +261,192 @@ func (c *Client) Authenticate() error {
 		return fmt.Errorf("credenciales inválidas o respuesta inesperada")
 	}
 
-	c.UID = int(uid)
-	fmt.Printf("✅ Autenticado correctamente. UID: %d (Cliente: %s)\n", c.UID, c.ClientName)
+	c.uid.Store(int64(uid))
+	logger.InfoContext(ctx, "✅ Autenticado con Odoo", "tenant", c.ClientID, "auth", "password", "uid", int(uid))
 
 	return nil
 }
 
-// doRequest realiza una petición JSON-RPC a Odoo
-func (c *Client) doRequest(payload jsonRPCRequest) (*jsonRPCResponse, error) {
+// Ping verifica que Odoo responda y siga aceptando las credenciales, sin cambiar el UID
+func (c *Client) Ping(ctx context.Context) error {
+	payload := jsonRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "call",
+		Params: map[string]interface{}{
+			"service": "common",
+			"method":  "authenticate",
+			"args":    []interface{}{c.Database, c.Username, c.authPassword(), map[string]interface{}{}},
+		},
+		ID: 1,
+	}
+
+	response, err := c.doRequest(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("error en la petición de autenticación: %w", err)
+	}
+	if response.Error != nil {
+		return newRPCError(response.Error)
+	}
+	if uid, ok := response.Result.(float64); !ok || uid == 0 {
+		return fmt.Errorf("credenciales inválidas o respuesta inesperada")
+	}
+	return nil
+}
+
+// doRequest realiza una petición JSON-RPC a Odoo que se cancela junto con ctx
+// Cada llamada se registra en el log (con el request_id del contexto), en un span de la traza y, las
+// execute_kw, en las métricas. Los errores de red y las respuestas 5xx abren el circuit breaker
+func (c *Client) doRequest(ctx context.Context, payload jsonRPCRequest) (*jsonRPCResponse, error) {
+	ctx, span := c.startSpan(ctx, payload)
+	start := time.Now()
+	response, err := c.call(ctx, payload)
+	elapsed := time.Since(start)
+
+	var kind string
+	var httpErr *HTTPError
+	switch {
+	case errors.Is(err, breaker.ErrOpen):
+		kind = "breaker"
+	case errors.As(err, &httpErr):
+		kind = "http"
+	case err != nil:
+		kind = "transport"
+	case response.Error != nil:
+		kind = "rpc"
+	}
+
+	attrs := []any{"tenant", c.ClientID, "service", payload.Params["service"]}
+	model, method, isKW := executeKWTarget(payload)
+	if isKW {
+		attrs = append(attrs, "model", model, "method", method)
+		rpcRequests.Inc(model, method)
+		rpcDuration.Observe(elapsed.Seconds(), model, method)
+		if kind != "" {
+			rpcErrors.Inc(model, method, kind)
+		}
+	} else {
+		attrs = append(attrs, "method", payload.Params["method"])
+	}
+	attrs = append(attrs, "duration_ms", elapsed.Milliseconds())
+
+	switch kind {
+	case "":
+		if count, ok := recordCount(payload, response.Result); ok {
+			span.SetAttributes(attribute.Int("odoo.record_count", count))
+		}
+		logger.DebugContext(ctx, "📡 Llamada a Odoo", attrs...)
+		tracing.End(span, nil)
+	case "rpc":
+		logger.WarnContext(ctx, "⚠️ Odoo devolvió un error", append(attrs, "error", response.Error.Message)...)
+		span.SetAttributes(attribute.String("odoo.error_kind", kind))
+		tracing.End(span, newRPCError(response.Error))
+	default:
+		logger.WarnContext(ctx, "⚠️ Error en la llamada a Odoo", append(attrs, "error", err)...)
+		span.SetAttributes(attribute.String("odoo.error_kind", kind))
+		tracing.End(span, err)
+	}
+	return response, err
+}
+
+// startSpan abre el span de una llamada a Odoo: "odoo hr.employee.search_read" para las execute_kw
+// y "odoo common.authenticate" para las demás
+func (c *Client) startSpan(ctx context.Context, payload jsonRPCRequest) (context.Context, trace.Span) {
+	service, _ := payload.Params["service"].(string)
+	rpcMethod, _ := payload.Params["method"].(string)
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", rpcMethod),
+		attribute.String("url.full", c.URL+"/jsonrpc"),
+		attribute.String("tenant", c.ClientID),
+	}
+	name := "odoo " + service + "." + rpcMethod
+	if model, method, ok := executeKWTarget(payload); ok {
+		name = "odoo " + model + "." + method
+		attrs = append(attrs, attribute.String("odoo.model", model), attribute.String("odoo.method", method))
+	}
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// recordCount devuelve cuántos registros involucra una execute_kw: los devueltos por las lecturas,
+// los indicados en write y unlink, y uno por cada create
+func recordCount(payload jsonRPCRequest, result interface{}) (int, bool) {
+	_, method, ok := executeKWTarget(payload)
+	if !ok {
+		return 0, false
+	}
+	if records, ok := result.([]interface{}); ok {
+		return len(records), true
+	}
+	switch method {
+	case "write", "unlink":
+		args, _ := payload.Params["args"].([]interface{})
+		if len(args) > 5 {
+			if positional, ok := args[5].([]interface{}); ok && len(positional) > 0 {
+				if ids, ok := positional[0].([]int); ok {
+					return len(ids), true
+				}
+				if ids, ok := positional[0].([]interface{}); ok {
+					return len(ids), true
+				}
+			}
+		}
+	case "create":
+		if _, ok := result.(float64); ok {
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// executeKWTarget devuelve el modelo y el método de una llamada execute_kw
+func executeKWTarget(payload jsonRPCRequest) (model, method string, ok bool) {
+	if payload.Params["method"] != "execute_kw" {
+		return "", "", false
+	}
+	args, _ := payload.Params["args"].([]interface{})
+	if len(args) < 5 {
+		return "", "", false
+	}
+	model, _ = args[3].(string)
+	method, _ = args[4].(string)
+	return model, method, model != "" && method != ""
+}
+
+// call envía la petición si el circuit breaker lo permite y le informa el resultado
+// Los errores de la respuesta JSON-RPC (permisos, validaciones) y la cancelación de ctx no cuentan como fallas
+func (c *Client) call(ctx context.Context, payload jsonRPCRequest) (*jsonRPCResponse, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, fmt.Errorf("Odoo no disponible: %w", err)
+	}
+	response, err := c.send(ctx, payload)
+	var httpErr *HTTPError
+	failed := err != nil && ctx.Err() == nil
+	if errors.As(err, &httpErr) {
+		failed = httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	c.breaker.Done(failed)
+	return response, err
+}
+
+// send envía la petición JSON-RPC y decodifica la respuesta
+func (c *Client) send(ctx context.Context, payload jsonRPCRequest) (*jsonRPCResponse, error) {
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("error al serializar la petición: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.URL+"/jsonrpc", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.URL+"/jsonrpc", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("error al crear la petición HTTP: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	// Permite correlacionar la petición con los logs del servidor de Odoo o de un proxy intermedio
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	tracing.Inject(ctx, req.Header)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -171,7 +460,7 @@ func (c *Client) doRequest(payload jsonRPCRequest) (*jsonRPCResponse, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var response jsonRPCResponse
@@ -0,0 +1,95 @@
+package odoo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/breaker"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// TestClientConcurrentAuthenticate autentica mientras otras goroutines llaman a execute_kw, como hacen
+// la sonda de disponibilidad y una sincronización en curso; con -race detecta accesos sin sincronizar al UID
+func TestClientConcurrentAuthenticate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": 2})
+	}))
+	defer server.Close()
+
+	client := NewClient(&Config{URL: server.URL, Database: "acme", Username: "admin", Password: secret.New("secret")})
+	if _, err := client.ExecuteKW(context.Background(), "hr.employee", "search_count", nil, nil); err == nil {
+		t.Fatal("ExecuteKW() sin autenticar debe fallar")
+	}
+	if err := client.Authenticate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := client.Authenticate(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.ExecuteKW(context.Background(), "hr.employee", "search_count", []interface{}{[]interface{}{}}, nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if client.UID() != 2 {
+		t.Errorf("UID() = %d, se esperaba 2", client.UID())
+	}
+}
+
+// TestClientBreaker verifica que las respuestas 5xx abran el circuito y que, abierto, las llamadas
+// fallen sin llegar a Odoo; los errores de la respuesta JSON-RPC no cuentan como fallas
+func TestClientBreaker(t *testing.T) {
+	var requests atomic.Int32
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1,
+			"error": map[string]interface{}{"code": 200, "message": "Odoo Server Error", "data": map[string]interface{}{"name": "odoo.exceptions.AccessDenied"}}})
+	}))
+	defer server.Close()
+	client := NewClient(&Config{URL: server.URL, Database: "acme", Username: "admin", Password: secret.New("secret")})
+
+	for i := 0; i < breaker.DefaultThreshold+1; i++ {
+		if err := client.Ping(context.Background()); err == nil {
+			t.Fatal("Ping() con credenciales rechazadas debe fallar")
+		}
+	}
+	if state := client.BreakerState(); state != breaker.Closed {
+		t.Fatalf("los errores JSON-RPC abrieron el circuito: estado %s", state)
+	}
+
+	down.Store(true)
+	for i := 0; i < breaker.DefaultThreshold; i++ {
+		client.Ping(context.Background())
+	}
+	if state := client.BreakerState(); state != breaker.Open {
+		t.Fatalf("estado = %s tras %d respuestas 502, se esperaba %s", state, breaker.DefaultThreshold, breaker.Open)
+	}
+	before := requests.Load()
+	if err := client.Ping(context.Background()); !errors.Is(err, breaker.ErrOpen) {
+		t.Errorf("Ping() con el circuito abierto = %v, se esperaba %v", err, breaker.ErrOpen)
+	}
+	if requests.Load() != before {
+		t.Error("la llamada llegó a Odoo con el circuito abierto")
+	}
+}
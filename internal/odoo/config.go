@@ -3,6 +3,8 @@ package odoo
 import (
 	"fmt"
 	"os"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
 )
 
 // Config contiene la configuración para conectarse a Odoo
@@ -11,8 +13,8 @@ type Config struct {
 	URL      string
 	Database string
 	Username string
-	Password string
-	APIKey   string // API Key de Odoo (recomendado sobre usuario/contraseña)
+	Password secret.Value
+	APIKey   secret.Value // API Key de Odoo (recomendado sobre usuario/contraseña)
 
 	// Información del cliente (multi-tenant)
 	ClientID   string
@@ -21,6 +23,7 @@ type Config struct {
 
 // NewConfigFromEnv crea una configuración desde variables de entorno
 // Soporta autenticación con API Key (recomendado) o usuario/contraseña (legacy)
+// ODOO_API_KEY y ODOO_PASSWORD también se pueden leer de archivos o del keystore (ver secret.Get)
 func NewConfigFromEnv() (*Config, error) {
 	url := os.Getenv("ODOO_URL")
 	if url == "" {
@@ -32,12 +35,18 @@ func NewConfigFromEnv() (*Config, error) {
 		return nil, fmt.Errorf("ODOO_DATABASE no está configurado")
 	}
 
-	apiKey := os.Getenv("ODOO_API_KEY")
+	apiKey, err := secret.Get("ODOO_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	username := os.Getenv("ODOO_USERNAME")
-	password := os.Getenv("ODOO_PASSWORD")
+	password, err := secret.Get("ODOO_PASSWORD")
+	if err != nil {
+		return nil, err
+	}
 
 	// Validar que tengamos al menos un método de autenticación
-	if apiKey == "" && (username == "" || password == "") {
+	if apiKey.IsZero() && (username == "" || password.IsZero()) {
 		return nil, fmt.Errorf("debe configurar ODOO_API_KEY o ODOO_USERNAME+ODOO_PASSWORD")
 	}
 
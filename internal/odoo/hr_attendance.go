@@ -0,0 +1,129 @@
+package odoo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// odooDateTime es el formato de fecha y hora de Odoo (siempre en UTC)
+const odooDateTime = "2006-01-02 15:04:05"
+
+// HrAttendance representa una asistencia en Odoo (modelo hr.attendance)
+type HrAttendance struct {
+	ID         int        `json:"id"`
+	EmployeeID int        `json:"employee_id"`
+	CheckIn    time.Time  `json:"check_in"`
+	CheckOut   *time.Time `json:"check_out,omitempty"`
+}
+
+// AttendanceService proporciona operaciones para asistencias
+type AttendanceService struct {
+	client *Client
+}
+
+// NewAttendanceService crea un nuevo servicio de asistencias
+func NewAttendanceService(client *Client) *AttendanceService {
+	return &AttendanceService{
+		client: client,
+	}
+}
+
+// CheckIn registra una entrada y devuelve el ID de la asistencia creada
+// Odoo rechaza con ValidationError una entrada si el empleado tiene otra asistencia abierta
+func (s *AttendanceService) CheckIn(ctx context.Context, employeeID int, at time.Time) (int, error) {
+	result, err := s.client.ExecuteKW(ctx, "hr.attendance", "create", []interface{}{
+		map[string]interface{}{
+			"employee_id": employeeID,
+			"check_in":    at.UTC().Format(odooDateTime),
+		},
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	id, ok := result.(float64)
+	if !ok {
+		return 0, fmt.Errorf("respuesta inesperada al crear asistencia: %v", result)
+	}
+	return int(id), nil
+}
+
+// CheckOut cierra la asistencia abierta del empleado y devuelve su ID
+func (s *AttendanceService) CheckOut(ctx context.Context, employeeID int, at time.Time) (int, error) {
+	open, err := s.FindOpen(ctx, employeeID)
+	if err != nil {
+		return 0, err
+	}
+	if open == nil {
+		return 0, fmt.Errorf("el empleado %d no tiene una asistencia abierta", employeeID)
+	}
+
+	_, err = s.client.ExecuteKW(ctx, "hr.attendance", "write", []interface{}{
+		[]int{open.ID},
+		map[string]interface{}{
+			"check_out": at.UTC().Format(odooDateTime),
+		},
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	return open.ID, nil
+}
+
+// FindOpen busca la asistencia sin salida del empleado (nil si no hay)
+func (s *AttendanceService) FindOpen(ctx context.Context, employeeID int) (*HrAttendance, error) {
+	result, err := s.client.ExecuteKW(ctx, "hr.attendance", "search_read", []interface{}{
+		[]interface{}{
+			[]interface{}{"employee_id", "=", employeeID},
+			[]interface{}{"check_out", "=", false},
+		},
+	}, map[string]interface{}{
+		"fields": []string{"id", "employee_id", "check_in", "check_out"},
+		"order":  "check_in desc",
+		"limit":  1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("formato de respuesta inválido")
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	data, ok := records[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("formato de respuesta inválido")
+	}
+	return parseAttendanceData(data), nil
+}
+
+// parseAttendanceData convierte los datos crudos de Odoo a HrAttendance
+func parseAttendanceData(data map[string]interface{}) *HrAttendance {
+	attendance := &HrAttendance{}
+
+	if id, ok := data["id"].(float64); ok {
+		attendance.ID = int(id)
+	}
+	if employee, ok := data["employee_id"].([]interface{}); ok && len(employee) > 0 {
+		if id, ok := employee[0].(float64); ok {
+			attendance.EmployeeID = int(id)
+		}
+	}
+	if checkIn, ok := data["check_in"].(string); ok {
+		if t, err := time.Parse(odooDateTime, checkIn); err == nil {
+			attendance.CheckIn = t
+		}
+	}
+	if checkOut, ok := data["check_out"].(string); ok {
+		if t, err := time.Parse(odooDateTime, checkOut); err == nil {
+			attendance.CheckOut = &t
+		}
+	}
+
+	return attendance
+}
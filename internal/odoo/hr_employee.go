@@ -1,6 +1,7 @@
 package odoo
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -66,12 +67,12 @@ func NewEmployeeService(client *Client) *EmployeeService {
 }
 
 // GetAllEmployees obtiene todos los empleados de Odoo
-func (s *EmployeeService) GetAllEmployees() ([]*HrEmployee, error) {
-	if s.client.UID == 0 {
+func (s *EmployeeService) GetAllEmployees(ctx context.Context) ([]*HrEmployee, error) {
+	if s.client.UID() == 0 {
 		return nil, fmt.Errorf("cliente no autenticado")
 	}
 
-	fmt.Println("👥 Obteniendo todos los empleados de Odoo...")
+	logger.DebugContext(ctx, "👥 Obteniendo todos los empleados de Odoo", "tenant", s.client.ClientID)
 
 	// Ejecutar método search_read en hr.employee
 	payload := jsonRPCRequest{
@@ -82,8 +83,8 @@ func (s *EmployeeService) GetAllEmployees() ([]*HrEmployee, error) {
 			"method":  "execute_kw",
 			"args": []interface{}{
 				s.client.Database,
-				s.client.UID,
-				s.client.GetAuthPassword(), // Usa API Key si está disponible
+				s.client.UID(),
+				s.client.authPassword(), // Usa API Key si está disponible
 				"hr.employee",
 				"search_read",
 				[]interface{}{
@@ -113,7 +114,7 @@ func (s *EmployeeService) GetAllEmployees() ([]*HrEmployee, error) {
 		ID: 1,
 	}
 
-	response, err := s.client.doRequest(payload)
+	response, err := s.client.doRequest(ctx, payload)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo empleados: %w", err)
 	}
@@ -140,17 +141,17 @@ func (s *EmployeeService) GetAllEmployees() ([]*HrEmployee, error) {
 		employees = append(employees, employee)
 	}
 
-	fmt.Printf("✅ Se obtuvieron %d empleados\n", len(employees))
+	logger.InfoContext(ctx, "✅ Empleados obtenidos de Odoo", "tenant", s.client.ClientID, "count", len(employees))
 	return employees, nil
 }
 
 // GetEmployeeByID obtiene un empleado específico por su ID
-func (s *EmployeeService) GetEmployeeByID(employeeID int) (*HrEmployee, error) {
-	if s.client.UID == 0 {
+func (s *EmployeeService) GetEmployeeByID(ctx context.Context, employeeID int) (*HrEmployee, error) {
+	if s.client.UID() == 0 {
 		return nil, fmt.Errorf("cliente no autenticado")
 	}
 
-	fmt.Printf("🔍 Buscando empleado ID: %d\n", employeeID)
+	logger.DebugContext(ctx, "🔍 Buscando empleado", "tenant", s.client.ClientID, "employee_id", employeeID)
 
 	// Ejecutar método read en hr.employee
 	payload := jsonRPCRequest{
@@ -161,8 +162,8 @@ func (s *EmployeeService) GetEmployeeByID(employeeID int) (*HrEmployee, error) {
 			"method":  "execute_kw",
 			"args": []interface{}{
 				s.client.Database,
-				s.client.UID,
-				s.client.GetAuthPassword(), // Usa API Key si está disponible
+				s.client.UID(),
+				s.client.authPassword(), // Usa API Key si está disponible
 				"hr.employee",
 				"read",
 				[]interface{}{[]int{employeeID}},
@@ -190,7 +191,7 @@ func (s *EmployeeService) GetEmployeeByID(employeeID int) (*HrEmployee, error) {
 		ID: 1,
 	}
 
-	response, err := s.client.doRequest(payload)
+	response, err := s.client.doRequest(ctx, payload)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo empleado: %w", err)
 	}
@@ -211,11 +212,88 @@ func (s *EmployeeService) GetEmployeeByID(employeeID int) (*HrEmployee, error) {
 	}
 
 	employee := s.parseEmployeeData(empData)
-	fmt.Printf("✅ Empleado encontrado: %s\n", employee.Name)
+	logger.DebugContext(ctx, "✅ Empleado encontrado", "tenant", s.client.ClientID, "employee_id", employee.ID)
 
 	return employee, nil
 }
 
+// GetEmployeesByIDs obtiene los empleados activos con los IDs indicados
+// Los IDs que no aparecen en el resultado corresponden a empleados archivados o eliminados
+func (s *EmployeeService) GetEmployeesByIDs(ctx context.Context, employeeIDs []int) ([]*HrEmployee, error) {
+	if s.client.UID() == 0 {
+		return nil, fmt.Errorf("cliente no autenticado")
+	}
+
+	logger.DebugContext(ctx, "🔍 Buscando empleados por ID", "tenant", s.client.ClientID, "count", len(employeeIDs))
+
+	result, err := s.client.ExecuteKW(ctx, "hr.employee", "search_read", []interface{}{
+		[]interface{}{
+			[]interface{}{"id", "in", employeeIDs},
+		},
+	}, map[string]interface{}{
+		"fields": []string{
+			"id",
+			"identification_id",
+			"name",
+			"country_id",
+			"work_email",
+			"work_phone",
+			"image_1920",
+			"birthday",
+			"gender",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo empleados: %w", err)
+	}
+
+	resultSlice, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("formato de respuesta inválido")
+	}
+
+	employees := make([]*HrEmployee, 0, len(resultSlice))
+	for _, item := range resultSlice {
+		if empData, ok := item.(map[string]interface{}); ok {
+			employees = append(employees, s.parseEmployeeData(empData))
+		}
+	}
+	return employees, nil
+}
+
+// GetRelatedEmployeeIDs lee el campo employee_id de registros de otro modelo (ej: hr.contract, hr.leave)
+func (s *EmployeeService) GetRelatedEmployeeIDs(ctx context.Context, model string, ids []int) ([]int, error) {
+	if s.client.UID() == 0 {
+		return nil, fmt.Errorf("cliente no autenticado")
+	}
+
+	result, err := s.client.ExecuteKW(ctx, model, "read", []interface{}{ids}, map[string]interface{}{
+		"fields": []string{"employee_id"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", model, err)
+	}
+
+	records, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("formato de respuesta inválido")
+	}
+
+	employeeIDs := []int{}
+	for _, item := range records {
+		data, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if employee, ok := data["employee_id"].([]interface{}); ok && len(employee) > 0 {
+			if id, ok := employee[0].(float64); ok {
+				employeeIDs = append(employeeIDs, int(id))
+			}
+		}
+	}
+	return employeeIDs, nil
+}
+
 // parseEmployeeData convierte los datos crudos de Odoo a estructura HrEmployee
 func (s *EmployeeService) parseEmployeeData(data map[string]interface{}) *HrEmployee {
 	employee := &HrEmployee{}
@@ -0,0 +1,239 @@
+package odoo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// odooDate es el formato de fecha de Odoo
+const odooDate = "2006-01-02"
+
+// LeaveService proporciona operaciones para ausencias (modelo hr.leave)
+type LeaveService struct {
+	client *Client
+}
+
+// NewLeaveService crea un nuevo servicio de ausencias
+func NewLeaveService(client *Client) *LeaveService {
+	return &LeaveService{
+		client: client,
+	}
+}
+
+// FindLeaveTypeID busca un tipo de ausencia (hr.leave.type) por nombre, sin distinguir mayúsculas
+// Devuelve 0 si no existe
+func (s *LeaveService) FindLeaveTypeID(ctx context.Context, name string) (int, error) {
+	result, err := s.client.ExecuteKW(ctx, "hr.leave.type", "search", []interface{}{
+		[]interface{}{
+			[]interface{}{"name", "=ilike", name},
+		},
+	}, map[string]interface{}{
+		"limit": 1,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ids, ok := result.([]interface{})
+	if !ok {
+		return 0, fmt.Errorf("formato de respuesta inválido")
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	id, ok := ids[0].(float64)
+	if !ok {
+		return 0, fmt.Errorf("formato de respuesta inválido")
+	}
+	return int(id), nil
+}
+
+// Create registra una solicitud de ausencia y devuelve su ID
+// Odoo rechaza con ValidationError las ausencias que se superponen con otras del empleado
+func (s *LeaveService) Create(ctx context.Context, employeeID, leaveTypeID int, from, to time.Time, description string) (int, error) {
+	result, err := s.client.ExecuteKW(ctx, "hr.leave", "create", []interface{}{
+		map[string]interface{}{
+			"employee_id":       employeeID,
+			"holiday_status_id": leaveTypeID,
+			"request_date_from": from.Format(odooDate),
+			"request_date_to":   to.Format(odooDate),
+			"name":              description,
+		},
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	id, ok := result.(float64)
+	if !ok {
+		return 0, fmt.Errorf("respuesta inesperada al crear ausencia: %v", result)
+	}
+	return int(id), nil
+}
+
+// LeaveBalance es el saldo de un tipo de ausencia para un empleado
+type LeaveBalance struct {
+	LeaveTypeID   int     `json:"leave_type_id"`
+	LeaveType     string  `json:"leave_type"`
+	Allocated     float64 `json:"allocated"` // Días asignados (asignaciones aprobadas)
+	Taken         float64 `json:"taken"`     // Días ya usados
+	Remaining     float64 `json:"remaining"`
+	AllocationIDs []int   `json:"allocation_ids"`
+}
+
+// GetBalances calcula los saldos por tipo de ausencia a partir de las asignaciones
+// aprobadas (hr.leave.allocation) del empleado
+func (s *LeaveService) GetBalances(ctx context.Context, employeeID int) ([]*LeaveBalance, error) {
+	result, err := s.client.ExecuteKW(ctx, "hr.leave.allocation", "search_read", []interface{}{
+		[]interface{}{
+			[]interface{}{"employee_id", "=", employeeID},
+			[]interface{}{"state", "=", "validate"},
+		},
+	}, map[string]interface{}{
+		"fields": []string{"id", "holiday_status_id", "number_of_days", "leaves_taken"},
+		"order":  "holiday_status_id",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("formato de respuesta inválido")
+	}
+
+	balances := []*LeaveBalance{}
+	byType := map[int]*LeaveBalance{}
+	for _, record := range records {
+		data, ok := record.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		leaveType, ok := data["holiday_status_id"].([]interface{})
+		if !ok || len(leaveType) < 2 {
+			continue
+		}
+		typeID, _ := leaveType[0].(float64)
+		typeName, _ := leaveType[1].(string)
+
+		balance, ok := byType[int(typeID)]
+		if !ok {
+			balance = &LeaveBalance{LeaveTypeID: int(typeID), LeaveType: typeName, AllocationIDs: []int{}}
+			byType[int(typeID)] = balance
+			balances = append(balances, balance)
+		}
+		if id, ok := data["id"].(float64); ok {
+			balance.AllocationIDs = append(balance.AllocationIDs, int(id))
+		}
+		if days, ok := data["number_of_days"].(float64); ok {
+			balance.Allocated += days
+		}
+		if taken, ok := data["leaves_taken"].(float64); ok {
+			balance.Taken += taken
+		}
+	}
+
+	for _, balance := range balances {
+		balance.Remaining = balance.Allocated - balance.Taken
+	}
+	return balances, nil
+}
+
+// HrLeave representa una ausencia en Odoo (modelo hr.leave)
+type HrLeave struct {
+	ID           int       `json:"id"`
+	EmployeeID   int       `json:"employee_id"`
+	LeaveTypeID  int       `json:"leave_type_id"`
+	LeaveType    string    `json:"leave_type"`
+	State        string    `json:"state"`     // draft, confirm, validate1, validate, refuse, cancel
+	DateFrom     string    `json:"date_from"` // YYYY-MM-DD
+	DateTo       string    `json:"date_to"`   // YYYY-MM-DD
+	NumberOfDays float64   `json:"number_of_days"`
+	WriteDate    time.Time `json:"write_date"` // Última modificación en Odoo
+}
+
+// leaveFields son los campos de hr.leave que se leen
+var leaveFields = []string{"id", "employee_id", "holiday_status_id", "state", "request_date_from", "request_date_to", "number_of_days", "write_date"}
+
+// GetLeaves obtiene las ausencias indicadas; las que ya no existen no se devuelven
+func (s *LeaveService) GetLeaves(ctx context.Context, ids []int) ([]*HrLeave, error) {
+	return s.searchLeaves(ctx, []interface{}{
+		[]interface{}{"id", "in", ids},
+	})
+}
+
+// GetLeavesChangedSince obtiene las ausencias creadas o modificadas después de since, las más antiguas primero
+func (s *LeaveService) GetLeavesChangedSince(ctx context.Context, since time.Time) ([]*HrLeave, error) {
+	return s.searchLeaves(ctx, []interface{}{
+		[]interface{}{"write_date", ">", since.UTC().Format(odooDateTime)},
+	})
+}
+
+// searchLeaves lee las ausencias que cumplen domain, incluidas las archivadas (ej: canceladas) para
+// informar también su cambio de estado
+func (s *LeaveService) searchLeaves(ctx context.Context, domain []interface{}) ([]*HrLeave, error) {
+	result, err := s.client.ExecuteKW(ctx, "hr.leave", "search_read", []interface{}{domain}, map[string]interface{}{
+		"fields":  leaveFields,
+		"order":   "write_date asc, id asc",
+		"context": map[string]interface{}{"active_test": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("formato de respuesta inválido")
+	}
+
+	leaves := make([]*HrLeave, 0, len(records))
+	for _, record := range records {
+		data, ok := record.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		leaves = append(leaves, parseLeaveData(data))
+	}
+	return leaves, nil
+}
+
+// parseLeaveData convierte los datos crudos de Odoo a HrLeave
+func parseLeaveData(data map[string]interface{}) *HrLeave {
+	leave := &HrLeave{}
+
+	if id, ok := data["id"].(float64); ok {
+		leave.ID = int(id)
+	}
+	if employee, ok := data["employee_id"].([]interface{}); ok && len(employee) > 0 {
+		if id, ok := employee[0].(float64); ok {
+			leave.EmployeeID = int(id)
+		}
+	}
+	if leaveType, ok := data["holiday_status_id"].([]interface{}); ok && len(leaveType) >= 2 {
+		if id, ok := leaveType[0].(float64); ok {
+			leave.LeaveTypeID = int(id)
+		}
+		leave.LeaveType, _ = leaveType[1].(string)
+	}
+	// Odoo devuelve false en los campos vacíos, por eso se valida el tipo
+	if state, ok := data["state"].(string); ok {
+		leave.State = state
+	}
+	if dateFrom, ok := data["request_date_from"].(string); ok {
+		leave.DateFrom = dateFrom
+	}
+	if dateTo, ok := data["request_date_to"].(string); ok {
+		leave.DateTo = dateTo
+	}
+	if days, ok := data["number_of_days"].(float64); ok {
+		leave.NumberOfDays = days
+	}
+	if writeDate, ok := data["write_date"].(string); ok {
+		if t, err := time.Parse(odooDateTime, writeDate); err == nil {
+			leave.WriteDate = t.UTC()
+		}
+	}
+
+	return leave
+}
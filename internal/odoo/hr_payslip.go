@@ -0,0 +1,98 @@
+package odoo
+
+import (
+	"context"
+	"fmt"
+)
+
+// HrPayslip representa una liquidación de sueldo en Odoo (modelo hr.payslip, módulo hr_payroll)
+type HrPayslip struct {
+	ID         int     `json:"id"`
+	EmployeeID int     `json:"employee_id"`
+	Name       string  `json:"name"`
+	Number     string  `json:"number,omitempty"`
+	DateFrom   string  `json:"date_from"` // YYYY-MM-DD
+	DateTo     string  `json:"date_to"`   // YYYY-MM-DD
+	State      string  `json:"state"`
+	NetWage    float64 `json:"net_wage"`
+}
+
+// PayslipService proporciona operaciones de consulta de liquidaciones
+type PayslipService struct {
+	client *Client
+}
+
+// NewPayslipService crea un nuevo servicio de liquidaciones
+func NewPayslipService(client *Client) *PayslipService {
+	return &PayslipService{
+		client: client,
+	}
+}
+
+// GetEmployeePayslips obtiene las liquidaciones cerradas de un empleado, las más recientes primero
+// Los borradores y las liquidaciones en cálculo no se muestran al empleado
+func (s *PayslipService) GetEmployeePayslips(ctx context.Context, employeeID, limit int) ([]*HrPayslip, error) {
+	result, err := s.client.ExecuteKW(ctx, "hr.payslip", "search_read", []interface{}{
+		[]interface{}{
+			[]interface{}{"employee_id", "=", employeeID},
+			[]interface{}{"state", "in", []string{"done", "paid"}},
+		},
+	}, map[string]interface{}{
+		"fields": []string{"id", "employee_id", "name", "number", "date_from", "date_to", "state", "net_wage"},
+		"order":  "date_from desc",
+		"limit":  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("formato de respuesta inválido")
+	}
+
+	payslips := make([]*HrPayslip, 0, len(records))
+	for _, record := range records {
+		data, ok := record.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		payslips = append(payslips, parsePayslipData(data))
+	}
+	return payslips, nil
+}
+
+// parsePayslipData convierte los datos crudos de Odoo a HrPayslip
+func parsePayslipData(data map[string]interface{}) *HrPayslip {
+	payslip := &HrPayslip{}
+
+	if id, ok := data["id"].(float64); ok {
+		payslip.ID = int(id)
+	}
+	if employee, ok := data["employee_id"].([]interface{}); ok && len(employee) > 0 {
+		if id, ok := employee[0].(float64); ok {
+			payslip.EmployeeID = int(id)
+		}
+	}
+	// Odoo devuelve false en los campos vacíos, por eso se valida el tipo
+	if name, ok := data["name"].(string); ok {
+		payslip.Name = name
+	}
+	if number, ok := data["number"].(string); ok {
+		payslip.Number = number
+	}
+	if dateFrom, ok := data["date_from"].(string); ok {
+		payslip.DateFrom = dateFrom
+	}
+	if dateTo, ok := data["date_to"].(string); ok {
+		payslip.DateTo = dateTo
+	}
+	if state, ok := data["state"].(string); ok {
+		payslip.State = state
+	}
+	if netWage, ok := data["net_wage"].(float64); ok {
+		payslip.NetWage = netWage
+	}
+
+	return payslip
+}
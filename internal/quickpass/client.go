@@ -1 +1,139 @@
 package quickpass
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/breaker"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tracing"
+)
+
+var tracer = tracing.Tracer("quickpass")
+
+// Client es el cliente HTTP de la API REST de Quickpass
+type Client struct {
+	URL       string
+	apiKey    secret.Value
+	apiSecret secret.Value
+
+	httpClient *http.Client
+	breaker    *breaker.Breaker
+}
+
+// APIError representa una respuesta de error de Quickpass
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("error HTTP %d de Quickpass: %s", e.StatusCode, e.Message)
+}
+
+// NewClient inicializa el cliente con la configuración indicada
+func NewClient(config *Config) *Client {
+	return &Client{
+		URL:       strings.TrimRight(config.URL, "/"),
+		apiKey:    config.APIKey,
+		apiSecret: config.APISecret,
+		httpClient: &http.Client{
+			Timeout: config.Timeout,
+		},
+		breaker: breaker.New(breaker.DefaultThreshold, breaker.DefaultCooldown),
+	}
+}
+
+// BreakerState devuelve el estado del circuit breaker de las llamadas a Quickpass
+func (c *Client) BreakerState() breaker.State {
+	return c.breaker.State()
+}
+
+// doRequest realiza una petición a la API de Quickpass y decodifica la respuesta en out
+// Cada llamada queda en un span de la traza ("quickpass GET"), sin los parámetros de la URL
+// Los errores de red y las respuestas 5xx abren el circuit breaker (ver send)
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, out interface{}) (err error) {
+	urlPath, _, _ := strings.Cut(path, "?")
+	ctx, span := tracer.Start(ctx, "quickpass "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.request.method", method),
+		attribute.String("url.full", c.URL+urlPath),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error al serializar la petición: %w", err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+path, reader)
+	if err != nil {
+		return fmt.Errorf("error al crear la petición HTTP: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-API-Key", c.apiKey.Reveal())
+	if !c.apiSecret.IsZero() {
+		req.Header.Set("X-API-Secret", c.apiSecret.Reveal())
+	}
+	tracing.Inject(ctx, req.Header)
+
+	status, respBody, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+
+	if status < 200 || status >= 300 {
+		return &APIError{StatusCode: status, Message: strings.TrimSpace(string(respBody))}
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("error al deserializar la respuesta: %w", err)
+	}
+	return nil
+}
+
+// send envía la petición si el circuit breaker lo permite y le informa el resultado
+// Las respuestas 4xx y la cancelación de la petición no cuentan como fallas de Quickpass
+func (c *Client) send(req *http.Request) (int, []byte, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return 0, nil, fmt.Errorf("Quickpass no disponible: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.breaker.Done(req.Context().Err() == nil)
+		return 0, nil, fmt.Errorf("error al realizar la petición HTTP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.breaker.Done(req.Context().Err() == nil)
+		return 0, nil, fmt.Errorf("error al leer la respuesta: %w", err)
+	}
+	c.breaker.Done(resp.StatusCode >= http.StatusInternalServerError)
+	return resp.StatusCode, respBody, nil
+}
+
+// Ping verifica que la API de Quickpass responda
+func (c *Client) Ping(ctx context.Context) error {
+	return c.doRequest(ctx, http.MethodGet, "/api/v1/ping", nil, nil)
+}
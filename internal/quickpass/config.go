@@ -0,0 +1,55 @@
+package quickpass
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// Config contiene la configuración para conectarse a la API de Quickpass
+type Config struct {
+	URL       string
+	APIKey    secret.Value
+	APISecret secret.Value
+	Timeout   time.Duration
+}
+
+// NewConfigFromEnv crea una configuración desde variables de entorno
+// QUICKPASS_API_KEY y QUICKPASS_API_SECRET también se pueden leer de archivos o del keystore (ver secret.Get)
+func NewConfigFromEnv() (*Config, error) {
+	url := os.Getenv("QUICKPASS_URL")
+	if url == "" {
+		return nil, fmt.Errorf("QUICKPASS_URL no está configurado")
+	}
+
+	apiKey, err := secret.Get("QUICKPASS_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.IsZero() {
+		return nil, fmt.Errorf("QUICKPASS_API_KEY no está configurado")
+	}
+	apiSecret, err := secret.Get("QUICKPASS_API_SECRET")
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := 30 * time.Second
+	if value := os.Getenv("QUICKPASS_TIMEOUT"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("QUICKPASS_TIMEOUT inválido: %s", value)
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	return &Config{
+		URL:       url,
+		APIKey:    apiKey,
+		APISecret: apiSecret,
+		Timeout:   timeout,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package quickpass
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Tipos de marcación
+const (
+	PunchCheckIn  = "check_in"
+	PunchCheckOut = "check_out"
+)
+
+// Punch representa una marcación biométrica registrada en Quickpass
+type Punch struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Type      string    `json:"type"` // check_in | check_out
+	Timestamp time.Time `json:"timestamp"`
+	DeviceID  string    `json:"device_id,omitempty"`
+}
+
+// punchList es la respuesta paginada de /punches
+type punchList struct {
+	Data     []*Punch `json:"data"`
+	NextPage int      `json:"next_page"`
+}
+
+// ListPunches obtiene las marcaciones registradas desde "since" (exclusivo)
+func (c *Client) ListPunches(ctx context.Context, since time.Time) ([]*Punch, error) {
+	punches := []*Punch{}
+	page := 1
+	for page > 0 {
+		var result punchList
+		query := url.Values{
+			"since":    {since.UTC().Format(time.RFC3339)},
+			"page":     {strconv.Itoa(page)},
+			"per_page": {"500"},
+		}
+		if err := c.doRequest(ctx, http.MethodGet, "/api/v1/punches?"+query.Encode(), nil, &result); err != nil {
+			return nil, fmt.Errorf("error obteniendo marcaciones de Quickpass: %w", err)
+		}
+		punches = append(punches, result.Data...)
+		page = result.NextPage
+	}
+	return punches, nil
+}
@@ -0,0 +1,11 @@
+package quickpass
+
+// TimeOffRequest representa una solicitud de tiempo libre ingresada por un trabajador en Quickpass
+type TimeOffRequest struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	LeaveType string `json:"leave_type"` // Nombre del tipo de ausencia (ej: "Vacaciones")
+	DateFrom  string `json:"date_from"`  // YYYY-MM-DD
+	DateTo    string `json:"date_to"`    // YYYY-MM-DD
+	Reason    string `json:"reason,omitempty"`
+}
@@ -0,0 +1,82 @@
+package quickpass
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// User representa un usuario (trabajador) en Quickpass
+type User struct {
+	ID             string `json:"id,omitempty"`
+	RUT            string `json:"rut"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	SecondLastName string `json:"second_last_name,omitempty"`
+	Email          string `json:"email,omitempty"`
+	Phone          string `json:"phone,omitempty"`
+	Birthday       string `json:"birthday,omitempty"` // YYYY-MM-DD
+	Gender         string `json:"gender,omitempty"`
+	Nationality    string `json:"nationality,omitempty"`
+	PhotoURL       string `json:"photo_url,omitempty"`
+	Active         bool   `json:"active"`
+}
+
+// userList es la respuesta paginada de /users
+type userList struct {
+	Data     []*User `json:"data"`
+	NextPage int     `json:"next_page"`
+}
+
+// ListUsers obtiene todos los usuarios de Quickpass recorriendo todas las páginas
+func (c *Client) ListUsers(ctx context.Context) ([]*User, error) {
+	users := []*User{}
+	page := 1
+	for page > 0 {
+		var result userList
+		query := url.Values{"page": {strconv.Itoa(page)}, "per_page": {"200"}}
+		if err := c.doRequest(ctx, http.MethodGet, "/api/v1/users?"+query.Encode(), nil, &result); err != nil {
+			return nil, fmt.Errorf("error obteniendo usuarios de Quickpass: %w", err)
+		}
+		users = append(users, result.Data...)
+		page = result.NextPage
+	}
+	return users, nil
+}
+
+// GetUser obtiene un usuario por su ID
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	var user User
+	if err := c.doRequest(ctx, http.MethodGet, "/api/v1/users/"+url.PathEscape(id), nil, &user); err != nil {
+		return nil, fmt.Errorf("error obteniendo usuario %s de Quickpass: %w", id, err)
+	}
+	return &user, nil
+}
+
+// CreateUser crea un usuario y devuelve el registro con su ID asignado
+func (c *Client) CreateUser(ctx context.Context, user *User) (*User, error) {
+	var created User
+	if err := c.doRequest(ctx, http.MethodPost, "/api/v1/users", user, &created); err != nil {
+		return nil, fmt.Errorf("error creando usuario en Quickpass: %w", err)
+	}
+	return &created, nil
+}
+
+// UpdateUser actualiza un usuario existente
+func (c *Client) UpdateUser(ctx context.Context, id string, user *User) error {
+	if err := c.doRequest(ctx, http.MethodPut, "/api/v1/users/"+url.PathEscape(id), user, nil); err != nil {
+		return fmt.Errorf("error actualizando usuario %s en Quickpass: %w", id, err)
+	}
+	return nil
+}
+
+// Verify comprueba que las credenciales sean válidas leyendo la primera página de usuarios
+func (c *Client) Verify(ctx context.Context) error {
+	query := url.Values{"page": {"1"}, "per_page": {"1"}}
+	if err := c.doRequest(ctx, http.MethodGet, "/api/v1/users?"+query.Encode(), nil, &userList{}); err != nil {
+		return fmt.Errorf("error verificando credenciales de Quickpass: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/rut"
+)
+
+// Action es lo que se hace con un campo cuando el llamador no puede verlo
+type Action string
+
+// Acciones de redacción
+const (
+	Remove    Action = "remove" // Se elimina el campo
+	MaskRUT   Action = "rut"    // "**.***.678-9"
+	MaskEmail Action = "email"  // "j***@empresa.cl"
+	MaskPhone Action = "phone"  // "*****5678"
+	MaskText  Action = "text"   // Texto libre: se ocultan los RUT y emails que contenga
+)
+
+// hidden reemplaza los valores eliminados dentro de un diff de campos
+const hidden = "***"
+
+// Rule define cómo se protege un campo
+type Rule struct {
+	Action Action
+	Scopes []string // Scopes que ven el valor original (admin los incluye todos)
+}
+
+// Policy asocia nombres de campos JSON con su regla
+// Se aplica a cualquier profundidad de la respuesta y también a los diffs de campos
+// ({"field": "rut", "before": ..., "after": ...}) que generan los planes de sincronización
+type Policy map[string]Rule
+
+// personal son los scopes que ven los datos personales: pii:read y el propio empleado en el portal
+var personal = []string{auth.ScopePIIRead, auth.ScopeSelf}
+
+// DefaultPolicy protege los datos personales de empleados y usuarios (Ley 19.628)
+var DefaultPolicy = Policy{
+	"identification_id": {Action: MaskRUT, Scopes: personal},
+	"rut":               {Action: MaskRUT, Scopes: personal},
+	"birthday":          {Action: Remove, Scopes: personal},
+	"birthday_parsed":   {Action: Remove, Scopes: personal},
+	"gender":            {Action: Remove, Scopes: personal},
+	"private_email":     {Action: MaskEmail, Scopes: personal},
+	"private_phone":     {Action: MaskPhone, Scopes: personal},
+	"private_street":    {Action: Remove, Scopes: personal},
+	"private_city":      {Action: Remove, Scopes: personal},
+	"private_state_id":  {Action: Remove, Scopes: personal},
+	"private_address":   {Action: Remove, Scopes: personal},
+	"hr_commune":        {Action: Remove, Scopes: personal},
+	"commune":           {Action: Remove, Scopes: personal},
+	"description":       {Action: MaskText, Scopes: personal},
+	"details":           {Action: MaskText, Scopes: personal},
+}
+
+// Exempt indica si el llamador puede ver todos los campos de la política sin redactar
+func (p Policy) Exempt(allowed func(scopes ...string) bool) bool {
+	for _, rule := range p {
+		if !allowed(rule.Scopes...) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply devuelve el valor (decodificado de JSON) con los campos que el llamador no puede ver
+// eliminados o enmascarados; allowed indica si el llamador tiene alguno de los scopes dados
+func (p Policy) Apply(value interface{}, allowed func(scopes ...string) bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// Diff de campos: before y after se protegen con la regla del campo que describen
+		if field, ok := v["field"].(string); ok {
+			for _, key := range []string{"before", "after"} {
+				if current, ok := v[key].(string); ok {
+					v[key] = p.Field(field, current, allowed)
+				}
+			}
+		}
+
+		for key, child := range v {
+			rule, ok := p[key]
+			if !ok || allowed(rule.Scopes...) {
+				v[key] = p.Apply(child, allowed)
+				continue
+			}
+			if masked, keep := rule.apply(p.Apply(child, allowed)); keep {
+				v[key] = masked
+			} else {
+				delete(v, key)
+			}
+		}
+		return v
+
+	case []interface{}:
+		for i, child := range v {
+			v[i] = p.Apply(child, allowed)
+		}
+		return v
+
+	default:
+		return v
+	}
+}
+
+// Field protege el valor de texto de un campo; los campos que se eliminan quedan como "***"
+// Se usa para datos que no pasan por JSON, como los diffs de un plan en texto
+func (p Policy) Field(field, value string, allowed func(scopes ...string) bool) string {
+	rule, ok := p[field]
+	if !ok || value == "" || allowed(rule.Scopes...) {
+		return value
+	}
+	if masked, keep := rule.apply(value); keep {
+		return masked.(string)
+	}
+	return hidden
+}
+
+// apply protege un valor; devuelve false si el campo debe eliminarse
+func (r Rule) apply(value interface{}) (interface{}, bool) {
+	if r.Action == Remove {
+		return nil, false
+	}
+	if r.Action == MaskText {
+		// Los detalles de los errores son objetos: se ocultan los textos que contengan
+		return maskTexts(value), true
+	}
+	text, ok := value.(string)
+	if !ok {
+		// Odoo devuelve false en los campos vacíos: no hay nada que ocultar
+		if value == nil || value == false {
+			return value, true
+		}
+		return nil, false
+	}
+
+	switch r.Action {
+	case MaskRUT:
+		return rut.Mask(text), true
+	case MaskEmail:
+		return Email(text), true
+	case MaskPhone:
+		return Phone(text), true
+	}
+	return nil, false
+}
+
+// maskTexts aplica Text a todos los textos de un valor JSON, incluidos los de objetos y listas
+func maskTexts(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return Text(v)
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = maskTexts(child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = maskTexts(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// JSON aplica la política a un cuerpo JSON; devuelve el cuerpo original si no es JSON válido
+func (p Policy) JSON(body []byte, allowed func(scopes ...string) bool) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(p.Apply(value, allowed)); err != nil {
+		return body
+	}
+	return out.Bytes()
+}
+
+// Email oculta la parte local de un email salvo su primera letra: "j***@empresa.cl"
+func Email(value string) string {
+	local, domain, found := strings.Cut(value, "@")
+	if !found || local == "" {
+		if value == "" {
+			return ""
+		}
+		return hidden
+	}
+	_, size := utf8.DecodeRuneInString(local)
+	return local[:size] + hidden + "@" + domain
+}
+
+// Phone oculta todos los dígitos de un teléfono salvo los cuatro últimos
+func Phone(value string) string {
+	digits := 0
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+
+	var b strings.Builder
+	seen := 0
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			seen++
+			if digits-seen >= 4 {
+				r = '*'
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var (
+	rutPattern   = regexp.MustCompile(`\b\d{1,2}\.?\d{3}\.?\d{3}-[\dkK]\b`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// Text oculta los RUT y emails que aparezcan en un texto libre (mensajes de log, descripciones)
+func Text(value string) string {
+	value = rutPattern.ReplaceAllStringFunc(value, rut.Mask)
+	return emailPattern.ReplaceAllStringFunc(value, Email)
+}
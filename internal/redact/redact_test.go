@@ -0,0 +1,122 @@
+package redact
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+)
+
+// scopes devuelve la función allowed de un llamador con los scopes dados (admin los incluye todos)
+func scopes(granted ...string) func(...string) bool {
+	principal := &auth.Principal{Scopes: granted}
+	return principal.HasScope
+}
+
+func TestPolicyJSON(t *testing.T) {
+	employee := `{"success": true, "data": [{"id": 7, "name": "Juan Pérez", "identification_id": "12.345.678-5",
+		"birthday": "1990-01-31", "private_email": "juan.perez@gmail.com", "private_phone": "+56 9 1234 5678",
+		"private_street": false, "work_email": "jperez@empresa.cl"}]}`
+	plan := `{"changes": [{"description": "Crear usuario 12.345.678-5", "fields": [
+		{"field": "rut", "before": "", "after": "12.345.678-5"}, {"field": "birthday", "before": "1990-01-31", "after": "1990-02-01"},
+		{"field": "name", "before": "Juan", "after": "Juan Pérez"}]}]}`
+	failure := `{"error": {"code": "method_not_allowed", "message": "Método no permitido",
+		"details": {"allowed_methods": ["GET", "HEAD"], "reason": "RUT 12.345.678-5 duplicado", "rut": "12.345.678-5"}}}`
+
+	tests := []struct {
+		name    string
+		body    string
+		allowed func(...string) bool
+		want    string
+	}{
+		{
+			name:    "sin pii:read se enmascara o elimina",
+			body:    employee,
+			allowed: scopes(auth.ScopeEmployeesRead),
+			want: `{"success": true, "data": [{"id": 7, "name": "Juan Pérez", "identification_id": "**.***.678-5",
+				"private_email": "j***@gmail.com", "private_phone": "+** * **** 5678",
+				"work_email": "jperez@empresa.cl"}]}`,
+		},
+		{"con pii:read se devuelve sin cambios", employee, scopes(auth.ScopeEmployeesRead, auth.ScopePIIRead), employee},
+		{"admin ve todo", employee, scopes(auth.ScopeAdmin), employee},
+		{"el propio empleado ve sus datos", employee, scopes(auth.ScopeSelf), employee},
+		{
+			name:    "diffs de un plan",
+			body:    plan,
+			allowed: scopes(auth.ScopeEmployeesWrite),
+			want: `{"changes": [{"description": "Crear usuario **.***.678-5", "fields": [
+				{"field": "rut", "before": "", "after": "**.***.678-5"}, {"field": "birthday", "before": "***", "after": "***"},
+				{"field": "name", "before": "Juan", "after": "Juan Pérez"}]}]}`,
+		},
+		{
+			name:    "los detalles de un error se conservan con sus datos personales enmascarados",
+			body:    failure,
+			allowed: scopes(auth.ScopeEmployeesWrite),
+			want: `{"error": {"code": "method_not_allowed", "message": "Método no permitido",
+				"details": {"allowed_methods": ["GET", "HEAD"], "reason": "RUT **.***.678-5 duplicado", "rut": "**.***.678-5"}}}`,
+		},
+		{"no es JSON", `no es json`, scopes(), `no es json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DefaultPolicy.JSON([]byte(tt.body), tt.allowed)
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal(got, &gotValue); err != nil {
+				if string(got) != tt.want {
+					t.Fatalf("JSON() = %s, se esperaba %s", got, tt.want)
+				}
+				return
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Fatalf("JSON() = %s\nse esperaba %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyExempt(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed func(...string) bool
+		want    bool
+	}{
+		{"admin", scopes(auth.ScopeAdmin), true},
+		{"pii:read", scopes(auth.ScopePIIRead), true},
+		{"empleado", scopes(auth.ScopeSelf), true},
+		{"solo lectura", scopes(auth.ScopeEmployeesRead), false},
+		{"sin scopes", scopes(), false},
+	}
+	for _, tt := range tests {
+		if got := DefaultPolicy.Exempt(tt.allowed); got != tt.want {
+			t.Errorf("%s: Exempt() = %v, se esperaba %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEmail(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"juan.perez@gmail.com", "j***@gmail.com"},
+		{"ñandú@empresa.cl", "ñ***@empresa.cl"},
+		{"éric@empresa.cl", "é***@empresa.cl"},
+		{"@empresa.cl", "***"},
+		{"sin-arroba", "***"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := Email(tt.value)
+		if got != tt.want {
+			t.Errorf("Email(%q) = %q, se esperaba %q", tt.value, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Email(%q) = %q no es UTF-8 válido", tt.value, got)
+		}
+	}
+}
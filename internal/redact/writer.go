@@ -0,0 +1,22 @@
+package redact
+
+import "io"
+
+// Writer oculta los RUT y emails de todo lo que se escribe en el destino
+// Se usa como salida del paquete log para que ningún mensaje deje datos personales en los logs
+type Writer struct {
+	out io.Writer
+}
+
+// NewWriter envuelve la salida de logs
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Write implementa io.Writer; log escribe cada mensaje completo en una sola llamada
+func (w *Writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, Text(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
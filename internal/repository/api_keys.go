@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const apiKeyColumns = `id, tenant, name, prefix, key_hash, scopes, rotated_from, expires_at, revoked_at, last_used_at, created_at`
+
+// CreateAPIKey guarda una clave nueva
+func (s *SQLStore) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	key.CreatedAt = now()
+
+	var rotatedFrom sql.NullInt64
+	if key.RotatedFrom != nil {
+		rotatedFrom = sql.NullInt64{Int64: *key.RotatedFrom, Valid: true}
+	}
+
+	err := s.queryRow(ctx, `
+		INSERT INTO api_keys (tenant, name, prefix, key_hash, scopes, rotated_from, expires_at, revoked_at, last_used_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		key.Tenant, key.Name, key.Prefix, key.KeyHash, strings.Join(key.Scopes, ","), rotatedFrom,
+		nullTime(key.ExpiresAt), nullTime(key.RevokedAt), nullTime(key.LastUsedAt), key.CreatedAt).Scan(&key.ID)
+	if err != nil {
+		return fmt.Errorf("error creando clave de API: %w", err)
+	}
+	return nil
+}
+
+// UpdateAPIKey actualiza nombre, scopes, expiración y revocación de una clave
+func (s *SQLStore) UpdateAPIKey(ctx context.Context, key *APIKey) error {
+	res, err := s.exec(ctx, `
+		UPDATE api_keys SET name = ?, scopes = ?, expires_at = ?, revoked_at = ?
+		WHERE id = ?`,
+		key.Name, strings.Join(key.Scopes, ","), nullTime(key.ExpiresAt), nullTime(key.RevokedAt), key.ID)
+	if err != nil {
+		return fmt.Errorf("error actualizando clave de API: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetAPIKey obtiene una clave por ID
+func (s *SQLStore) GetAPIKey(ctx context.Context, id int64) (*APIKey, error) {
+	return s.getAPIKey(ctx, `id = ?`, id)
+}
+
+// GetAPIKeyByHash obtiene una clave por el hash de su valor
+func (s *SQLStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	return s.getAPIKey(ctx, `key_hash = ?`, keyHash)
+}
+
+func (s *SQLStore) getAPIKey(ctx context.Context, where string, args ...interface{}) (*APIKey, error) {
+	rows, err := s.query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo clave de API: %w", err)
+	}
+	keys, err := scanAPIKeys(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, ErrNotFound
+	}
+	return keys[0], nil
+}
+
+// ListAPIKeys lista las claves de un tenant, incluidas las revocadas y expiradas
+func (s *SQLStore) ListAPIKeys(ctx context.Context, tenant string) ([]*APIKey, error) {
+	rows, err := s.query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE tenant = ? ORDER BY id`, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("error listando claves de API: %w", err)
+	}
+	return scanAPIKeys(rows)
+}
+
+// TouchAPIKey registra el último uso de una clave
+func (s *SQLStore) TouchAPIKey(ctx context.Context, id int64, at time.Time) error {
+	if _, err := s.exec(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, at.UTC(), id); err != nil {
+		return fmt.Errorf("error actualizando uso de clave de API: %w", err)
+	}
+	return nil
+}
+
+func scanAPIKeys(rows *sql.Rows) ([]*APIKey, error) {
+	defer rows.Close()
+
+	keys := []*APIKey{}
+	for rows.Next() {
+		k := &APIKey{}
+		var scopes string
+		var rotatedFrom sql.NullInt64
+		var expiresAt, revokedAt, lastUsedAt sql.NullTime
+		if err := rows.Scan(&k.ID, &k.Tenant, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &rotatedFrom,
+			&expiresAt, &revokedAt, &lastUsedAt, &k.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo clave de API: %w", err)
+		}
+		k.Scopes = strings.Split(scopes, ",")
+		if rotatedFrom.Valid {
+			id := rotatedFrom.Int64
+			k.RotatedFrom = &id
+		}
+		k.ExpiresAt = timePtr(expiresAt)
+		k.RevokedAt = timePtr(revokedAt)
+		k.LastUsedAt = timePtr(lastUsedAt)
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const auditColumns = `id, tenant, occurred_at, actor_method, actor_id, actor_name, action, method, path, employee_ids, changes, outcome, status, request_id, remote_addr`
+
+// AppendAudit agrega una entrada al registro de auditoría
+func (s *SQLStore) AppendAudit(ctx context.Context, entry *AuditEntry) error {
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = now()
+	}
+
+	// Los IDs se guardan entre comas (",7,12,") para poder filtrar por empleado con LIKE
+	employeeIDs := ""
+	if len(entry.EmployeeIDs) > 0 {
+		employeeIDs = "," + joinIDs(entry.EmployeeIDs) + ","
+	}
+	changes := ""
+	if len(entry.Changes) > 0 {
+		data, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return fmt.Errorf("error serializando cambios auditados: %w", err)
+		}
+		changes = string(data)
+	}
+
+	err := s.queryRow(ctx, `
+		INSERT INTO audit_log (tenant, occurred_at, actor_method, actor_id, actor_name, action, method, path,
+			employee_ids, changes, outcome, status, request_id, remote_addr)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		entry.Tenant, entry.OccurredAt, entry.ActorMethod, entry.ActorID, entry.ActorName, entry.Action,
+		entry.Method, entry.Path, employeeIDs, changes, entry.Outcome, entry.Status, entry.RequestID,
+		entry.RemoteAddr).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("error guardando entrada de auditoría: %w", err)
+	}
+	return nil
+}
+
+// ListAudit lista el registro de auditoría, lo más reciente primero
+func (s *SQLStore) ListAudit(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error) {
+	w := &whereBuilder{}
+	if filter.Tenant != "" {
+		w.add("tenant = ?", filter.Tenant)
+	}
+	if filter.EmployeeID != 0 {
+		w.add("employee_ids LIKE ?", "%,"+strconv.Itoa(filter.EmployeeID)+",%")
+	}
+	if filter.Actor != "" {
+		w.conds = append(w.conds, "(actor_id = ? OR actor_name = ?)")
+		w.args = append(w.args, filter.Actor, filter.Actor)
+	}
+	if filter.Action != "" {
+		w.add("action = ?", filter.Action)
+	}
+	if filter.Outcome != "" {
+		w.add("outcome = ?", filter.Outcome)
+	}
+	if filter.RequestID != "" {
+		w.add("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		w.add("occurred_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		w.add("occurred_at < ?", filter.To.UTC())
+	}
+	if filter.BeforeID > 0 {
+		w.add("id < ?", filter.BeforeID)
+	}
+
+	rows, err := s.query(ctx, `SELECT `+auditColumns+` FROM audit_log`+w.String()+
+		` ORDER BY id DESC LIMIT `+strconv.Itoa(limitOrDefault(filter.Limit)), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando auditoría: %w", err)
+	}
+	return scanAudit(rows)
+}
+
+func scanAudit(rows *sql.Rows) ([]*AuditEntry, error) {
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		e := &AuditEntry{}
+		var employeeIDs, changes string
+		if err := rows.Scan(&e.ID, &e.Tenant, &e.OccurredAt, &e.ActorMethod, &e.ActorID, &e.ActorName,
+			&e.Action, &e.Method, &e.Path, &employeeIDs, &changes, &e.Outcome, &e.Status,
+			&e.RequestID, &e.RemoteAddr); err != nil {
+			return nil, fmt.Errorf("error leyendo entrada de auditoría: %w", err)
+		}
+		e.EmployeeIDs = splitIDs(strings.Trim(employeeIDs, ","))
+		if e.EmployeeIDs == nil {
+			e.EmployeeIDs = []int{}
+		}
+		if changes != "" {
+			if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+				return nil, fmt.Errorf("error leyendo cambios auditados: %w", err)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
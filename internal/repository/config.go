@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// Drivers soportados
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Config contiene la configuración de la base de datos (ver config.DatabaseConfig)
+type Config struct {
+	Driver string       // sqlite | postgres
+	DSN    secret.Value // Ruta del archivo (sqlite) o cadena de conexión (postgres, incluye la contraseña)
+}
+
+// Open abre el repositorio correspondiente al driver configurado
+func Open(config *Config) (*SQLStore, error) {
+	switch config.Driver {
+	case DriverSQLite:
+		return NewSQLite(config.DSN.Reveal())
+	case DriverPostgres:
+		return NewPostgres(config.DSN.Reveal())
+	default:
+		return nil, fmt.Errorf("driver de base de datos no soportado: %s", config.Driver)
+	}
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const deadLetterColumns = `id, tenant, flow, item_key, payload, error_class, error, attempts, status, next_retry_at, last_attempt_at, created_at, updated_at`
+
+// SaveDeadLetter crea o actualiza un elemento fallido
+func (s *SQLStore) SaveDeadLetter(ctx context.Context, d *DeadLetter) error {
+	ts := now()
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = ts
+	}
+	d.UpdatedAt = ts
+
+	err := s.queryRow(ctx, `
+		INSERT INTO dead_letters (tenant, flow, item_key, payload, error_class, error, attempts, status,
+			next_retry_at, last_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant, flow, item_key) DO UPDATE SET
+			payload = excluded.payload,
+			error_class = excluded.error_class,
+			error = excluded.error,
+			attempts = excluded.attempts,
+			status = excluded.status,
+			next_retry_at = excluded.next_retry_at,
+			last_attempt_at = excluded.last_attempt_at,
+			updated_at = excluded.updated_at
+		RETURNING id`,
+		d.Tenant, d.Flow, d.ItemKey, string(d.Payload), d.ErrorClass, d.Error, d.Attempts, d.Status,
+		nullTime(d.NextRetryAt), nullTime(d.LastAttemptAt), d.CreatedAt, d.UpdatedAt).Scan(&d.ID)
+	if err != nil {
+		return fmt.Errorf("error guardando elemento fallido: %w", err)
+	}
+	return nil
+}
+
+// GetDeadLetter obtiene un elemento fallido por ID
+func (s *SQLStore) GetDeadLetter(ctx context.Context, id int64) (*DeadLetter, error) {
+	return s.getDeadLetter(ctx, `id = ?`, id)
+}
+
+// GetDeadLetterByKey obtiene un elemento fallido por su clave dentro del flujo
+func (s *SQLStore) GetDeadLetterByKey(ctx context.Context, tenant, flow, itemKey string) (*DeadLetter, error) {
+	return s.getDeadLetter(ctx, `tenant = ? AND flow = ? AND item_key = ?`, tenant, flow, itemKey)
+}
+
+func (s *SQLStore) getDeadLetter(ctx context.Context, where string, args ...interface{}) (*DeadLetter, error) {
+	rows, err := s.query(ctx, `SELECT `+deadLetterColumns+` FROM dead_letters WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo elemento fallido: %w", err)
+	}
+	items, err := scanDeadLetters(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return items[0], nil
+}
+
+// ListDeadLetters lista los elementos fallidos, los más recientes primero
+func (s *SQLStore) ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*DeadLetter, error) {
+	w := &whereBuilder{}
+	if filter.Tenant != "" {
+		w.add("tenant = ?", filter.Tenant)
+	}
+	if filter.Flow != "" {
+		w.add("flow = ?", filter.Flow)
+	}
+	if filter.Status != "" {
+		w.add("status = ?", filter.Status)
+	}
+
+	rows, err := s.query(ctx, `SELECT `+deadLetterColumns+` FROM dead_letters`+w.String()+
+		` ORDER BY updated_at DESC LIMIT `+strconv.Itoa(limitOrDefault(filter.Limit)), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando elementos fallidos: %w", err)
+	}
+	return scanDeadLetters(rows)
+}
+
+// DueDeadLetters devuelve los elementos cuyo reintento ya venció (de todos los tenants si tenant es vacío)
+func (s *SQLStore) DueDeadLetters(ctx context.Context, tenant string, at time.Time, limit int) ([]*DeadLetter, error) {
+	w := &whereBuilder{}
+	w.add("status = ?", DeadLetterRetrying)
+	w.add("next_retry_at <= ?", at.UTC())
+	if tenant != "" {
+		w.add("tenant = ?", tenant)
+	}
+
+	rows, err := s.query(ctx, `SELECT `+deadLetterColumns+` FROM dead_letters`+w.String()+
+		` ORDER BY next_retry_at LIMIT `+strconv.Itoa(limitOrDefault(limit)), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo reintentos pendientes: %w", err)
+	}
+	return scanDeadLetters(rows)
+}
+
+func scanDeadLetters(rows *sql.Rows) ([]*DeadLetter, error) {
+	defer rows.Close()
+
+	items := []*DeadLetter{}
+	for rows.Next() {
+		d := &DeadLetter{}
+		var payload string
+		var nextRetryAt, lastAttemptAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.Tenant, &d.Flow, &d.ItemKey, &payload, &d.ErrorClass, &d.Error,
+			&d.Attempts, &d.Status, &nextRetryAt, &lastAttemptAt, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo elemento fallido: %w", err)
+		}
+		d.Payload = []byte(payload)
+		d.NextRetryAt = timePtr(nextRetryAt)
+		d.LastAttemptAt = timePtr(lastAttemptAt)
+		items = append(items, d)
+	}
+	return items, rows.Err()
+}
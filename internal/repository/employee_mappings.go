@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const employeeMappingColumns = `tenant, odoo_employee_id, quickpass_user_id, rut, match_method, last_synced_hash, last_synced_at, created_at, updated_at`
+
+// SaveEmployeeMapping crea o actualiza el mapeo de un empleado
+func (s *SQLStore) SaveEmployeeMapping(ctx context.Context, m *EmployeeMapping) error {
+	ts := now()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = ts
+	}
+	m.UpdatedAt = ts
+
+	_, err := s.exec(ctx, `
+		INSERT INTO employee_mappings (`+employeeMappingColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant, odoo_employee_id) DO UPDATE SET
+			quickpass_user_id = excluded.quickpass_user_id,
+			rut = excluded.rut,
+			match_method = excluded.match_method,
+			last_synced_hash = excluded.last_synced_hash,
+			last_synced_at = excluded.last_synced_at,
+			updated_at = excluded.updated_at`,
+		m.Tenant, m.OdooEmployeeID, m.QuickpassUserID, m.RUT, m.MatchMethod,
+		m.LastSyncedHash, nullTime(m.LastSyncedAt), m.CreatedAt, m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error guardando mapeo de empleado: %w", err)
+	}
+	return nil
+}
+
+// GetEmployeeMappingByOdooID busca el mapeo por ID de hr.employee
+func (s *SQLStore) GetEmployeeMappingByOdooID(ctx context.Context, tenant string, odooEmployeeID int) (*EmployeeMapping, error) {
+	return s.getEmployeeMapping(ctx, `tenant = ? AND odoo_employee_id = ?`, tenant, odooEmployeeID)
+}
+
+// GetEmployeeMappingByQuickpassID busca el mapeo por ID de usuario Quickpass
+func (s *SQLStore) GetEmployeeMappingByQuickpassID(ctx context.Context, tenant, quickpassUserID string) (*EmployeeMapping, error) {
+	return s.getEmployeeMapping(ctx, `tenant = ? AND quickpass_user_id = ?`, tenant, quickpassUserID)
+}
+
+func (s *SQLStore) getEmployeeMapping(ctx context.Context, where string, args ...interface{}) (*EmployeeMapping, error) {
+	rows, err := s.query(ctx, `SELECT `+employeeMappingColumns+` FROM employee_mappings WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo mapeo de empleado: %w", err)
+	}
+	mappings, err := scanEmployeeMappings(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) == 0 {
+		return nil, ErrNotFound
+	}
+	return mappings[0], nil
+}
+
+// ListEmployeeMappings lista todos los mapeos de un tenant
+func (s *SQLStore) ListEmployeeMappings(ctx context.Context, tenant string) ([]*EmployeeMapping, error) {
+	rows, err := s.query(ctx, `SELECT `+employeeMappingColumns+` FROM employee_mappings
+		WHERE tenant = ? ORDER BY odoo_employee_id`, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("error listando mapeos de empleados: %w", err)
+	}
+	return scanEmployeeMappings(rows)
+}
+
+// DeleteEmployeeMapping elimina el mapeo de un empleado
+func (s *SQLStore) DeleteEmployeeMapping(ctx context.Context, tenant string, odooEmployeeID int) error {
+	res, err := s.exec(ctx, `DELETE FROM employee_mappings WHERE tenant = ? AND odoo_employee_id = ?`,
+		tenant, odooEmployeeID)
+	if err != nil {
+		return fmt.Errorf("error eliminando mapeo de empleado: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanEmployeeMappings(rows *sql.Rows) ([]*EmployeeMapping, error) {
+	defer rows.Close()
+
+	mappings := []*EmployeeMapping{}
+	for rows.Next() {
+		m := &EmployeeMapping{}
+		var lastSyncedAt sql.NullTime
+		if err := rows.Scan(&m.Tenant, &m.OdooEmployeeID, &m.QuickpassUserID, &m.RUT, &m.MatchMethod,
+			&m.LastSyncedHash, &lastSyncedAt, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo mapeo de empleado: %w", err)
+		}
+		m.LastSyncedAt = timePtr(lastSyncedAt)
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
+
+// ---- Conflictos de identidad ----
+
+// RecordMappingConflict registra un conflicto; si ya existe sin resolver solo actualiza su detalle
+func (s *SQLStore) RecordMappingConflict(ctx context.Context, c *MappingConflict) error {
+	ts := now()
+	c.CreatedAt = ts
+	c.LastSeenAt = ts
+
+	err := s.queryRow(ctx, `
+		INSERT INTO mapping_conflicts (tenant, odoo_employee_id, quickpass_user_id, rut, reason, details, created_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant, odoo_employee_id, quickpass_user_id, reason) DO UPDATE SET
+			rut = excluded.rut,
+			details = excluded.details,
+			last_seen_at = excluded.last_seen_at,
+			resolved_at = NULL
+		RETURNING id`,
+		c.Tenant, c.OdooEmployeeID, c.QuickpassUserID, c.RUT, c.Reason, c.Details, c.CreatedAt, c.LastSeenAt).Scan(&c.ID)
+	if err != nil {
+		return fmt.Errorf("error registrando conflicto de identidad: %w", err)
+	}
+	return nil
+}
+
+// ListMappingConflicts lista los conflictos de un tenant, los más recientes primero
+func (s *SQLStore) ListMappingConflicts(ctx context.Context, tenant string, includeResolved bool) ([]*MappingConflict, error) {
+	query := `SELECT id, tenant, odoo_employee_id, quickpass_user_id, rut, reason, details, created_at, last_seen_at, resolved_at
+		FROM mapping_conflicts WHERE tenant = ?`
+	if !includeResolved {
+		query += ` AND resolved_at IS NULL`
+	}
+	query += ` ORDER BY last_seen_at DESC`
+
+	rows, err := s.query(ctx, query, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("error listando conflictos: %w", err)
+	}
+	defer rows.Close()
+
+	conflicts := []*MappingConflict{}
+	for rows.Next() {
+		c := &MappingConflict{}
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.Tenant, &c.OdooEmployeeID, &c.QuickpassUserID, &c.RUT, &c.Reason,
+			&c.Details, &c.CreatedAt, &c.LastSeenAt, &resolvedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo conflicto: %w", err)
+		}
+		c.ResolvedAt = timePtr(resolvedAt)
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// ResolveMappingConflict marca un conflicto como resuelto
+func (s *SQLStore) ResolveMappingConflict(ctx context.Context, tenant string, id int64) error {
+	res, err := s.exec(ctx, `UPDATE mapping_conflicts SET resolved_at = ? WHERE tenant = ? AND id = ?`,
+		now(), tenant, id)
+	if err != nil {
+		return fmt.Errorf("error resolviendo conflicto: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// tables devuelve las tablas de una base SQLite, sin las internas
+func tables(t *testing.T, store *SQLStore) []string {
+	t.Helper()
+	rows, err := store.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// TestMigrateRoundTrip aplica todas las migraciones de SQLite, las revierte y las vuelve a aplicar
+func TestMigrateRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLite(filepath.Join(t.TempDir(), "sync.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	migrator := store.Migrator()
+
+	migrations, err := migrator.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, mig := range migrations {
+		if mig.Version != i+1 || mig.Up == "" || mig.Down == "" {
+			t.Fatalf("migración %04d_%s: las versiones deben ser correlativas y tener up y down", mig.Version, mig.Name)
+		}
+	}
+
+	// Pending no crea la tabla de control
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(migrations) || len(tables(t, store)) != 0 {
+		t.Fatalf("antes de migrar: %d pendientes y tablas %v", len(pending), tables(t, store))
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Fatalf("Up() aplicó %d migraciones, se esperaban %d", applied, len(migrations))
+	}
+	schema := tables(t, store)
+	if again, err := migrator.Up(ctx); err != nil || again != 0 {
+		t.Fatalf("segundo Up() = %d, %v; no debía aplicar nada", again, err)
+	}
+	if pending, err := migrator.Pending(ctx); err != nil || len(pending) != 0 {
+		t.Fatalf("Pending() = %d, %v después de migrar", len(pending), err)
+	}
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			t.Errorf("la migración %04d_%s figura sin aplicar", s.Version, s.Name)
+		}
+	}
+
+	// Revertir una migración la deja pendiente
+	if reverted, err := migrator.Down(ctx, 1); err != nil || reverted != 1 {
+		t.Fatalf("Down(1) = %d, %v", reverted, err)
+	}
+	pending, err = migrator.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Version != len(migrations) {
+		t.Fatalf("después de Down(1) quedan pendientes %v", pending)
+	}
+
+	// Revertir todas deja solo la tabla de control, y volver a aplicarlas recrea el mismo esquema
+	if reverted, err := migrator.Down(ctx, len(migrations)); err != nil || reverted != len(migrations)-1 {
+		t.Fatalf("Down(todas) = %d, %v", reverted, err)
+	}
+	if left := tables(t, store); !reflect.DeepEqual(left, []string{"schema_migrations"}) {
+		t.Fatalf("después de revertir todo quedan las tablas %v", left)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != len(migrations) {
+		t.Fatalf("Up() después de revertir = %d, %v", applied, err)
+	}
+	if recreated := tables(t, store); !reflect.DeepEqual(recreated, schema) {
+		t.Fatalf("el esquema cambió tras revertir y aplicar: %v, antes %v", recreated, schema)
+	}
+}
+
+// TestMigrationsMatchAcrossDrivers verifica que SQLite y PostgreSQL tengan las mismas migraciones
+func TestMigrationsMatchAcrossDrivers(t *testing.T) {
+	sqlite, err := NewMigrator(nil, DriverSQLite).Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := NewMigrator(nil, DriverPostgres).Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("SQLite tiene %d migraciones y PostgreSQL %d", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].Version != postgres[i].Version || sqlite[i].Name != postgres[i].Name {
+			t.Errorf("migración %d: %04d_%s en SQLite y %04d_%s en PostgreSQL", i,
+				sqlite[i].Version, sqlite[i].Name, postgres[i].Version, postgres[i].Name)
+		}
+	}
+}
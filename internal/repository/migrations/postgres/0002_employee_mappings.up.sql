@@ -0,0 +1,28 @@
+CREATE TABLE employee_mappings (
+    tenant            TEXT      NOT NULL,
+    odoo_employee_id  INTEGER   NOT NULL,
+    quickpass_user_id TEXT      NOT NULL,
+    rut               TEXT      NOT NULL DEFAULT '',
+    match_method      TEXT      NOT NULL,
+    last_synced_hash  TEXT      NOT NULL DEFAULT '',
+    last_synced_at    TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL,
+    updated_at        TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (tenant, odoo_employee_id)
+);
+CREATE UNIQUE INDEX idx_employee_mappings_quickpass ON employee_mappings (tenant, quickpass_user_id);
+CREATE INDEX idx_employee_mappings_rut ON employee_mappings (tenant, rut);
+
+CREATE TABLE mapping_conflicts (
+    id                BIGSERIAL PRIMARY KEY,
+    tenant            TEXT      NOT NULL,
+    odoo_employee_id  INTEGER   NOT NULL DEFAULT 0,
+    quickpass_user_id TEXT      NOT NULL DEFAULT '',
+    rut               TEXT      NOT NULL DEFAULT '',
+    reason            TEXT      NOT NULL,
+    details           TEXT      NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL,
+    last_seen_at      TIMESTAMPTZ NOT NULL,
+    resolved_at       TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX idx_mapping_conflicts_identity ON mapping_conflicts (tenant, odoo_employee_id, quickpass_user_id, reason);
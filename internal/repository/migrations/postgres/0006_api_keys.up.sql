@@ -0,0 +1,15 @@
+CREATE TABLE api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    tenant       TEXT      NOT NULL,
+    name         TEXT      NOT NULL,
+    prefix       TEXT      NOT NULL,
+    key_hash     TEXT      NOT NULL,
+    scopes       TEXT      NOT NULL,
+    rotated_from BIGINT,
+    expires_at   TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX idx_api_keys_hash ON api_keys (key_hash);
+CREATE INDEX idx_api_keys_tenant ON api_keys (tenant);
@@ -0,0 +1,28 @@
+CREATE TABLE audit_log (
+    id           BIGSERIAL PRIMARY KEY,
+    tenant       TEXT      NOT NULL,
+    occurred_at  TIMESTAMPTZ NOT NULL,
+    actor_method TEXT      NOT NULL,
+    actor_id     TEXT      NOT NULL DEFAULT '',
+    actor_name   TEXT      NOT NULL DEFAULT '',
+    action       TEXT      NOT NULL,
+    method       TEXT      NOT NULL DEFAULT '',
+    path         TEXT      NOT NULL DEFAULT '',
+    employee_ids TEXT      NOT NULL DEFAULT '',
+    changes      TEXT      NOT NULL DEFAULT '',
+    outcome      TEXT      NOT NULL,
+    status       INTEGER   NOT NULL DEFAULT 0,
+    request_id   TEXT      NOT NULL DEFAULT '',
+    remote_addr  TEXT      NOT NULL DEFAULT ''
+);
+CREATE INDEX idx_audit_log_tenant ON audit_log (tenant, occurred_at);
+CREATE INDEX idx_audit_log_request ON audit_log (request_id);
+
+-- Solo inserción: el registro de auditoría no se puede modificar ni borrar
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log es de solo inserción';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
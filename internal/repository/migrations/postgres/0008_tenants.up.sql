@@ -0,0 +1,7 @@
+CREATE TABLE tenants (
+    id         TEXT      PRIMARY KEY,
+    config     TEXT      NOT NULL,
+    secrets    TEXT      NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
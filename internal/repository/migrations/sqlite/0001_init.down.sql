@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS event_logs;
+DROP TABLE IF EXISTS sync_runs;
+DROP TABLE IF EXISTS watermarks;
+DROP TABLE IF EXISTS id_mappings;
@@ -0,0 +1,47 @@
+CREATE TABLE id_mappings (
+    tenant       TEXT      NOT NULL,
+    entity       TEXT      NOT NULL,
+    odoo_id      INTEGER   NOT NULL,
+    quickpass_id TEXT      NOT NULL,
+    created_at   TIMESTAMP NOT NULL,
+    updated_at   TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant, entity, odoo_id)
+);
+CREATE UNIQUE INDEX idx_id_mappings_quickpass ON id_mappings (tenant, entity, quickpass_id);
+
+CREATE TABLE watermarks (
+    tenant     TEXT      NOT NULL,
+    flow       TEXT      NOT NULL,
+    value      TEXT      NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant, flow)
+);
+
+CREATE TABLE sync_runs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant      TEXT      NOT NULL,
+    flow        TEXT      NOT NULL,
+    status      TEXT      NOT NULL,
+    started_at  TIMESTAMP,
+    finished_at TIMESTAMP,
+    created     INTEGER   NOT NULL DEFAULT 0,
+    updated     INTEGER   NOT NULL DEFAULT 0,
+    skipped     INTEGER   NOT NULL DEFAULT 0,
+    failed      INTEGER   NOT NULL DEFAULT 0,
+    error       TEXT      NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_sync_runs_tenant_flow ON sync_runs (tenant, flow, id);
+
+CREATE TABLE event_logs (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id     INTEGER REFERENCES sync_runs (id) ON DELETE CASCADE,
+    tenant     TEXT      NOT NULL,
+    flow       TEXT      NOT NULL,
+    level      TEXT      NOT NULL,
+    record_ref TEXT      NOT NULL DEFAULT '',
+    message    TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_event_logs_run ON event_logs (run_id);
+CREATE INDEX idx_event_logs_tenant_flow ON event_logs (tenant, flow, id);
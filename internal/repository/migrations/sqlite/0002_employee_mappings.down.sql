@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS mapping_conflicts;
+DROP TABLE IF EXISTS employee_mappings;
@@ -0,0 +1,17 @@
+CREATE TABLE dead_letters (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant          TEXT      NOT NULL,
+    flow            TEXT      NOT NULL,
+    item_key        TEXT      NOT NULL,
+    payload         TEXT      NOT NULL,
+    error_class     TEXT      NOT NULL,
+    error           TEXT      NOT NULL,
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    status          TEXT      NOT NULL,
+    next_retry_at   TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX idx_dead_letters_item ON dead_letters (tenant, flow, item_key);
+CREATE INDEX idx_dead_letters_due ON dead_letters (status, next_retry_at);
@@ -0,0 +1,2 @@
+DROP TABLE webhook_events;
+ALTER TABLE sync_runs DROP COLUMN targets;
@@ -0,0 +1,17 @@
+ALTER TABLE sync_runs ADD COLUMN targets TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE webhook_events (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    source       TEXT      NOT NULL,
+    tenant       TEXT      NOT NULL,
+    event_id     TEXT      NOT NULL,
+    event_type   TEXT      NOT NULL,
+    payload      TEXT      NOT NULL,
+    status       TEXT      NOT NULL,
+    error        TEXT      NOT NULL DEFAULT '',
+    attempts     INTEGER   NOT NULL DEFAULT 0,
+    received_at  TIMESTAMP NOT NULL,
+    processed_at TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_webhook_events_event ON webhook_events (source, tenant, event_id);
+CREATE INDEX idx_webhook_events_status ON webhook_events (status, received_at);
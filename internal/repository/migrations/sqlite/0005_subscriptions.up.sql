@@ -0,0 +1,30 @@
+CREATE TABLE subscriptions (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant      TEXT      NOT NULL,
+    url         TEXT      NOT NULL,
+    events      TEXT      NOT NULL,
+    secret      TEXT      NOT NULL,
+    description TEXT      NOT NULL DEFAULT '',
+    active      BOOLEAN   NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_subscriptions_tenant ON subscriptions (tenant);
+
+CREATE TABLE deliveries (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER   NOT NULL,
+    tenant          TEXT      NOT NULL,
+    event_id        TEXT      NOT NULL,
+    event_type      TEXT      NOT NULL,
+    payload         TEXT      NOT NULL,
+    status          TEXT      NOT NULL,
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    response_status INTEGER   NOT NULL DEFAULT 0,
+    error           TEXT      NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL,
+    delivered_at    TIMESTAMP
+);
+CREATE INDEX idx_deliveries_subscription ON deliveries (subscription_id, created_at);
+CREATE INDEX idx_deliveries_due ON deliveries (status, next_attempt_at);
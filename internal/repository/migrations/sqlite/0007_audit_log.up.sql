@@ -0,0 +1,29 @@
+CREATE TABLE audit_log (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant       TEXT      NOT NULL,
+    occurred_at  TIMESTAMP NOT NULL,
+    actor_method TEXT      NOT NULL,
+    actor_id     TEXT      NOT NULL DEFAULT '',
+    actor_name   TEXT      NOT NULL DEFAULT '',
+    action       TEXT      NOT NULL,
+    method       TEXT      NOT NULL DEFAULT '',
+    path         TEXT      NOT NULL DEFAULT '',
+    employee_ids TEXT      NOT NULL DEFAULT '',
+    changes      TEXT      NOT NULL DEFAULT '',
+    outcome      TEXT      NOT NULL,
+    status       INTEGER   NOT NULL DEFAULT 0,
+    request_id   TEXT      NOT NULL DEFAULT '',
+    remote_addr  TEXT      NOT NULL DEFAULT ''
+);
+CREATE INDEX idx_audit_log_tenant ON audit_log (tenant, occurred_at);
+CREATE INDEX idx_audit_log_request ON audit_log (request_id);
+
+-- Solo inserción: el registro de auditoría no se puede modificar ni borrar
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log es de solo inserción');
+END;
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log es de solo inserción');
+END;
@@ -0,0 +1,7 @@
+CREATE TABLE tenants (
+    id         TEXT      PRIMARY KEY,
+    config     TEXT      NOT NULL,
+    secrets    TEXT      NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
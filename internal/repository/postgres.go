@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Driver PostgreSQL
+)
+
+// NewPostgres abre una conexión a PostgreSQL
+func NewPostgres(dsn string) (*SQLStore, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo PostgreSQL: %w", err)
+	}
+
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error conectando a PostgreSQL: %w", err)
+	}
+
+	return &SQLStore{db: db, driver: DriverPostgres}, nil
+}
@@ -0,0 +1,441 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrNotFound se devuelve cuando el registro solicitado no existe
+var ErrNotFound = errors.New("registro no encontrado")
+
+// Estados posibles de una ejecución de sincronización
+const (
+	RunStatusPending   = "pending"
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+	RunStatusCancelled = "cancelled"
+)
+
+// Niveles de los eventos registrados durante la sincronización
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// IDMapping relaciona un registro de Odoo con su equivalente en Quickpass
+type IDMapping struct {
+	Tenant      string    `json:"tenant"`
+	Entity      string    `json:"entity"` // Ej: "attendance", "leave"
+	OdooID      int       `json:"odoo_id"`
+	QuickpassID string    `json:"quickpass_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Métodos con que se estableció un mapeo de empleado
+const (
+	MatchByRUT   = "rut"
+	MatchByEmail = "email"
+	MatchManual  = "manual"
+	MatchCreated = "created" // El usuario fue creado en Quickpass por la sincronización
+)
+
+// EmployeeMapping relaciona un hr.employee de Odoo con un usuario de Quickpass
+type EmployeeMapping struct {
+	Tenant          string     `json:"tenant"`
+	OdooEmployeeID  int        `json:"odoo_employee_id"`
+	QuickpassUserID string     `json:"quickpass_user_id"`
+	RUT             string     `json:"rut"` // Normalizado: 12345678-9
+	MatchMethod     string     `json:"match_method"`
+	LastSyncedHash  string     `json:"last_synced_hash,omitempty"`
+	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// MappingConflict registra dos registros que reclaman la misma identidad
+type MappingConflict struct {
+	ID              int64      `json:"id"`
+	Tenant          string     `json:"tenant"`
+	OdooEmployeeID  int        `json:"odoo_employee_id"`
+	QuickpassUserID string     `json:"quickpass_user_id"`
+	RUT             string     `json:"rut,omitempty"`
+	Reason          string     `json:"reason"`
+	Details         string     `json:"details,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastSeenAt      time.Time  `json:"last_seen_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Watermark guarda el último punto procesado por un flujo (fecha, cursor, etc.)
+type Watermark struct {
+	Tenant    string    `json:"tenant"`
+	Flow      string    `json:"flow"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SyncRun representa una ejecución de un flujo de sincronización
+type SyncRun struct {
+	ID         int64      `json:"id"`
+	Tenant     string     `json:"tenant"`
+	Flow       string     `json:"flow"`
+	Status     string     `json:"status"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Created    int        `json:"created"`
+	Updated    int        `json:"updated"`
+	Skipped    int        `json:"skipped"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	Targets    []int      `json:"targets,omitempty"` // IDs de Odoo de una ejecución dirigida (vacío = todos los registros)
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// EventLog es una entrada del registro de eventos de sincronización
+type EventLog struct {
+	ID        int64     `json:"id"`
+	RunID     *int64    `json:"run_id,omitempty"`
+	Tenant    string    `json:"tenant"`
+	Flow      string    `json:"flow"`
+	Level     string    `json:"level"`
+	RecordRef string    `json:"record_ref,omitempty"` // Ej: "hr.employee:42"
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Estados de un elemento en la cola de mensajes fallidos (dead-letter)
+const (
+	DeadLetterRetrying  = "retrying"  // Se reintentará automáticamente en NextRetryAt
+	DeadLetterDead      = "dead"      // Requiere intervención manual
+	DeadLetterResolved  = "resolved"  // Reprocesado con éxito
+	DeadLetterDiscarded = "discarded" // Descartado por un administrador
+)
+
+// DeadLetter es un elemento de sincronización que falló, con todo lo necesario para reprocesarlo
+type DeadLetter struct {
+	ID            int64           `json:"id"`
+	Tenant        string          `json:"tenant"`
+	Flow          string          `json:"flow"`
+	ItemKey       string          `json:"item_key"` // Identifica el elemento dentro del flujo (ej: "quickpass.punch:123")
+	Payload       json.RawMessage `json:"payload"`
+	ErrorClass    string          `json:"error_class"`
+	Error         string          `json:"error"`
+	Attempts      int             `json:"attempts"`
+	Status        string          `json:"status"`
+	NextRetryAt   *time.Time      `json:"next_retry_at,omitempty"`
+	LastAttemptAt *time.Time      `json:"last_attempt_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// Estados de un evento recibido por webhook
+const (
+	WebhookReceived  = "received"  // Guardado, pendiente de procesar
+	WebhookProcessed = "processed" // Procesado (ej: sincronización encolada)
+	WebhookIgnored   = "ignored"   // No requiere acción (modelo o tipo no soportado)
+	WebhookFailed    = "failed"    // El procesamiento falló
+)
+
+// WebhookEvent es un evento recibido desde Odoo o Quickpass, guardado tal como llegó
+type WebhookEvent struct {
+	ID          int64           `json:"id"`
+	Source      string          `json:"source"` // odoo | quickpass
+	Tenant      string          `json:"tenant"`
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"` // Ej: "hr.employee" o "punch.recorded"
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error,omitempty"`
+	Attempts    int             `json:"attempts"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt *time.Time      `json:"processed_at,omitempty"`
+}
+
+// Subscription es un sistema externo que recibe notificaciones de cambios (ej: proveedor de nómina, BI)
+type Subscription struct {
+	ID          int64     `json:"id"`
+	Tenant      string    `json:"tenant"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"` // Tipos de evento o patrones: "employee.updated", "employee.*", "*"
+	Secret      string    `json:"-"`      // Firma las entregas; solo se muestra al crear la suscripción
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Estados de una entrega a un suscriptor
+const (
+	DeliveryPending   = "pending"   // Pendiente de enviar o esperando reintento en NextAttemptAt
+	DeliveryDelivered = "delivered" // El suscriptor respondió 2xx
+	DeliveryFailed    = "failed"    // Se agotaron los reintentos
+)
+
+// Delivery es el envío de un evento a un suscriptor, con el resultado del último intento
+type Delivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	Tenant         string          `json:"tenant"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// DeliveryFilter filtra el registro de entregas
+type DeliveryFilter struct {
+	Tenant         string
+	SubscriptionID int64
+	Status         string
+	Limit          int
+}
+
+// APIKey es una clave de acceso a la API; solo se guarda su hash
+type APIKey struct {
+	ID          int64      `json:"id"`
+	Tenant      string     `json:"tenant"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"` // Inicio de la clave, para reconocerla sin exponerla
+	KeyHash     string     `json:"-"`
+	Scopes      []string   `json:"scopes"`
+	RotatedFrom *int64     `json:"rotated_from,omitempty"` // Clave que esta reemplazó al rotar
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TenantRecord es un tenant creado con la API de administración
+// Config es su configuración en JSON sin secretos; Secrets, los secretos cifrados por el paquete tenant
+type TenantRecord struct {
+	ID        string    `json:"id"`
+	Config    string    `json:"-"`
+	Secrets   string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Acciones y resultados de una entrada de auditoría
+const (
+	AuditRead  = "read"
+	AuditWrite = "write"
+
+	AuditSuccess = "success"
+	AuditDenied  = "denied" // Rechazada por autenticación o permisos
+	AuditError   = "error"
+)
+
+// AuditChange es el cambio de un campo de un empleado en una escritura
+type AuditChange struct {
+	EmployeeID int    `json:"employee_id,omitempty"`
+	Field      string `json:"field"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+}
+
+// AuditEntry registra quién leyó o modificó datos de empleados, cuándo y con qué resultado
+type AuditEntry struct {
+	ID          int64         `json:"id"`
+	Tenant      string        `json:"tenant"`
+	OccurredAt  time.Time     `json:"occurred_at"`
+	ActorMethod string        `json:"actor_method"`       // api_key | jwt | sync | anonymous
+	ActorID     string        `json:"actor_id,omitempty"` // ID de la clave, "sub" del token o ejecución
+	ActorName   string        `json:"actor_name,omitempty"`
+	Action      string        `json:"action"`
+	Method      string        `json:"method,omitempty"`
+	Path        string        `json:"path,omitempty"`
+	EmployeeIDs []int         `json:"employee_ids"`
+	Changes     []AuditChange `json:"changes,omitempty"`
+	Outcome     string        `json:"outcome"`
+	Status      int           `json:"status,omitempty"` // Código HTTP de la respuesta
+	RequestID   string        `json:"request_id,omitempty"`
+	RemoteAddr  string        `json:"remote_addr,omitempty"`
+}
+
+// AuditFilter filtra el registro de auditoría
+type AuditFilter struct {
+	Tenant     string
+	EmployeeID int
+	Actor      string // ID o nombre del actor
+	Action     string
+	Outcome    string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	BeforeID   int64 // Paginación: solo entradas con ID menor
+	Limit      int
+}
+
+// WebhookEventFilter filtra el listado de eventos recibidos
+type WebhookEventFilter struct {
+	Tenant string
+	Source string
+	Status string
+	Limit  int
+}
+
+// DeadLetterFilter filtra el listado de mensajes fallidos
+type DeadLetterFilter struct {
+	Tenant string
+	Flow   string
+	Status string
+	Limit  int
+}
+
+// SyncRunFilter filtra el listado de ejecuciones
+type SyncRunFilter struct {
+	Tenant string
+	Flow   string
+	Status string
+	Limit  int
+}
+
+// EventLogFilter filtra el listado de eventos
+type EventLogFilter struct {
+	Tenant string
+	Flow   string
+	RunID  *int64
+	Level  string
+	Limit  int
+}
+
+// MappingStore administra la relación de IDs entre Odoo y Quickpass
+type MappingStore interface {
+	SaveMapping(ctx context.Context, m *IDMapping) error
+	GetMappingByOdooID(ctx context.Context, tenant, entity string, odooID int) (*IDMapping, error)
+	GetMappingByQuickpassID(ctx context.Context, tenant, entity, quickpassID string) (*IDMapping, error)
+	DeleteMapping(ctx context.Context, tenant, entity string, odooID int) error
+}
+
+// EmployeeMappingStore administra la identidad empleado Odoo ↔ usuario Quickpass
+type EmployeeMappingStore interface {
+	SaveEmployeeMapping(ctx context.Context, m *EmployeeMapping) error
+	GetEmployeeMappingByOdooID(ctx context.Context, tenant string, odooEmployeeID int) (*EmployeeMapping, error)
+	GetEmployeeMappingByQuickpassID(ctx context.Context, tenant, quickpassUserID string) (*EmployeeMapping, error)
+	ListEmployeeMappings(ctx context.Context, tenant string) ([]*EmployeeMapping, error)
+	DeleteEmployeeMapping(ctx context.Context, tenant string, odooEmployeeID int) error
+
+	RecordMappingConflict(ctx context.Context, c *MappingConflict) error
+	ListMappingConflicts(ctx context.Context, tenant string, includeResolved bool) ([]*MappingConflict, error)
+	ResolveMappingConflict(ctx context.Context, tenant string, id int64) error
+}
+
+// WatermarkStore administra los puntos de avance de cada flujo
+type WatermarkStore interface {
+	GetWatermark(ctx context.Context, tenant, flow string) (*Watermark, error)
+	SetWatermark(ctx context.Context, tenant, flow, value string) error
+}
+
+// SyncRunStore administra el historial de ejecuciones
+type SyncRunStore interface {
+	CreateSyncRun(ctx context.Context, run *SyncRun) error
+	UpdateSyncRun(ctx context.Context, run *SyncRun) error
+	GetSyncRun(ctx context.Context, id int64) (*SyncRun, error)
+	ListSyncRuns(ctx context.Context, filter SyncRunFilter) ([]*SyncRun, error)
+}
+
+// EventLogStore administra el registro de eventos
+type EventLogStore interface {
+	AppendEvent(ctx context.Context, event *EventLog) error
+	ListEvents(ctx context.Context, filter EventLogFilter) ([]*EventLog, error)
+}
+
+// DeadLetterStore administra la cola de elementos fallidos
+type DeadLetterStore interface {
+	// SaveDeadLetter crea o actualiza un elemento; es único por (tenant, flow, item_key)
+	SaveDeadLetter(ctx context.Context, d *DeadLetter) error
+	GetDeadLetter(ctx context.Context, id int64) (*DeadLetter, error)
+	GetDeadLetterByKey(ctx context.Context, tenant, flow, itemKey string) (*DeadLetter, error)
+	ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*DeadLetter, error)
+	// DueDeadLetters devuelve los elementos en reintento cuya próxima ejecución ya venció
+	// Con tenant vacío incluye los de todos los tenants
+	DueDeadLetters(ctx context.Context, tenant string, now time.Time, limit int) ([]*DeadLetter, error)
+}
+
+// WebhookEventStore administra los eventos recibidos por webhook
+type WebhookEventStore interface {
+	// CreateWebhookEvent guarda un evento nuevo; devuelve false si ya existía (mismo source, tenant y event_id)
+	CreateWebhookEvent(ctx context.Context, e *WebhookEvent) (bool, error)
+	UpdateWebhookEvent(ctx context.Context, e *WebhookEvent) error
+	GetWebhookEvent(ctx context.Context, id int64) (*WebhookEvent, error)
+	GetWebhookEventByEventID(ctx context.Context, source, tenant, eventID string) (*WebhookEvent, error)
+	ListWebhookEvents(ctx context.Context, filter WebhookEventFilter) ([]*WebhookEvent, error)
+}
+
+// SubscriptionStore administra las suscripciones a notificaciones y sus entregas
+type SubscriptionStore interface {
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+	UpdateSubscription(ctx context.Context, sub *Subscription) error
+	GetSubscription(ctx context.Context, id int64) (*Subscription, error)
+	ListSubscriptions(ctx context.Context, tenant string) ([]*Subscription, error)
+	// DeleteSubscription elimina la suscripción junto con su registro de entregas
+	DeleteSubscription(ctx context.Context, id int64) error
+
+	CreateDelivery(ctx context.Context, d *Delivery) error
+	UpdateDelivery(ctx context.Context, d *Delivery) error
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*Delivery, error)
+	// DueDeliveries devuelve las entregas pendientes cuyo próximo intento ya venció
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+}
+
+// APIKeyStore administra las claves de acceso a la API
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	// UpdateAPIKey actualiza nombre, scopes, expiración y revocación de una clave
+	UpdateAPIKey(ctx context.Context, key *APIKey) error
+	GetAPIKey(ctx context.Context, id int64) (*APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context, tenant string) ([]*APIKey, error)
+	// TouchAPIKey registra el último uso de una clave
+	TouchAPIKey(ctx context.Context, id int64, at time.Time) error
+}
+
+// AuditStore guarda el registro de auditoría de accesos a datos de empleados
+// Es de solo inserción: no hay forma de modificar ni borrar entradas
+type AuditStore interface {
+	AppendAudit(ctx context.Context, entry *AuditEntry) error
+	ListAudit(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error)
+}
+
+// TenantStore guarda los tenants administrados por la API
+type TenantStore interface {
+	// CreateTenant guarda un tenant nuevo; devuelve false si ya existía
+	CreateTenant(ctx context.Context, t *TenantRecord) (bool, error)
+	UpdateTenant(ctx context.Context, t *TenantRecord) error
+	GetTenant(ctx context.Context, id string) (*TenantRecord, error)
+	ListTenants(ctx context.Context) ([]*TenantRecord, error)
+	// DeleteTenant elimina solo la configuración: los datos sincronizados del tenant se conservan
+	DeleteTenant(ctx context.Context, id string) error
+}
+
+// Repository agrupa todas las operaciones de persistencia del servicio
+type Repository interface {
+	MappingStore
+	EmployeeMappingStore
+	WatermarkStore
+	SyncRunStore
+	EventLogStore
+	DeadLetterStore
+	WebhookEventStore
+	SubscriptionStore
+	APIKeyStore
+	AuditStore
+	TenantStore
+
+	// Ping verifica la conexión con la base de datos
+	Ping(ctx context.Context) error
+	// Close libera la conexión con la base de datos
+	Close() error
+}
@@ -0,0 +1,406 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLStore implementa Repository sobre database/sql
+// El mismo código sirve para SQLite y PostgreSQL; solo cambian los placeholders
+type SQLStore struct {
+	db     *sql.DB
+	driver string
+}
+
+// Verificación en tiempo de compilación
+var _ Repository = (*SQLStore)(nil)
+
+// DB expone la conexión subyacente (usado por las migraciones)
+func (s *SQLStore) DB() *sql.DB {
+	return s.db
+}
+
+// Driver devuelve el nombre del driver en uso
+func (s *SQLStore) Driver() string {
+	return s.driver
+}
+
+// Migrator crea un migrador para esta base de datos
+func (s *SQLStore) Migrator() *Migrator {
+	return NewMigrator(s.db, s.driver)
+}
+
+// Ping verifica la conexión con la base de datos
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close cierra la conexión
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// rebind convierte los placeholders "?" al formato del driver ($1, $2... en PostgreSQL)
+func (s *SQLStore) rebind(query string) string {
+	if s.driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(ctx, s.rebind(query), args...)
+}
+
+func (s *SQLStore) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, s.rebind(query), args...)
+}
+
+func (s *SQLStore) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRowContext(ctx, s.rebind(query), args...)
+}
+
+// now devuelve la hora actual en UTC (todas las fechas se guardan en UTC)
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// notFound traduce sql.ErrNoRows a ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// nullTime convierte un puntero a sql.NullTime
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// timePtr convierte un sql.NullTime a puntero
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time.UTC()
+	return &v
+}
+
+// limitOrDefault aplica un límite por defecto a los listados
+func limitOrDefault(limit int) int {
+	if limit <= 0 || limit > 1000 {
+		return 100
+	}
+	return limit
+}
+
+// joinIDs serializa una lista de IDs como "1,2,3"
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitIDs es la inversa de joinIDs
+func splitIDs(value string) []int {
+	if value == "" {
+		return nil
+	}
+	ids := []int{}
+	for _, part := range strings.Split(value, ",") {
+		if id, err := strconv.Atoi(part); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// whereBuilder arma cláusulas WHERE a partir de filtros opcionales
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+func (w *whereBuilder) add(cond string, arg interface{}) {
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, arg)
+}
+
+func (w *whereBuilder) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// ---- Mapeo de IDs ----
+
+// SaveMapping crea o actualiza un mapeo de IDs
+func (s *SQLStore) SaveMapping(ctx context.Context, m *IDMapping) error {
+	ts := now()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = ts
+	}
+	m.UpdatedAt = ts
+
+	_, err := s.exec(ctx, `
+		INSERT INTO id_mappings (tenant, entity, odoo_id, quickpass_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant, entity, odoo_id) DO UPDATE SET
+			quickpass_id = excluded.quickpass_id,
+			updated_at = excluded.updated_at`,
+		m.Tenant, m.Entity, m.OdooID, m.QuickpassID, m.CreatedAt, m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error guardando mapeo: %w", err)
+	}
+	return nil
+}
+
+// GetMappingByOdooID busca un mapeo por el ID de Odoo
+func (s *SQLStore) GetMappingByOdooID(ctx context.Context, tenant, entity string, odooID int) (*IDMapping, error) {
+	row := s.queryRow(ctx, `
+		SELECT tenant, entity, odoo_id, quickpass_id, created_at, updated_at
+		FROM id_mappings WHERE tenant = ? AND entity = ? AND odoo_id = ?`,
+		tenant, entity, odooID)
+	return scanMapping(row)
+}
+
+// GetMappingByQuickpassID busca un mapeo por el ID de Quickpass
+func (s *SQLStore) GetMappingByQuickpassID(ctx context.Context, tenant, entity, quickpassID string) (*IDMapping, error) {
+	row := s.queryRow(ctx, `
+		SELECT tenant, entity, odoo_id, quickpass_id, created_at, updated_at
+		FROM id_mappings WHERE tenant = ? AND entity = ? AND quickpass_id = ?`,
+		tenant, entity, quickpassID)
+	return scanMapping(row)
+}
+
+// DeleteMapping elimina un mapeo
+func (s *SQLStore) DeleteMapping(ctx context.Context, tenant, entity string, odooID int) error {
+	_, err := s.exec(ctx, `DELETE FROM id_mappings WHERE tenant = ? AND entity = ? AND odoo_id = ?`,
+		tenant, entity, odooID)
+	if err != nil {
+		return fmt.Errorf("error eliminando mapeo: %w", err)
+	}
+	return nil
+}
+
+func scanMapping(row *sql.Row) (*IDMapping, error) {
+	m := &IDMapping{}
+	if err := row.Scan(&m.Tenant, &m.Entity, &m.OdooID, &m.QuickpassID, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, notFound(err)
+	}
+	return m, nil
+}
+
+// ---- Watermarks ----
+
+// GetWatermark obtiene el punto de avance de un flujo
+func (s *SQLStore) GetWatermark(ctx context.Context, tenant, flow string) (*Watermark, error) {
+	w := &Watermark{}
+	err := s.queryRow(ctx, `
+		SELECT tenant, flow, value, updated_at FROM watermarks WHERE tenant = ? AND flow = ?`,
+		tenant, flow).Scan(&w.Tenant, &w.Flow, &w.Value, &w.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return w, nil
+}
+
+// SetWatermark actualiza el punto de avance de un flujo
+func (s *SQLStore) SetWatermark(ctx context.Context, tenant, flow, value string) error {
+	_, err := s.exec(ctx, `
+		INSERT INTO watermarks (tenant, flow, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (tenant, flow) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		tenant, flow, value, now())
+	if err != nil {
+		return fmt.Errorf("error guardando watermark: %w", err)
+	}
+	return nil
+}
+
+// ---- Ejecuciones de sincronización ----
+
+const syncRunColumns = `id, tenant, flow, status, started_at, finished_at, created, updated, skipped, failed, error, targets, created_at`
+
+// CreateSyncRun registra una nueva ejecución y asigna su ID
+func (s *SQLStore) CreateSyncRun(ctx context.Context, run *SyncRun) error {
+	if run.Status == "" {
+		run.Status = RunStatusPending
+	}
+	run.CreatedAt = now()
+
+	err := s.queryRow(ctx, `
+		INSERT INTO sync_runs (tenant, flow, status, started_at, finished_at, created, updated, skipped, failed, error, targets, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		run.Tenant, run.Flow, run.Status, nullTime(run.StartedAt), nullTime(run.FinishedAt),
+		run.Created, run.Updated, run.Skipped, run.Failed, run.Error, joinIDs(run.Targets), run.CreatedAt).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("error creando ejecución: %w", err)
+	}
+	return nil
+}
+
+// UpdateSyncRun actualiza el estado y los contadores de una ejecución
+func (s *SQLStore) UpdateSyncRun(ctx context.Context, run *SyncRun) error {
+	res, err := s.exec(ctx, `
+		UPDATE sync_runs SET status = ?, started_at = ?, finished_at = ?,
+			created = ?, updated = ?, skipped = ?, failed = ?, error = ?, targets = ?
+		WHERE id = ?`,
+		run.Status, nullTime(run.StartedAt), nullTime(run.FinishedAt),
+		run.Created, run.Updated, run.Skipped, run.Failed, run.Error, joinIDs(run.Targets), run.ID)
+	if err != nil {
+		return fmt.Errorf("error actualizando ejecución: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetSyncRun obtiene una ejecución por ID
+func (s *SQLStore) GetSyncRun(ctx context.Context, id int64) (*SyncRun, error) {
+	rows, err := s.query(ctx, `SELECT `+syncRunColumns+` FROM sync_runs WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo ejecución: %w", err)
+	}
+	runs, err := scanSyncRuns(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, ErrNotFound
+	}
+	return runs[0], nil
+}
+
+// ListSyncRuns lista las ejecuciones más recientes primero
+func (s *SQLStore) ListSyncRuns(ctx context.Context, filter SyncRunFilter) ([]*SyncRun, error) {
+	w := &whereBuilder{}
+	if filter.Tenant != "" {
+		w.add("tenant = ?", filter.Tenant)
+	}
+	if filter.Flow != "" {
+		w.add("flow = ?", filter.Flow)
+	}
+	if filter.Status != "" {
+		w.add("status = ?", filter.Status)
+	}
+
+	query := `SELECT ` + syncRunColumns + ` FROM sync_runs` + w.String() +
+		` ORDER BY id DESC LIMIT ` + strconv.Itoa(limitOrDefault(filter.Limit))
+
+	rows, err := s.query(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando ejecuciones: %w", err)
+	}
+	return scanSyncRuns(rows)
+}
+
+func scanSyncRuns(rows *sql.Rows) ([]*SyncRun, error) {
+	defer rows.Close()
+
+	runs := []*SyncRun{}
+	for rows.Next() {
+		run := &SyncRun{}
+		var startedAt, finishedAt sql.NullTime
+		var targets string
+		if err := rows.Scan(&run.ID, &run.Tenant, &run.Flow, &run.Status, &startedAt, &finishedAt,
+			&run.Created, &run.Updated, &run.Skipped, &run.Failed, &run.Error, &targets, &run.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo ejecución: %w", err)
+		}
+		run.Targets = splitIDs(targets)
+		run.StartedAt = timePtr(startedAt)
+		run.FinishedAt = timePtr(finishedAt)
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// ---- Registro de eventos ----
+
+// AppendEvent agrega un evento al registro
+func (s *SQLStore) AppendEvent(ctx context.Context, event *EventLog) error {
+	if event.Level == "" {
+		event.Level = LevelInfo
+	}
+	event.CreatedAt = now()
+
+	var runID sql.NullInt64
+	if event.RunID != nil {
+		runID = sql.NullInt64{Int64: *event.RunID, Valid: true}
+	}
+
+	err := s.queryRow(ctx, `
+		INSERT INTO event_logs (run_id, tenant, flow, level, record_ref, message, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		runID, event.Tenant, event.Flow, event.Level, event.RecordRef, event.Message, event.CreatedAt).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("error registrando evento: %w", err)
+	}
+	return nil
+}
+
+// ListEvents lista eventos en orden cronológico
+func (s *SQLStore) ListEvents(ctx context.Context, filter EventLogFilter) ([]*EventLog, error) {
+	w := &whereBuilder{}
+	if filter.Tenant != "" {
+		w.add("tenant = ?", filter.Tenant)
+	}
+	if filter.Flow != "" {
+		w.add("flow = ?", filter.Flow)
+	}
+	if filter.RunID != nil {
+		w.add("run_id = ?", *filter.RunID)
+	}
+	if filter.Level != "" {
+		w.add("level = ?", filter.Level)
+	}
+
+	query := `SELECT id, run_id, tenant, flow, level, record_ref, message, created_at FROM event_logs` +
+		w.String() + ` ORDER BY id ASC LIMIT ` + strconv.Itoa(limitOrDefault(filter.Limit))
+
+	rows, err := s.query(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando eventos: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*EventLog{}
+	for rows.Next() {
+		e := &EventLog{}
+		var runID sql.NullInt64
+		if err := rows.Scan(&e.ID, &runID, &e.Tenant, &e.Flow, &e.Level, &e.RecordRef, &e.Message, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo evento: %w", err)
+		}
+		if runID.Valid {
+			id := runID.Int64
+			e.RunID = &id
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
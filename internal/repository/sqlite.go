@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite" // Driver SQLite en Go puro (sin CGO)
+)
+
+// NewSQLite abre (o crea) una base de datos SQLite en la ruta indicada
+func NewSQLite(path string) (*SQLStore, error) {
+	if path != ":memory:" {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("error creando directorio de la base de datos: %w", err)
+			}
+		}
+	}
+
+	// WAL permite lecturas concurrentes mientras el sincronizador escribe
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo SQLite: %w", err)
+	}
+
+	// SQLite admite un solo escritor a la vez
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error conectando a SQLite: %w", err)
+	}
+
+	return &SQLStore{db: db, driver: DriverSQLite}, nil
+}
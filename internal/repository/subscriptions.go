@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const subscriptionColumns = `id, tenant, url, events, secret, description, active, created_at, updated_at`
+
+const deliveryColumns = `id, subscription_id, tenant, event_id, event_type, payload, status, attempts, response_status, error, next_attempt_at, created_at, delivered_at`
+
+// CreateSubscription registra una suscripción
+func (s *SQLStore) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	ts := now()
+	sub.CreatedAt = ts
+	sub.UpdatedAt = ts
+
+	err := s.queryRow(ctx, `
+		INSERT INTO subscriptions (tenant, url, events, secret, description, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		sub.Tenant, sub.URL, strings.Join(sub.Events, ","), sub.Secret, sub.Description, sub.Active,
+		sub.CreatedAt, sub.UpdatedAt).Scan(&sub.ID)
+	if err != nil {
+		return fmt.Errorf("error creando suscripción: %w", err)
+	}
+	return nil
+}
+
+// UpdateSubscription actualiza URL, eventos, secreto, descripción y estado de una suscripción
+func (s *SQLStore) UpdateSubscription(ctx context.Context, sub *Subscription) error {
+	sub.UpdatedAt = now()
+	res, err := s.exec(ctx, `
+		UPDATE subscriptions SET url = ?, events = ?, secret = ?, description = ?, active = ?, updated_at = ?
+		WHERE id = ?`,
+		sub.URL, strings.Join(sub.Events, ","), sub.Secret, sub.Description, sub.Active, sub.UpdatedAt, sub.ID)
+	if err != nil {
+		return fmt.Errorf("error actualizando suscripción: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetSubscription obtiene una suscripción por ID
+func (s *SQLStore) GetSubscription(ctx context.Context, id int64) (*Subscription, error) {
+	rows, err := s.query(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo suscripción: %w", err)
+	}
+	subs, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, ErrNotFound
+	}
+	return subs[0], nil
+}
+
+// ListSubscriptions lista las suscripciones de un tenant
+func (s *SQLStore) ListSubscriptions(ctx context.Context, tenant string) ([]*Subscription, error) {
+	rows, err := s.query(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE tenant = ? ORDER BY id`, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("error listando suscripciones: %w", err)
+	}
+	return scanSubscriptions(rows)
+}
+
+// DeleteSubscription elimina una suscripción y sus entregas
+func (s *SQLStore) DeleteSubscription(ctx context.Context, id int64) error {
+	if _, err := s.exec(ctx, `DELETE FROM deliveries WHERE subscription_id = ?`, id); err != nil {
+		return fmt.Errorf("error eliminando entregas: %w", err)
+	}
+	res, err := s.exec(ctx, `DELETE FROM subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("error eliminando suscripción: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]*Subscription, error) {
+	defer rows.Close()
+
+	subs := []*Subscription{}
+	for rows.Next() {
+		sub := &Subscription{}
+		var events string
+		if err := rows.Scan(&sub.ID, &sub.Tenant, &sub.URL, &events, &sub.Secret, &sub.Description, &sub.Active,
+			&sub.CreatedAt, &sub.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo suscripción: %w", err)
+		}
+		sub.Events = strings.Split(events, ",")
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// ---- Entregas ----
+
+// CreateDelivery registra una entrega pendiente
+func (s *SQLStore) CreateDelivery(ctx context.Context, d *Delivery) error {
+	if d.Status == "" {
+		d.Status = DeliveryPending
+	}
+	d.CreatedAt = now()
+
+	err := s.queryRow(ctx, `
+		INSERT INTO deliveries (subscription_id, tenant, event_id, event_type, payload, status, attempts,
+			response_status, error, next_attempt_at, created_at, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		d.SubscriptionID, d.Tenant, d.EventID, d.EventType, string(d.Payload), d.Status, d.Attempts,
+		d.ResponseStatus, d.Error, nullTime(d.NextAttemptAt), d.CreatedAt, nullTime(d.DeliveredAt)).Scan(&d.ID)
+	if err != nil {
+		return fmt.Errorf("error registrando entrega: %w", err)
+	}
+	return nil
+}
+
+// UpdateDelivery guarda el resultado de un intento de entrega
+func (s *SQLStore) UpdateDelivery(ctx context.Context, d *Delivery) error {
+	res, err := s.exec(ctx, `
+		UPDATE deliveries SET status = ?, attempts = ?, response_status = ?, error = ?, next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?`,
+		d.Status, d.Attempts, d.ResponseStatus, d.Error, nullTime(d.NextAttemptAt), nullTime(d.DeliveredAt), d.ID)
+	if err != nil {
+		return fmt.Errorf("error actualizando entrega: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDeliveries lista las entregas, las más recientes primero
+func (s *SQLStore) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*Delivery, error) {
+	w := &whereBuilder{}
+	if filter.Tenant != "" {
+		w.add("tenant = ?", filter.Tenant)
+	}
+	if filter.SubscriptionID != 0 {
+		w.add("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		w.add("status = ?", filter.Status)
+	}
+
+	rows, err := s.query(ctx, `SELECT `+deliveryColumns+` FROM deliveries`+w.String()+
+		` ORDER BY id DESC LIMIT `+strconv.Itoa(limitOrDefault(filter.Limit)), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando entregas: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// DueDeliveries devuelve las entregas pendientes cuyo próximo intento ya venció
+func (s *SQLStore) DueDeliveries(ctx context.Context, at time.Time, limit int) ([]*Delivery, error) {
+	rows, err := s.query(ctx, `SELECT `+deliveryColumns+` FROM deliveries
+		WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT `+strconv.Itoa(limitOrDefault(limit)),
+		DeliveryPending, at.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo entregas pendientes: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+func scanDeliveries(rows *sql.Rows) ([]*Delivery, error) {
+	defer rows.Close()
+
+	items := []*Delivery{}
+	for rows.Next() {
+		d := &Delivery{}
+		var payload string
+		var nextAttemptAt, deliveredAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Tenant, &d.EventID, &d.EventType, &payload, &d.Status,
+			&d.Attempts, &d.ResponseStatus, &d.Error, &nextAttemptAt, &d.CreatedAt, &deliveredAt); err != nil {
+			return nil, fmt.Errorf("error leyendo entrega: %w", err)
+		}
+		d.Payload = []byte(payload)
+		d.NextAttemptAt = timePtr(nextAttemptAt)
+		d.DeliveredAt = timePtr(deliveredAt)
+		items = append(items, d)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const tenantColumns = `id, config, secrets, created_at, updated_at`
+
+// CreateTenant guarda un tenant nuevo; devuelve false si ya existía otro con el mismo ID
+func (s *SQLStore) CreateTenant(ctx context.Context, t *TenantRecord) (bool, error) {
+	ts := now()
+	t.CreatedAt = ts
+	t.UpdatedAt = ts
+
+	err := s.queryRow(ctx, `
+		INSERT INTO tenants (id, config, secrets, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING id`,
+		t.ID, t.Config, t.Secrets, t.CreatedAt, t.UpdatedAt).Scan(&t.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error creando tenant: %w", err)
+	}
+	return true, nil
+}
+
+// UpdateTenant reemplaza la configuración y los secretos de un tenant
+func (s *SQLStore) UpdateTenant(ctx context.Context, t *TenantRecord) error {
+	t.UpdatedAt = now()
+	res, err := s.exec(ctx, `
+		UPDATE tenants SET config = ?, secrets = ?, updated_at = ?
+		WHERE id = ?`,
+		t.Config, t.Secrets, t.UpdatedAt, t.ID)
+	if err != nil {
+		return fmt.Errorf("error actualizando tenant: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetTenant obtiene un tenant por ID
+func (s *SQLStore) GetTenant(ctx context.Context, id string) (*TenantRecord, error) {
+	rows, err := s.query(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo tenant: %w", err)
+	}
+	tenants, err := scanTenants(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(tenants) == 0 {
+		return nil, ErrNotFound
+	}
+	return tenants[0], nil
+}
+
+// ListTenants lista todos los tenants guardados
+func (s *SQLStore) ListTenants(ctx context.Context) ([]*TenantRecord, error) {
+	rows, err := s.query(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("error listando tenants: %w", err)
+	}
+	return scanTenants(rows)
+}
+
+// DeleteTenant elimina la configuración de un tenant
+func (s *SQLStore) DeleteTenant(ctx context.Context, id string) error {
+	res, err := s.exec(ctx, `DELETE FROM tenants WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("error eliminando tenant: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanTenants(rows *sql.Rows) ([]*TenantRecord, error) {
+	defer rows.Close()
+
+	tenants := []*TenantRecord{}
+	for rows.Next() {
+		t := &TenantRecord{}
+		if err := rows.Scan(&t.ID, &t.Config, &t.Secrets, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo tenant: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const webhookEventColumns = `id, source, tenant, event_id, event_type, payload, status, error, attempts, received_at, processed_at`
+
+// CreateWebhookEvent guarda un evento recibido si no existe otro con el mismo event_id
+func (s *SQLStore) CreateWebhookEvent(ctx context.Context, e *WebhookEvent) (bool, error) {
+	if e.Status == "" {
+		e.Status = WebhookReceived
+	}
+	e.ReceivedAt = now()
+
+	err := s.queryRow(ctx, `
+		INSERT INTO webhook_events (source, tenant, event_id, event_type, payload, status, error, attempts, received_at, processed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source, tenant, event_id) DO NOTHING
+		RETURNING id`,
+		e.Source, e.Tenant, e.EventID, e.EventType, string(e.Payload), e.Status, e.Error, e.Attempts,
+		e.ReceivedAt, nullTime(e.ProcessedAt)).Scan(&e.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error guardando evento de webhook: %w", err)
+	}
+	return true, nil
+}
+
+// UpdateWebhookEvent actualiza el estado de procesamiento de un evento
+func (s *SQLStore) UpdateWebhookEvent(ctx context.Context, e *WebhookEvent) error {
+	res, err := s.exec(ctx, `
+		UPDATE webhook_events SET status = ?, error = ?, attempts = ?, processed_at = ?
+		WHERE id = ?`,
+		e.Status, e.Error, e.Attempts, nullTime(e.ProcessedAt), e.ID)
+	if err != nil {
+		return fmt.Errorf("error actualizando evento de webhook: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetWebhookEvent obtiene un evento por ID
+func (s *SQLStore) GetWebhookEvent(ctx context.Context, id int64) (*WebhookEvent, error) {
+	return s.getWebhookEvent(ctx, `id = ?`, id)
+}
+
+// GetWebhookEventByEventID obtiene un evento por el identificador asignado por el emisor
+func (s *SQLStore) GetWebhookEventByEventID(ctx context.Context, source, tenant, eventID string) (*WebhookEvent, error) {
+	return s.getWebhookEvent(ctx, `source = ? AND tenant = ? AND event_id = ?`, source, tenant, eventID)
+}
+
+func (s *SQLStore) getWebhookEvent(ctx context.Context, where string, args ...interface{}) (*WebhookEvent, error) {
+	rows, err := s.query(ctx, `SELECT `+webhookEventColumns+` FROM webhook_events WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo evento de webhook: %w", err)
+	}
+	events, err := scanWebhookEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrNotFound
+	}
+	return events[0], nil
+}
+
+// ListWebhookEvents lista los eventos recibidos, los más recientes primero
+func (s *SQLStore) ListWebhookEvents(ctx context.Context, filter WebhookEventFilter) ([]*WebhookEvent, error) {
+	w := &whereBuilder{}
+	if filter.Tenant != "" {
+		w.add("tenant = ?", filter.Tenant)
+	}
+	if filter.Source != "" {
+		w.add("source = ?", filter.Source)
+	}
+	if filter.Status != "" {
+		w.add("status = ?", filter.Status)
+	}
+
+	rows, err := s.query(ctx, `SELECT `+webhookEventColumns+` FROM webhook_events`+w.String()+
+		` ORDER BY id DESC LIMIT `+strconv.Itoa(limitOrDefault(filter.Limit)), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listando eventos de webhook: %w", err)
+	}
+	return scanWebhookEvents(rows)
+}
+
+func scanWebhookEvents(rows *sql.Rows) ([]*WebhookEvent, error) {
+	defer rows.Close()
+
+	events := []*WebhookEvent{}
+	for rows.Next() {
+		e := &WebhookEvent{}
+		var payload string
+		var processedAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.Source, &e.Tenant, &e.EventID, &e.EventType, &payload,
+			&e.Status, &e.Error, &e.Attempts, &e.ReceivedAt, &processedAt); err != nil {
+			return nil, fmt.Errorf("error leyendo evento de webhook: %w", err)
+		}
+		e.Payload = []byte(payload)
+		e.ProcessedAt = timePtr(processedAt)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,96 @@
+package rut
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Normalize deja un RUT chileno en formato canónico "12345678-9"
+// Elimina puntos, espacios y ceros a la izquierda, y usa "K" mayúscula
+// Devuelve "" si el valor no tiene forma de RUT
+func Normalize(value string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == 'k' || r == 'K':
+			return 'K'
+		default:
+			return -1
+		}
+	}, value)
+
+	if len(clean) < 2 {
+		return ""
+	}
+
+	body := strings.TrimLeft(clean[:len(clean)-1], "0")
+	dv := clean[len(clean)-1:]
+	if body == "" || strings.Contains(body, "K") {
+		return ""
+	}
+	return body + "-" + dv
+}
+
+// Valid indica si el RUT tiene un dígito verificador correcto
+func Valid(value string) bool {
+	normalized := Normalize(value)
+	if normalized == "" {
+		return false
+	}
+	body, dv, _ := strings.Cut(normalized, "-")
+	return checkDigit(body) == dv
+}
+
+// checkDigit calcula el dígito verificador (módulo 11)
+func checkDigit(body string) string {
+	sum := 0
+	factor := 2
+	for i := len(body) - 1; i >= 0; i-- {
+		d, _ := strconv.Atoi(string(body[i]))
+		sum += d * factor
+		factor++
+		if factor > 7 {
+			factor = 2
+		}
+	}
+
+	switch r := 11 - sum%11; r {
+	case 11:
+		return "0"
+	case 10:
+		return "K"
+	default:
+		return strconv.Itoa(r)
+	}
+}
+
+// Mask oculta un RUT dejando visibles solo los tres últimos dígitos y el verificador: "**.***.678-9"
+// Un valor que no tiene forma de RUT se oculta completo; uno ya enmascarado se devuelve igual
+func Mask(value string) string {
+	if strings.Contains(value, "*") {
+		return value
+	}
+	normalized := Normalize(value)
+	if normalized == "" {
+		if value == "" {
+			return ""
+		}
+		return "***"
+	}
+
+	body, dv, _ := strings.Cut(normalized, "-")
+	var masked []byte
+	for i := 0; i < len(body); i++ {
+		remaining := len(body) - i
+		if i > 0 && remaining%3 == 0 {
+			masked = append(masked, '.')
+		}
+		if remaining > 3 {
+			masked = append(masked, '*')
+		} else {
+			masked = append(masked, body[i])
+		}
+	}
+	return string(masked) + "-" + dv
+}
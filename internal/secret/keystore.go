@@ -0,0 +1,191 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// keystoreVersion es la versión del formato del archivo
+const keystoreVersion = 1
+
+// dataKeyLabel autentica la clave de datos al cifrarla con la clave maestra
+const dataKeyLabel = "keystore:data-key"
+
+// keystoreFile es el contenido del archivo del keystore
+type keystoreFile struct {
+	Version int `json:"version"`
+	// DataKey es la clave de datos cifrada con la clave maestra
+	DataKey string `json:"data_key"`
+	// Secrets son los secretos cifrados con la clave de datos, por nombre
+	Secrets map[string]string `json:"secrets"`
+}
+
+// Keystore es un archivo local de secretos con cifrado de sobre (envelope encryption):
+// cada secreto se cifra con una clave de datos aleatoria, y esa clave se guarda cifrada con la
+// clave maestra (SECRETS_MASTER_KEY). Cambiar la clave maestra solo vuelve a cifrar la clave de datos
+type Keystore struct {
+	path    string
+	master  *Sealer
+	dataKey []byte
+	data    *Sealer
+	file    keystoreFile
+}
+
+// CreateKeystore crea un keystore vacío con una clave de datos nueva; falla si el archivo ya existe
+func CreateKeystore(path string, masterKey Value) (*Keystore, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("el keystore %s ya existe", path)
+	}
+	master, err := NewSealerFromKey(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("clave maestra inválida: %w", err)
+	}
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("error al generar la clave de datos: %w", err)
+	}
+	k := &Keystore{path: path, master: master, file: keystoreFile{Version: keystoreVersion, Secrets: map[string]string{}}}
+	if err := k.setDataKey(dataKey); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// OpenKeystore abre un keystore existente con la clave maestra (en base64)
+func OpenKeystore(path string, masterKey Value) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el keystore: %w", err)
+	}
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error al leer el keystore %s: %w", path, err)
+	}
+	if file.Version != keystoreVersion {
+		return nil, fmt.Errorf("versión de keystore no soportada: %d", file.Version)
+	}
+	if file.Secrets == nil {
+		file.Secrets = map[string]string{}
+	}
+
+	master, err := NewSealerFromKey(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("clave maestra inválida: %w", err)
+	}
+	dataKey, err := master.Open(file.DataKey, dataKeyLabel)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir el keystore %s con SECRETS_MASTER_KEY: %w", path, err)
+	}
+	k := &Keystore{path: path, master: master, file: file}
+	if err := k.useDataKey(dataKey); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// setDataKey cifra la clave de datos con la clave maestra y la deja en uso
+func (k *Keystore) setDataKey(dataKey []byte) error {
+	wrapped, err := k.master.Seal(dataKey, dataKeyLabel)
+	if err != nil {
+		return fmt.Errorf("error al cifrar la clave de datos: %w", err)
+	}
+	k.file.DataKey = wrapped
+	return k.useDataKey(dataKey)
+}
+
+func (k *Keystore) useDataKey(dataKey []byte) error {
+	data, err := NewSealer(dataKey)
+	if err != nil {
+		return fmt.Errorf("clave de datos inválida: %w", err)
+	}
+	k.dataKey = dataKey
+	k.data = data
+	return nil
+}
+
+// Lookup implementa Source
+func (k *Keystore) Lookup(name string) (Value, bool, error) {
+	sealed, ok := k.file.Secrets[name]
+	if !ok {
+		return Value{}, false, nil
+	}
+	plaintext, err := k.data.Open(sealed, name)
+	if err != nil {
+		return Value{}, false, fmt.Errorf("error al descifrar el secreto %s: %w", name, err)
+	}
+	return New(string(plaintext)), true, nil
+}
+
+// Set cifra y guarda (en memoria) un secreto; use Save para escribir el archivo
+func (k *Keystore) Set(name string, value Value) error {
+	if name == "" {
+		return errors.New("el nombre del secreto no puede estar vacío")
+	}
+	sealed, err := k.data.Seal([]byte(value.Reveal()), name)
+	if err != nil {
+		return fmt.Errorf("error al cifrar el secreto %s: %w", name, err)
+	}
+	k.file.Secrets[name] = sealed
+	return nil
+}
+
+// Delete quita un secreto; devuelve false si no existía
+func (k *Keystore) Delete(name string) bool {
+	_, ok := k.file.Secrets[name]
+	delete(k.file.Secrets, name)
+	return ok
+}
+
+// Names devuelve los nombres de los secretos guardados, ordenados
+func (k *Keystore) Names() []string {
+	names := make([]string, 0, len(k.file.Secrets))
+	for name := range k.file.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rewrap vuelve a cifrar la clave de datos con una clave maestra nueva; los secretos no cambian
+func (k *Keystore) Rewrap(newMasterKey Value) error {
+	master, err := NewSealerFromKey(newMasterKey)
+	if err != nil {
+		return fmt.Errorf("clave maestra nueva inválida: %w", err)
+	}
+	k.master = master
+	return k.setDataKey(k.dataKey)
+}
+
+// Save escribe el keystore de forma atómica con permisos 0600
+func (k *Keystore) Save() error {
+	data, err := json.MarshalIndent(k.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error al serializar el keystore: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".keystore-*")
+	if err != nil {
+		return fmt.Errorf("error al escribir el keystore: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error al escribir el keystore: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error al escribir el keystore: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error al escribir el keystore: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), k.path); err != nil {
+		return fmt.Errorf("error al escribir el keystore: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package secret
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestKeystoreRewrap verifica que cambiar la clave maestra conserve los secretos y deje sin acceso a la anterior
+func TestKeystoreRewrap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	_, oldKey := newTestSealer(t)
+	_, newKey := newTestSealer(t)
+
+	store, err := CreateKeystore(path, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("ODOO_PASSWORD", New("odoo-secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateKeystore(path, oldKey); err == nil {
+		t.Error("CreateKeystore no debe sobrescribir un keystore existente")
+	}
+
+	store, err = OpenKeystore(path, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Rewrap(newKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenKeystore(path, oldKey); err == nil {
+		t.Error("la clave maestra anterior todavía abre el keystore")
+	}
+	store, err = OpenKeystore(path, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := store.Lookup("ODOO_PASSWORD")
+	if err != nil || !ok || value.Reveal() != "odoo-secret" {
+		t.Fatalf("Lookup() = %v, %v, %v; se esperaba el secreto original", value, ok, err)
+	}
+	if _, ok, err := store.Lookup("UNKNOWN"); ok || err != nil {
+		t.Errorf("Lookup(UNKNOWN) = %v, %v", ok, err)
+	}
+}
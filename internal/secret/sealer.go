@@ -0,0 +1,107 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedVersion identifica el formato del texto cifrado, para poder cambiar de algoritmo más adelante
+const sealedVersion = "v1:"
+
+// KeySize es el largo de las claves de cifrado (AES-256)
+const KeySize = 32
+
+// Sealer cifra datos con AES-256-GCM
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+// NewSealer crea el cifrador con una clave de KeySize bytes
+func NewSealer(key []byte) (*Sealer, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("la clave de cifrado debe tener %d bytes (tiene %d)", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el cifrador: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el cifrador: %w", err)
+	}
+	return &Sealer{aead: aead}, nil
+}
+
+// NewSealerFromKey crea el cifrador con una clave en base64 (ej: openssl rand -base64 32)
+func NewSealerFromKey(key Value) (*Sealer, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key.Reveal()))
+	if err != nil {
+		return nil, errors.New("la clave de cifrado debe estar en base64")
+	}
+	return NewSealer(decoded)
+}
+
+// SealerFromEnv crea el cifrador con la clave de la variable name, que también se puede leer de un
+// archivo o del keystore (ver Get); sin la clave devuelve nil
+func SealerFromEnv(name string) (*Sealer, error) {
+	key, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if key.IsZero() {
+		return nil, nil
+	}
+	sealer, err := NewSealerFromKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s inválida: %w", name, err)
+	}
+	return sealer, nil
+}
+
+// IsSealed indica si value tiene el formato de un valor generado por Seal
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedVersion)
+}
+
+// GenerateKey genera una clave aleatoria en base64
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("error al generar la clave: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Seal cifra plaintext; additional queda autenticado (ej: el nombre del secreto o el ID del tenant),
+// de modo que un texto cifrado no se puede copiar a otro registro
+func (s *Sealer) Seal(plaintext []byte, additional string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error al generar el nonce: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, plaintext, []byte(additional))
+	return sealedVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open descifra un valor generado por Seal con el mismo additional
+func (s *Sealer) Open(value, additional string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(value, sealedVersion)
+	if !ok {
+		return nil, errors.New("formato de dato cifrado desconocido")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return nil, errors.New("dato cifrado corrupto")
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(additional))
+	if err != nil {
+		return nil, errors.New("no se pudo descifrar: clave incorrecta o datos alterados")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,95 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func newTestSealer(t *testing.T) (*Sealer, Value) {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealer, err := NewSealerFromKey(New(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealer, New(key)
+}
+
+func TestSealerRoundTrip(t *testing.T) {
+	sealer, _ := newTestSealer(t)
+	other, _ := newTestSealer(t)
+	plaintext := []byte(`{"odoo":{"password":"secret"}}`)
+
+	sealed, err := sealer.Seal(plaintext, "tenant:acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, sealedVersion) || strings.Contains(sealed, "secret") {
+		t.Fatalf("formato de dato cifrado inesperado: %s", sealed)
+	}
+	again, err := sealer.Seal(plaintext, "tenant:acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == sealed {
+		t.Error("dos cifrados del mismo texto deben usar nonces distintos")
+	}
+
+	tampered := []byte(strings.TrimPrefix(sealed, sealedVersion))
+	decoded, _ := base64.StdEncoding.DecodeString(string(tampered))
+	decoded[len(decoded)-1] ^= 1
+
+	tests := []struct {
+		name       string
+		sealer     *Sealer
+		value      string
+		additional string
+		valid      bool
+	}{
+		{"mismo additional", sealer, sealed, "tenant:acme", true},
+		{"otro additional", sealer, sealed, "tenant:other", false},
+		{"otra clave", other, sealed, "tenant:acme", false},
+		{"datos alterados", sealer, sealedVersion + base64.StdEncoding.EncodeToString(decoded), "tenant:acme", false},
+		{"sin versión", sealer, strings.TrimPrefix(sealed, sealedVersion), "tenant:acme", false},
+		{"no es base64", sealer, sealedVersion + "%%%", "tenant:acme", false},
+		{"demasiado corto", sealer, sealedVersion + "AAAA", "tenant:acme", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, err := tt.sealer.Open(tt.value, tt.additional)
+			if tt.valid {
+				if err != nil || !bytes.Equal(opened, plaintext) {
+					t.Fatalf("Open() = %q, %v; se esperaba %q", opened, err, plaintext)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Open() = %q, se esperaba un error", opened)
+			}
+		})
+	}
+}
+
+func TestNewSealerFromKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{"32 bytes", base64.StdEncoding.EncodeToString(make([]byte, KeySize)), true},
+		{"con salto de línea", base64.StdEncoding.EncodeToString(make([]byte, KeySize)) + "\n", true},
+		{"16 bytes", base64.StdEncoding.EncodeToString(make([]byte, 16)), false},
+		{"no es base64", "no-es-base64!", false},
+		{"vacía", "", false},
+	}
+	for _, tt := range tests {
+		if _, err := NewSealerFromKey(New(tt.key)); (err == nil) != tt.valid {
+			t.Errorf("%s: NewSealerFromKey() = %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultDir es donde Docker monta los secretos (docker secret, compose secrets)
+const DefaultDir = "/run/secrets"
+
+// Source es un origen de secretos
+type Source interface {
+	// Lookup devuelve el secreto name; ok es false si este origen no lo define
+	Lookup(name string) (value Value, ok bool, err error)
+}
+
+// Env lee los secretos de variables de entorno
+type Env struct{}
+
+// Lookup implementa Source
+func (Env) Lookup(name string) (Value, bool, error) {
+	value := os.Getenv(name)
+	return New(value), value != "", nil
+}
+
+// Files lee secretos montados como archivos (Docker y Kubernetes secrets): la ruta indicada en
+// NAME_FILE o, si no está definida, Dir/NAME o Dir/name (en minúsculas)
+// Se quita el salto de línea final que suelen tener estos archivos
+type Files struct {
+	Dir string
+}
+
+// Lookup implementa Source
+func (f Files) Lookup(name string) (Value, bool, error) {
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		value, err := readSecretFile(path)
+		if err != nil {
+			return Value{}, false, fmt.Errorf("error al leer %s_FILE: %w", name, err)
+		}
+		return value, true, nil
+	}
+	if f.Dir == "" {
+		return Value{}, false, nil
+	}
+	for _, candidate := range []string{name, strings.ToLower(name)} {
+		value, err := readSecretFile(filepath.Join(f.Dir, candidate))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Value{}, false, fmt.Errorf("error al leer el secreto %s: %w", name, err)
+		}
+		return value, true, nil
+	}
+	return Value{}, false, nil
+}
+
+func readSecretFile(path string) (Value, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Value{}, err
+	}
+	return New(strings.TrimRight(string(data), "\r\n")), nil
+}
+
+// Chain consulta los orígenes en orden y devuelve el primero que define el secreto
+type Chain []Source
+
+// Lookup implementa Source
+func (c Chain) Lookup(name string) (Value, bool, error) {
+	for _, source := range c {
+		value, ok, err := source.Lookup(name)
+		if err != nil || ok {
+			return value, ok, err
+		}
+	}
+	return Value{}, false, nil
+}
+
+// NewChainFromEnv arma los orígenes de secretos, en orden de prioridad:
+//  1. variables de entorno
+//  2. archivos: NAME_FILE o SECRETS_DIR/NAME (SECRETS_DIR por defecto /run/secrets)
+//  3. el keystore cifrado SECRETS_KEYSTORE, si está definido, abierto con SECRETS_MASTER_KEY
+//     (que a su vez puede venir de SECRETS_MASTER_KEY_FILE)
+func NewChainFromEnv() (Chain, error) {
+	chain := NewPlainChainFromEnv()
+
+	path := os.Getenv("SECRETS_KEYSTORE")
+	if path == "" {
+		return chain, nil
+	}
+	master, ok, err := chain.Lookup("SECRETS_MASTER_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("SECRETS_KEYSTORE está definido pero falta SECRETS_MASTER_KEY")
+	}
+	keystore, err := OpenKeystore(path, master)
+	if err != nil {
+		return nil, err
+	}
+	return append(chain, keystore), nil
+}
+
+// NewPlainChainFromEnv devuelve los orígenes sin cifrar: variables de entorno y archivos
+func NewPlainChainFromEnv() Chain {
+	dir := os.Getenv("SECRETS_DIR")
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return Chain{Env{}, Files{Dir: dir}}
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultChain Chain
+	defaultErr   error
+)
+
+// Get busca un secreto en los orígenes configurados por entorno (ver NewChainFromEnv)
+// Un secreto que no está definido en ninguno devuelve un Value vacío
+// Las variables de entorno deben estar cargadas (config.LoadEnv) antes de la primera llamada
+func Get(name string) (Value, error) {
+	defaultOnce.Do(func() {
+		defaultChain, defaultErr = NewChainFromEnv()
+	})
+	if defaultErr != nil {
+		return Value{}, fmt.Errorf("error al configurar los secretos: %w", defaultErr)
+	}
+	value, _, err := defaultChain.Lookup(name)
+	return value, err
+}
@@ -0,0 +1,101 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// Mask reemplaza el contenido de un secreto al imprimirlo o serializarlo
+const Mask = "***"
+
+// Value es un secreto (contraseña, API key, clave de firma)
+// Su contenido solo se obtiene con Reveal: fmt (con cualquier verbo), JSON, texto y slog
+// muestran Mask, o vacío si el secreto no está configurado
+// El valor se guarda detrás de un puntero para que fmt tampoco lo muestre cuando Value es un campo
+// no exportado de otra estructura (ahí fmt no usa Format y, en lugar del contenido, imprime la dirección)
+type Value struct {
+	value *string
+}
+
+// New envuelve un valor en claro
+func New(value string) Value {
+	if value == "" {
+		return Value{}
+	}
+	return Value{value: &value}
+}
+
+// Reveal devuelve el valor en claro; úselo solo donde se necesita (headers, firmas, autenticación)
+func (v Value) Reveal() string {
+	if v.value == nil {
+		return ""
+	}
+	return *v.value
+}
+
+// IsZero indica si el secreto no está configurado
+func (v Value) IsZero() bool {
+	return v.value == nil
+}
+
+// masked es lo que se muestra en lugar del secreto
+func (v Value) masked() string {
+	if v.value == nil {
+		return ""
+	}
+	return Mask
+}
+
+// String implementa fmt.Stringer
+func (v Value) String() string {
+	return v.masked()
+}
+
+// GoString implementa fmt.GoStringer (%#v)
+func (v Value) GoString() string {
+	return fmt.Sprintf("secret.Value(%q)", v.masked())
+}
+
+// Format implementa fmt.Formatter para que ningún verbo (%x, %q, %d...) muestre el contenido
+func (v Value) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		fmt.Fprint(f, v.GoString())
+		return
+	}
+	fmt.Fprint(f, v.masked())
+}
+
+// MarshalJSON serializa el secreto enmascarado
+func (v Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.masked())
+}
+
+// UnmarshalJSON lee el secreto desde un string JSON (archivos de configuración, API de administración)
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("el secreto debe ser un string: %w", err)
+	}
+	*v = Value{}
+	if value != nil {
+		*v = New(*value)
+	}
+	return nil
+}
+
+// MarshalText serializa el secreto enmascarado (YAML, encoding.TextMarshaler)
+func (v Value) MarshalText() ([]byte, error) {
+	return []byte(v.masked()), nil
+}
+
+// UnmarshalText lee el secreto en claro (YAML, flags)
+func (v *Value) UnmarshalText(text []byte) error {
+	*v = New(string(text))
+	return nil
+}
+
+// LogValue implementa slog.LogValuer
+func (v Value) LogValue() slog.Value {
+	return slog.StringValue(v.masked())
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// createAPIKeyRequest es el cuerpo de POST /api/v1/admin/api-keys
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// rotateAPIKeyRequest es el cuerpo opcional de POST /api/v1/admin/api-keys/{id}/rotate
+type rotateAPIKeyRequest struct {
+	// OverlapSeconds es cuánto sigue siendo válida la clave anterior (por defecto API_KEY_ROTATION_OVERLAP)
+	OverlapSeconds *int `json:"overlap_seconds"`
+}
+
+// handleListAPIKeys lista las claves de API
+// GET /api/v1/admin/api-keys
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	keys, err := s.repo.ListAPIKeys(r.Context(), t.ID)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"count":   len(keys),
+		"data":    keys,
+	})
+}
+
+// handleCreateAPIKey crea una clave de API
+// POST /api/v1/admin/api-keys  {"name": "BI", "scopes": ["employees:read"], "expires_at": "2027-01-01T00:00:00Z"}
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		s.sendError(w, r, http.StatusBadRequest, codeMissingParameter, nil, "name")
+		return
+	}
+	if err := auth.ValidateScopes(req.Scopes); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeValidationFailed, err)
+		return
+	}
+
+	stored, key, err := s.auth.Create(r.Context(), t.ID, strings.TrimSpace(req.Name), req.Scopes, req.ExpiresAt)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	// La clave en claro solo se muestra ahora; se guarda únicamente su hash
+	s.sendJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    stored,
+		"key":     key,
+	})
+}
+
+// handleGetAPIKey consulta una clave de API
+// GET /api/v1/admin/api-keys/{id}
+func (s *Server) handleGetAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.apiKey(w, r)
+	if !ok {
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// handleRevokeAPIKey revoca una clave de API de inmediato
+// DELETE /api/v1/admin/api-keys/{id}
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.apiKey(w, r)
+	if !ok {
+		return
+	}
+	if key.RevokedAt == nil {
+		revokedAt := time.Now().UTC()
+		key.RevokedAt = &revokedAt
+		if err := s.repo.UpdateAPIKey(r.Context(), key); err != nil {
+			s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+			return
+		}
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// handleRotateAPIKey crea una clave nueva; la anterior vence tras el período de convivencia
+// POST /api/v1/admin/api-keys/{id}/rotate  [{"overlap_seconds": 3600}]
+func (s *Server) handleRotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.apiKey(w, r)
+	if !ok {
+		return
+	}
+
+	var req rotateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return
+	}
+	var overlap *time.Duration
+	if req.OverlapSeconds != nil {
+		if *req.OverlapSeconds < 0 {
+			s.sendError(w, r, http.StatusBadRequest, codeInvalidParameter, nil, "overlap_seconds")
+			return
+		}
+		d := time.Duration(*req.OverlapSeconds) * time.Second
+		overlap = &d
+	}
+
+	rotated, plain, err := s.auth.Rotate(r.Context(), key, overlap)
+	if err != nil {
+		if errors.Is(err, auth.ErrKeyRevoked) {
+			s.sendError(w, r, http.StatusConflict, codeAPIKeyRevoked, err, key.ID)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	s.sendJSON(w, http.StatusCreated, map[string]interface{}{
+		"success":  true,
+		"data":     rotated,
+		"key":      plain,
+		"previous": key,
+	})
+}
+
+// apiKey obtiene la clave de la ruta, verificando que pertenezca al cliente actual
+func (s *Server) apiKey(w http.ResponseWriter, r *http.Request) (*repository.APIKey, bool) {
+	t := currentTenant(r)
+	id, ok := s.pathID(w, r, "id")
+	if !ok || !s.requireRepository(w, r) {
+		return nil, false
+	}
+
+	key, err := s.repo.GetAPIKey(r.Context(), id)
+	if err == nil && key.Tenant == t.ID {
+		return key, true
+	}
+	if err == nil || errors.Is(err, repository.ErrNotFound) {
+		s.sendError(w, r, http.StatusNotFound, codeAPIKeyNotFound, nil, id)
+		return nil, false
+	}
+	s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+	return nil, false
+}
@@ -0,0 +1,376 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// auditExportPage es cuántas entradas se leen por consulta al exportar
+const auditExportPage = 1000
+
+type auditKey struct{}
+
+// requestIDMiddleware asigna a cada petición un identificador (X-Request-ID) que se devuelve en la
+// respuesta, queda en la auditoría y en cada línea de log de la petición (incluidas las llamadas a Odoo,
+// que también lo reciben); si el cliente envía uno válido se respeta
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			buf := make([]byte, 16)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		w.Header().Set("X-Request-ID", id)
+		traceAttribute(r, "request_id", id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID acepta identificadores de hasta 128 caracteres ASCII visibles (ej: un UUID)
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// auditRecord acumula durante la petición lo que se guardará en la auditoría
+// authMiddleware agrega quién llama y los handlers qué empleados y campos tocaron
+type auditRecord struct {
+	mu          sync.Mutex
+	tenant      string
+	principal   *auth.Principal
+	employeeIDs []int
+	changes     []repository.AuditChange
+}
+
+// auditAction indica si una petición accede a datos de empleados y si es lectura o escritura
+func auditAction(r *http.Request) (string, bool) {
+	path := r.URL.Path
+	action := repository.AuditRead
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		action = repository.AuditWrite
+	}
+
+	switch {
+	case path == "/api/v1/employees" || strings.HasPrefix(path, "/api/v1/employees/"),
+		strings.HasPrefix(path, "/api/v1/portal/"),
+		path == "/api/v1/admin/mappings" || strings.HasPrefix(path, "/api/v1/admin/mappings/"),
+		// Los elementos fallidos y los eventos de webhook guardan los registros de empleados que traían
+		path == "/api/v1/admin/dead-letters" || strings.HasPrefix(path, "/api/v1/admin/dead-letters/"),
+		path == "/api/v1/admin/webhook-events" || strings.HasPrefix(path, "/api/v1/admin/webhook-events/"):
+		return action, true
+	case strings.HasPrefix(path, "/api/v1/sync/") && !strings.HasPrefix(path, "/api/v1/sync/runs"):
+		// Las sincronizaciones escriben datos de empleados; sus cambios se auditan al aplicarse
+		return action, r.Method == http.MethodPost
+	}
+	return "", false
+}
+
+// auditParams son los parámetros de consulta que se guardan con la ruta: filtros sin datos personales
+// ni credenciales; el resto (ej: token) se descarta
+var auditParams = []string{"employee_id", "flow", "source", "status", "limit", "dry_run", "format", "include_events", "include_resolved"}
+
+// auditPath devuelve la ruta de la petición con solo los parámetros de auditParams
+func auditPath(r *http.Request) string {
+	query := r.URL.Query()
+	kept := url.Values{}
+	for _, name := range auditParams {
+		if values, ok := query[name]; ok {
+			kept[name] = values
+		}
+	}
+	if len(kept) == 0 {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + kept.Encode()
+}
+
+// auditMiddleware registra en la auditoría cada lectura o escritura de datos de empleados,
+// incluidas las rechazadas; debe ir antes de authMiddleware para ver también los 401 y 403
+func (s *Server) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action, audited := auditAction(r)
+		if !audited || s.repo == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		record := &auditRecord{tenant: currentTenant(r).ID}
+		recorder := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), auditKey{}, record)))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		entry := &repository.AuditEntry{
+			ActorMethod: "anonymous",
+			Action:      action,
+			Method:      r.Method,
+			Path:        auditPath(r),
+			Outcome:     repository.AuditSuccess,
+			Status:      status,
+			RequestID:   logging.RequestID(r.Context()),
+			RemoteAddr:  r.RemoteAddr,
+		}
+		switch {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			entry.Outcome = repository.AuditDenied
+		case status >= 400:
+			entry.Outcome = repository.AuditError
+		}
+
+		record.mu.Lock()
+		entry.Tenant = record.tenant
+		if p := record.principal; p != nil {
+			entry.ActorMethod = p.Method
+			entry.ActorName = p.Name
+			switch {
+			case p.KeyID != 0:
+				entry.ActorID = strconv.FormatInt(p.KeyID, 10)
+			case p.EmployeeID != 0:
+				entry.ActorID = "employee:" + strconv.Itoa(p.EmployeeID)
+			case p.QuickpassUserID != "":
+				entry.ActorID = "quickpass:" + p.QuickpassUserID
+			}
+		}
+		entry.EmployeeIDs = uniqueIDs(record.employeeIDs)
+		entry.Changes = record.changes
+		record.mu.Unlock()
+
+		if err := s.repo.AppendAudit(context.WithoutCancel(r.Context()), entry); err != nil {
+			logger.ErrorContext(r.Context(), "❌ Error registrando auditoría", "method", r.Method, "path", r.URL.Path, "error", err)
+		}
+	})
+}
+
+// statusWriter guarda el código HTTP de la respuesta
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush permite transmitir respuestas largas (ej: la exportación de auditoría)
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// uniqueIDs quita los IDs repetidos conservando el orden
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// auditFromContext devuelve el registro de auditoría de la petición (nil si la ruta no se audita)
+func auditFromContext(ctx context.Context) *auditRecord {
+	record, _ := ctx.Value(auditKey{}).(*auditRecord)
+	return record
+}
+
+// auditPrincipal anota quién hace la petición
+func auditPrincipal(r *http.Request, principal *auth.Principal) {
+	if record := auditFromContext(r.Context()); record != nil {
+		record.mu.Lock()
+		record.principal = principal
+		record.mu.Unlock()
+	}
+}
+
+// auditTenant anota el tenant de la petición cuando lo determina la credencial
+func auditTenant(r *http.Request, tenant string) {
+	if record := auditFromContext(r.Context()); record != nil {
+		record.mu.Lock()
+		record.tenant = tenant
+		record.mu.Unlock()
+	}
+}
+
+// auditEmployees anota los empleados cuyos datos se leyeron o modificaron
+func auditEmployees(r *http.Request, ids ...int) {
+	if record := auditFromContext(r.Context()); record != nil {
+		record.mu.Lock()
+		record.employeeIDs = append(record.employeeIDs, ids...)
+		record.mu.Unlock()
+	}
+}
+
+// auditChanges anota los campos modificados por una escritura
+func auditChanges(r *http.Request, changes ...repository.AuditChange) {
+	if record := auditFromContext(r.Context()); record != nil {
+		record.mu.Lock()
+		record.changes = append(record.changes, changes...)
+		record.mu.Unlock()
+	}
+}
+
+// handleAudit consulta o exporta el registro de auditoría
+// GET /api/v1/audit?employee_id=7&actor=3&action=read&outcome=denied&from=2026-01-01T00:00:00Z&to=...&limit=100
+// GET /api/v1/audit?format=jsonl -> exporta todas las entradas que cumplen el filtro como JSON Lines
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeValidationFailed, err)
+		return
+	}
+	filter.Tenant = currentTenant(r).ID
+
+	if r.URL.Query().Get("format") == "jsonl" || strings.HasPrefix(r.Header.Get("Accept"), "application/x-ndjson") {
+		s.exportAudit(w, r, filter)
+		return
+	}
+
+	entries, err := s.repo.ListAudit(r.Context(), filter)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"count":   len(entries),
+		"data":    entries,
+	}
+	if len(entries) > 0 {
+		// Para la página siguiente: ?before_id=<next_before_id>
+		response["next_before_id"] = entries[len(entries)-1].ID
+	}
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// exportAudit escribe una entrada JSON por línea, recorriendo todas las páginas del filtro
+func (s *Server) exportAudit(w http.ResponseWriter, r *http.Request, filter repository.AuditFilter) {
+	remaining := 0
+	if r.URL.Query().Get("limit") != "" {
+		remaining = filter.Limit
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.jsonl"`, time.Now().UTC().Format("20060102-150405")))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	for {
+		filter.Limit = auditExportPage
+		if remaining > 0 && remaining < auditExportPage {
+			filter.Limit = remaining
+		}
+		entries, err := s.repo.ListAudit(r.Context(), filter)
+		if err != nil {
+			// Ya se envió el encabezado: se corta la exportación y queda en el log
+			logger.ErrorContext(r.Context(), "❌ Error exportando auditoría", "error", err)
+			return
+		}
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if len(entries) < filter.Limit {
+			return
+		}
+		if remaining > 0 {
+			if remaining -= len(entries); remaining <= 0 {
+				return
+			}
+		}
+		filter.BeforeID = entries[len(entries)-1].ID
+	}
+}
+
+// parseAuditFilter lee los filtros de la consulta de auditoría
+func parseAuditFilter(r *http.Request) (repository.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := repository.AuditFilter{
+		Actor:     query.Get("actor"),
+		Action:    query.Get("action"),
+		Outcome:   query.Get("outcome"),
+		RequestID: query.Get("request_id"),
+	}
+
+	if value := query.Get("employee_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("employee_id inválido: %s", value)
+		}
+		filter.EmployeeID = id
+	}
+	if value := query.Get("before_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("before_id inválido: %s", value)
+		}
+		filter.BeforeID = id
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("limit inválido: %s", value)
+		}
+		filter.Limit = limit
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("%s inválido: use formato RFC3339 (ej: 2026-01-01T00:00:00Z)", name)
+			}
+			*target = &t
+		}
+	}
+	if filter.Action != "" && filter.Action != repository.AuditRead && filter.Action != repository.AuditWrite {
+		return filter, fmt.Errorf("action inválido: use read o write")
+	}
+	return filter, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+func TestAuditAction(t *testing.T) {
+	tests := []struct {
+		method, target string
+		action         string
+		audited        bool
+	}{
+		{"GET", "/api/v1/employees/7", repository.AuditRead, true},
+		{"GET", "/api/v1/portal/me", repository.AuditRead, true},
+		{"POST", "/api/v1/admin/mappings/match", repository.AuditWrite, true},
+		{"POST", "/api/v1/sync/employees", repository.AuditWrite, true},
+		{"GET", "/api/v1/sync/runs", "", false},
+		{"GET", "/api/v1/admin/dead-letters", repository.AuditRead, true},
+		{"GET", "/api/v1/admin/dead-letters/5", repository.AuditRead, true},
+		{"PUT", "/api/v1/admin/dead-letters/5", repository.AuditWrite, true},
+		{"DELETE", "/api/v1/admin/dead-letters/5", repository.AuditWrite, true},
+		{"POST", "/api/v1/admin/dead-letters/5/replay", repository.AuditWrite, true},
+		{"GET", "/api/v1/admin/webhook-events", repository.AuditRead, true},
+		{"POST", "/api/v1/admin/webhook-events/9/replay", repository.AuditWrite, true},
+		{"GET", "/api/v1/admin/api-keys", "", false},
+	}
+	for _, tt := range tests {
+		action, audited := auditAction(httptest.NewRequest(tt.method, tt.target, nil))
+		if audited != tt.audited || (audited && action != tt.action) {
+			t.Errorf("%s %s: auditAction() = %q, %v; se esperaba %q, %v", tt.method, tt.target, action, audited, tt.action, tt.audited)
+		}
+	}
+}
+
+func TestAuditPath(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/api/v1/employees/7", "/api/v1/employees/7"},
+		{"/api/v1/portal/me?employee_id=7", "/api/v1/portal/me?employee_id=7"},
+		{"/api/v1/admin/dead-letters?status=dead&flow=attendance&limit=10", "/api/v1/admin/dead-letters?flow=attendance&limit=10&status=dead"},
+		{"/api/v1/employees?name=Juan+P%C3%A9rez&rut=12.345.678-5", "/api/v1/employees"},
+		{"/api/v1/portal/me?employee_id=7&token=secreto", "/api/v1/portal/me?employee_id=7"},
+	}
+	for _, tt := range tests {
+		if got := auditPath(httptest.NewRequest("GET", tt.target, nil)); got != tt.want {
+			t.Errorf("auditPath(%s) = %s, se esperaba %s", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+)
+
+// flowScopes indica el scope que permite ejecutar cada flujo de sincronización
+// Los flujos que no aparecen requieren admin
+var flowScopes = map[string]string{
+	syncer.FlowEmployees:  auth.ScopeEmployeesWrite,
+	syncer.FlowAttendance: auth.ScopeAttendanceWrite,
+}
+
+// requiredScopes devuelve los scopes que permiten acceder a una ruta de /api/v1 (basta con uno)
+// Las rutas no contempladas requieren admin
+func requiredScopes(r *http.Request) []string {
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/api/v1/admin/"):
+		return []string{auth.ScopeAdmin}
+
+	case strings.HasPrefix(path, "/api/v1/portal/"):
+		// Los empleados ven sus propios registros; un servicio con employees:read, los de cualquiera
+		return []string{auth.ScopeSelf, auth.ScopeEmployeesRead}
+
+	case path == "/api/v1/employees" || strings.HasPrefix(path, "/api/v1/employees/"):
+		if r.Method == http.MethodGet {
+			return []string{auth.ScopeEmployeesRead}
+		}
+		return []string{auth.ScopeEmployeesWrite}
+
+	case path == "/api/v1/sync/runs" || strings.HasPrefix(path, "/api/v1/sync/runs/"):
+		// Quien puede ejecutar una sincronización puede seguir su avance y cancelarla
+		return []string{auth.ScopeEmployeesWrite, auth.ScopeAttendanceWrite}
+
+	case strings.HasPrefix(path, "/api/v1/sync/"):
+		if scope, ok := flowScopes[strings.TrimPrefix(path, "/api/v1/sync/")]; ok {
+			return []string{scope}
+		}
+	}
+	return []string{auth.ScopeAdmin}
+}
+
+// authMiddleware exige una clave de API válida (header X-API-Key) o un JWT (Authorization: Bearer)
+// en las rutas /api/v1 y verifica que tenga alguno de los scopes requeridos por la ruta
+// El resto de las rutas (/livez, /readyz, webhooks con su propia verificación, etc.) queda abierto
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1" && !strings.HasPrefix(r.URL.Path, "/api/v1/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.auth.Enabled() {
+			s.sendError(w, r, http.StatusUnauthorized, codeAuthNotConfigured, nil)
+			return
+		}
+
+		var principal *auth.Principal
+		var err error
+		method := "api_key"
+		if token, ok := bearerToken(r); ok {
+			method = "jwt"
+			principal, err = s.auth.AuthenticateBearer(r.Context(), token)
+		} else {
+			principal, err = s.auth.Authenticate(r.Context(), r.Header.Get("X-API-Key"))
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidToken):
+				authAttempts.Inc(method, "invalid")
+				logger.WarnContext(r.Context(), "🚫 JWT rechazado", "method", r.Method, "path", r.URL.Path, "error", err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				s.sendError(w, r, http.StatusUnauthorized, codeInvalidToken, nil)
+			case errors.Is(err, auth.ErrMissingCredentials) || errors.Is(err, auth.ErrInvalidKey):
+				code := codeInvalidAPIKey
+				if errors.Is(err, auth.ErrMissingCredentials) {
+					code = codeMissingCredentials
+					authAttempts.Inc(method, "missing")
+				} else {
+					authAttempts.Inc(method, "invalid")
+				}
+				w.Header().Set("WWW-Authenticate", `ApiKey header="X-API-Key", Bearer`)
+				s.sendError(w, r, http.StatusUnauthorized, code, nil)
+			default:
+				authAttempts.Inc(method, "error")
+				logger.ErrorContext(r.Context(), "❌ Error validando credenciales", "error", err)
+				s.sendError(w, r, http.StatusInternalServerError, codeInternal, nil)
+			}
+			return
+		}
+
+		auditPrincipal(r, principal)
+
+		r, ok := s.bindTenant(w, r, principal)
+		if !ok {
+			authAttempts.Inc(method, "forbidden")
+			return
+		}
+
+		scopes := requiredScopes(r)
+		if !principal.HasScope(scopes...) {
+			authAttempts.Inc(method, "forbidden")
+			logger.WarnContext(r.Context(), "🚫 Credencial sin permiso para la operación", "auth_method", principal.Method, "principal", principal.Name,
+				"method", r.Method, "path", r.URL.Path, "required_scopes", strings.Join(scopes, " o "))
+			s.sendError(w, r, http.StatusForbidden, codeForbidden, map[string]interface{}{
+				"required_scopes": scopes,
+			})
+			return
+		}
+
+		authAttempts.Inc(method, "success")
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// bearerToken extrae el token del header Authorization: Bearer
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
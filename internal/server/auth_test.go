@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+func TestRequiredScopes(t *testing.T) {
+	var (
+		admin      = []string{auth.ScopeAdmin}
+		read       = []string{auth.ScopeEmployeesRead}
+		write      = []string{auth.ScopeEmployeesWrite}
+		attendance = []string{auth.ScopeAttendanceWrite}
+		runs       = []string{auth.ScopeEmployeesWrite, auth.ScopeAttendanceWrite}
+		portal     = []string{auth.ScopeSelf, auth.ScopeEmployeesRead}
+	)
+	tests := []struct {
+		method, path string
+		want         []string
+	}{
+		{"GET", "/api/v1/employees", read},
+		{"GET", "/api/v1/employees/7", read},
+		{"DELETE", "/api/v1/employees", write},
+		{"GET", "/api/v1/employeesx", admin},
+		{"POST", "/api/v1/sync/employees", write},
+		{"POST", "/api/v1/sync/attendance", attendance},
+		{"POST", "/api/v1/sync/unknown", admin},
+		{"GET", "/api/v1/sync/runs", runs},
+		{"POST", "/api/v1/sync/runs/5/cancel", runs},
+		{"GET", "/api/v1/portal/me", portal},
+		{"GET", "/api/v1/portal/leave-balances", portal},
+		{"GET", "/api/v1/admin/mappings", admin},
+		{"POST", "/api/v1/admin/tenants", admin},
+		{"GET", "/api/v1/audit", admin},
+		{"GET", "/api/v1", admin},
+	}
+	for _, tt := range tests {
+		got := requiredScopes(httptest.NewRequest(tt.method, tt.path, nil))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s: requiredScopes() = %v, se esperaba %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestScopeDenial(t *testing.T) {
+	tests := []struct {
+		name         string
+		scopes       []string
+		method, path string
+		allowed      bool
+	}{
+		{"lectura consulta empleados", []string{auth.ScopeEmployeesRead}, "GET", "/api/v1/employees", true},
+		{"lectura no sincroniza", []string{auth.ScopeEmployeesRead}, "POST", "/api/v1/sync/employees", false},
+		{"lectura no ve ejecuciones", []string{auth.ScopeEmployeesRead}, "GET", "/api/v1/sync/runs", false},
+		{"asistencias no sincroniza empleados", []string{auth.ScopeAttendanceWrite}, "POST", "/api/v1/sync/employees", false},
+		{"asistencias ve ejecuciones", []string{auth.ScopeAttendanceWrite}, "GET", "/api/v1/sync/runs/3", true},
+		{"escritura no administra", []string{auth.ScopeEmployeesWrite}, "GET", "/api/v1/admin/api-keys", false},
+		{"pii:read solo no consulta empleados", []string{auth.ScopePIIRead}, "GET", "/api/v1/employees", false},
+		{"empleado en el portal", []string{auth.ScopeSelf}, "GET", "/api/v1/portal/me", true},
+		{"empleado fuera del portal", []string{auth.ScopeSelf}, "GET", "/api/v1/employees/7", false},
+		{"admin en todas las rutas", []string{auth.ScopeAdmin}, "POST", "/api/v1/admin/tenants", true},
+		{"sin scopes", nil, "GET", "/api/v1/employees", false},
+	}
+	for _, tt := range tests {
+		principal := &auth.Principal{Scopes: tt.scopes}
+		if got := principal.HasScope(requiredScopes(httptest.NewRequest(tt.method, tt.path, nil))...); got != tt.allowed {
+			t.Errorf("%s: %s %s permitido = %v, se esperaba %v", tt.name, tt.method, tt.path, got, tt.allowed)
+		}
+	}
+}
+
+func TestBindTenant(t *testing.T) {
+	if err := logging.Setup(io.Discard, "text"); err != nil {
+		t.Fatal(err)
+	}
+	registry := tenant.NewRegistry(nil, nil, nil)
+	if err := registry.Load(&tenant.File{Tenants: []*tenant.Config{
+		{ID: tenant.DefaultID}, {ID: "acme"}, {ID: "beta"}, {ID: "off", Disabled: true},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{tenants: registry}
+
+	tests := []struct {
+		name string
+		// selected es el tenant indicado en la petición (vacío: ninguno, atiende el por defecto)
+		selected  string
+		principal *auth.Principal
+		want      string // Tenant que atiende la petición; vacío si se rechaza
+		code      string
+	}{
+		{"credencial del mismo tenant", "acme", &auth.Principal{Tenant: "acme", Scopes: []string{auth.ScopeEmployeesRead}}, "acme", ""},
+		{"sin tenant explícito se usa el de la credencial", "", &auth.Principal{Tenant: "acme", Scopes: []string{auth.ScopeEmployeesRead}}, "acme", ""},
+		{"credencial de otro tenant", "beta", &auth.Principal{Tenant: "acme", Scopes: []string{auth.ScopeEmployeesRead}}, "", codeTenantDenied},
+		{"admin de un cliente no opera otros tenants", "beta", &auth.Principal{Tenant: "acme", Scopes: []string{auth.ScopeAdmin}}, "", codeTenantDenied},
+		{"admin de un cliente no opera el tenant por defecto", tenant.DefaultID, &auth.Principal{Tenant: "acme", Scopes: []string{auth.ScopeAdmin}}, "", codeTenantDenied},
+		{"admin del tenant por defecto opera otros tenants", "acme", &auth.Principal{Tenant: tenant.DefaultID, Scopes: []string{auth.ScopeAdmin}}, "acme", ""},
+		{"admin del tenant por defecto sin tenant explícito", "", &auth.Principal{Tenant: tenant.DefaultID, Scopes: []string{auth.ScopeAdmin}}, tenant.DefaultID, ""},
+		{"el tenant por defecto sin admin no opera otros tenants", "acme", &auth.Principal{Tenant: tenant.DefaultID, Scopes: []string{auth.ScopeEmployeesWrite}}, "", codeTenantDenied},
+		{"credencial de un tenant inexistente", "", &auth.Principal{Tenant: "ghost", Scopes: []string{auth.ScopeAdmin}}, "", codeTenantOrphan},
+		{"credencial de un tenant deshabilitado", "", &auth.Principal{Tenant: "off", Scopes: []string{auth.ScopeAdmin}}, "", codeTenantDisabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection := &tenantSelection{tenant: registry.Default()}
+			if tt.selected != "" {
+				selected, _ := registry.Get(tt.selected)
+				selection = &tenantSelection{tenant: selected, explicit: true}
+			}
+			r := httptest.NewRequest("GET", "/api/v1/employees", nil)
+			r = r.WithContext(context.WithValue(r.Context(), tenantKey{}, selection))
+			rec := httptest.NewRecorder()
+
+			bound, ok := s.bindTenant(rec, r, tt.principal)
+			if tt.want != "" {
+				if !ok {
+					t.Fatalf("bindTenant() rechazó la petición: %d %s", rec.Code, rec.Body.String())
+				}
+				if got := currentTenant(bound).ID; got != tt.want {
+					t.Errorf("tenant = %s, se esperaba %s", got, tt.want)
+				}
+				return
+			}
+
+			if ok {
+				t.Fatalf("bindTenant() aceptó la petición con el tenant %s", currentTenant(bound).ID)
+			}
+			var body struct {
+				Error errorBody `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != http.StatusForbidden || body.Error.Code != tt.code {
+				t.Errorf("respuesta %d %s, se esperaba %d %s", rec.Code, body.Error.Code, http.StatusForbidden, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/config"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+// registerReloadHooks aplica las recargas de configuración a los tenants
+// El archivo de tenants se valida antes de aplicar nada: si es inválido la recarga se rechaza completa
+func registerReloadHooks(configs *config.Reloader, registry *tenant.Registry) {
+	configs.OnReload(func(next *config.Config) (func() []string, error) {
+		if next.TenantsFile == "" {
+			return nil, nil
+		}
+		file, err := tenant.LoadFile(next.TenantsFile)
+		if err != nil {
+			return nil, err
+		}
+		return func() []string { return registry.Reload(file) }, nil
+	})
+	configs.OnReload(func(next *config.Config) (func() []string, error) {
+		policy, flows, interval := next.Sync.RetryPolicy(), next.Sync.Flows(), next.Sync.Interval
+		return func() []string {
+			registry.ApplySyncDefaults(policy, flows, interval)
+			return nil
+		}, nil
+	})
+}
+
+// handleConfig devuelve la configuración vigente (secretos enmascarados) y su versión
+// GET /api/v1/admin/config
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOperator(w, r) {
+		return
+	}
+	s.sendConfigStatus(w, r, http.StatusOK)
+}
+
+// handleConfigReload recarga la configuración igual que SIGHUP
+// POST /api/v1/admin/config/reload -> 422 si es inválida (se mantiene la anterior)
+func (s *Server) handleConfigReload(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOperator(w, r) {
+		return
+	}
+
+	if err := s.configs.Reload(); err != nil {
+		version := s.configs.Status().Version
+		details := map[string]interface{}{
+			"version": version,
+			"reason":  err.Error(),
+		}
+		var invalid *config.ValidationError
+		if errors.As(err, &invalid) {
+			delete(details, "reason")
+			details["problems"] = invalid.Problems
+		}
+		s.sendError(w, r, http.StatusUnprocessableEntity, codeConfigRejected, details, version)
+		return
+	}
+	s.sendConfigStatus(w, r, http.StatusOK)
+}
+
+func (s *Server) sendConfigStatus(w http.ResponseWriter, r *http.Request, status int) {
+	masked, err := s.configs.Current().Masked()
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	s.sendJSON(w, status, map[string]interface{}{
+		"success": true,
+		"status":  s.configs.Status(),
+		"data":    masked,
+	})
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+)
+
+// deadLetterPayloadRequest es el cuerpo de PUT /{id} y POST /{id}/replay
+type deadLetterPayloadRequest struct {
+	Payload json.RawMessage `json:"payload"`
+}
+
+// handleDeadLetters lista los elementos fallidos
+// GET /api/v1/admin/dead-letters?flow=attendance&status=dead&limit=50
+func (s *Server) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	items, err := s.repo.ListDeadLetters(r.Context(), repository.DeadLetterFilter{
+		Tenant: t.ID,
+		Flow:   query.Get("flow"),
+		Status: query.Get("status"),
+		Limit:  limit,
+	})
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"count":   len(items),
+		"data":    items,
+	})
+}
+
+// handleGetDeadLetter consulta un elemento fallido
+// GET /api/v1/admin/dead-letters/{id}
+func (s *Server) handleGetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.deadLetter(w, r)
+	if !ok {
+		return
+	}
+	s.sendDeadLetter(w, item)
+}
+
+// handleUpdateDeadLetter edita el payload de un elemento fallido sin reprocesarlo
+// PUT /api/v1/admin/dead-letters/{id}  {"payload": {...}}
+func (s *Server) handleUpdateDeadLetter(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.deadLetter(w, r)
+	if !ok {
+		return
+	}
+	req, ok := s.decodeDeadLetterPayload(w, r, true)
+	if !ok {
+		return
+	}
+	item, err := currentTenant(r).Engine.DeadLetters().Update(r.Context(), item.ID, req.Payload)
+	if err != nil {
+		s.sendDeadLetterError(w, r, err)
+		return
+	}
+	s.sendDeadLetter(w, item)
+}
+
+// handleReplayDeadLetter reprocesa un elemento fallido, opcionalmente con un payload editado
+// POST /api/v1/admin/dead-letters/{id}/replay  [{"payload": {...}}]
+func (s *Server) handleReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.deadLetter(w, r)
+	if !ok {
+		return
+	}
+	req, ok := s.decodeDeadLetterPayload(w, r, false)
+	if !ok {
+		return
+	}
+	replayed, err := currentTenant(r).Engine.DeadLetters().Replay(r.Context(), item.ID, req.Payload)
+	if err != nil {
+		if replayed == nil || errors.Is(err, syncer.ErrNotReplayable) || errors.Is(err, syncer.ErrUnknownFlow) {
+			s.sendDeadLetterError(w, r, err)
+			return
+		}
+		// El reproceso falló: el elemento queda actualizado con el nuevo error
+		s.sendError(w, r, http.StatusUnprocessableEntity, codeReplayFailed, map[string]interface{}{
+			"reason": err.Error(),
+			"item":   replayed,
+		})
+		return
+	}
+	s.sendDeadLetter(w, replayed)
+}
+
+// handleDiscardDeadLetter descarta un elemento fallido
+// DELETE /api/v1/admin/dead-letters/{id}
+func (s *Server) handleDiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.deadLetter(w, r)
+	if !ok {
+		return
+	}
+	item, err := currentTenant(r).Engine.DeadLetters().Discard(r.Context(), item.ID)
+	if err != nil {
+		s.sendDeadLetterError(w, r, err)
+		return
+	}
+	s.sendDeadLetter(w, item)
+}
+
+// deadLetter obtiene el elemento fallido de la ruta, verificando que pertenezca al cliente actual
+func (s *Server) deadLetter(w http.ResponseWriter, r *http.Request) (*repository.DeadLetter, bool) {
+	t := currentTenant(r)
+	id, ok := s.pathID(w, r, "id")
+	if !ok || !s.requireEngine(w, r, t) {
+		return nil, false
+	}
+
+	item, err := s.repo.GetDeadLetter(r.Context(), id)
+	if err == nil && item.Tenant == t.ID {
+		return item, true
+	}
+	if err == nil || errors.Is(err, repository.ErrNotFound) {
+		s.sendError(w, r, http.StatusNotFound, codeDeadLetter, nil, id)
+		return nil, false
+	}
+	s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+	return nil, false
+}
+
+// sendDeadLetter responde con el elemento fallido
+func (s *Server) sendDeadLetter(w http.ResponseWriter, item *repository.DeadLetter) {
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    item,
+	})
+}
+
+// decodeDeadLetterPayload lee el cuerpo {"payload": ...}; si required es false el cuerpo puede venir vacío
+func (s *Server) decodeDeadLetterPayload(w http.ResponseWriter, r *http.Request, required bool) (*deadLetterPayloadRequest, bool) {
+	var req deadLetterPayloadRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && !(errors.Is(err, io.EOF) && !required) {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return nil, false
+	}
+	if required && len(req.Payload) == 0 {
+		s.sendError(w, r, http.StatusBadRequest, codeMissingParameter, nil, "payload")
+		return nil, false
+	}
+	return &req, true
+}
+
+// sendDeadLetterError responde al fallar una operación sobre un elemento fallido
+func (s *Server) sendDeadLetterError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, syncer.ErrInvalidPayload):
+		s.sendError(w, r, http.StatusBadRequest, codeValidationFailed, err)
+	case errors.Is(err, syncer.ErrNotReplayable), errors.Is(err, syncer.ErrUnknownFlow):
+		s.sendError(w, r, http.StatusConflict, codeNotReplayable, err)
+	default:
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+	}
+}
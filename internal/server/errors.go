@@ -0,0 +1,240 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+)
+
+// Códigos de error de la API: identifican el error para los programas, el mensaje es para las personas
+// y se traduce según Accept-Language (ver errorMessages)
+const (
+	codeNotFound           = "not_found"
+	codeMethodNotAllowed   = "method_not_allowed"
+	codeInvalidBody        = "invalid_body"
+	codeInvalidParameter   = "invalid_parameter"
+	codeMissingParameter   = "missing_parameter"
+	codeValidationFailed   = "validation_failed"
+	codeInternal           = "internal_error"
+	codePayloadTooLarge    = "payload_too_large"
+	codeDatabaseMissing    = "database_not_configured"
+	codeOdooMissing        = "odoo_not_configured"
+	codeOdooUnavailable    = "odoo_unavailable"
+	codeQuickpassMissing   = "quickpass_not_configured"
+	codeUpstreamFailed     = "upstream_error"
+	codeAuthNotConfigured  = "auth_not_configured"
+	codeMissingCredentials = "missing_credentials"
+	codeInvalidAPIKey      = "invalid_api_key"
+	codeInvalidToken       = "invalid_token"
+	codeForbidden          = "forbidden"
+	codeOperatorRequired   = "operator_required"
+	codeSelfOnly           = "self_only"
+	codeEmployeeUnlinked   = "employee_not_linked"
+	codeTenantUnknown      = "tenant_unknown"
+	codeTenantDisabled     = "tenant_disabled"
+	codeTenantDenied       = "tenant_access_denied"
+	codeTenantOrphan       = "tenant_not_configured"
+	codeTenantNotFound     = "tenant_not_found"
+	codeTenantConflict     = "tenant_conflict"
+	codeTenantReadOnly     = "tenant_not_managed"
+	codeTenantIDMismatch   = "tenant_id_mismatch"
+	codeTenantCredentials  = "tenant_credentials_invalid"
+	codeEmployeeNotFound   = "employee_not_found"
+	codeQuickpassUser      = "quickpass_user_not_found"
+	codeMappingNotFound    = "mapping_not_found"
+	codeIdentityTaken      = "identity_taken"
+	codeConflictNotFound   = "conflict_not_found"
+	codeRunNotFound        = "run_not_found"
+	codeRunNotActive       = "run_not_active"
+	codeUnknownFlow        = "unknown_flow"
+	codeFeatureDisabled    = "feature_disabled"
+	codeQueueFull          = "queue_full"
+	codePlanFailed         = "plan_failed"
+	codeDeadLetter         = "dead_letter_not_found"
+	codeReplayFailed       = "replay_failed"
+	codeNotReplayable      = "not_replayable"
+	codeAPIKeyNotFound     = "api_key_not_found"
+	codeAPIKeyRevoked      = "api_key_revoked"
+	codeSubscription       = "subscription_not_found"
+	codeSubscriptionKey    = "subscription_key_missing"
+	codeConfigRejected     = "config_rejected"
+	codeWebhookDisabled    = "webhook_not_configured"
+	codeWebhookSignature   = "webhook_signature_invalid"
+	codeWebhookPayload     = "webhook_payload_invalid"
+	codeWebhookFailed      = "webhook_processing_failed"
+	codeWebhookEvent       = "webhook_event_not_found"
+)
+
+// defaultLanguage es el idioma de los mensajes si Accept-Language no pide uno disponible
+const defaultLanguage = "es"
+
+// errorMessages son los mensajes de cada código por idioma; los %v se completan con los argumentos
+// de sendError (ej: el ID que no se encontró)
+var errorMessages = map[string]map[string]string{
+	codeNotFound:           {"es": "Ruta no encontrada", "en": "Route not found"},
+	codeMethodNotAllowed:   {"es": "Método no permitido. Use %v", "en": "Method not allowed. Use %v"},
+	codeInvalidBody:        {"es": "Cuerpo de la petición inválido", "en": "Invalid request body"},
+	codeInvalidParameter:   {"es": "Parámetro inválido: %v", "en": "Invalid parameter: %v"},
+	codeMissingParameter:   {"es": "Falta un dato obligatorio: %v", "en": "Missing required value: %v"},
+	codeValidationFailed:   {"es": "Los datos enviados no son válidos", "en": "The submitted data is not valid"},
+	codeInternal:           {"es": "Error interno del servidor", "en": "Internal server error"},
+	codePayloadTooLarge:    {"es": "El cuerpo de la petición es demasiado grande", "en": "Request body too large"},
+	codeDatabaseMissing:    {"es": "Base de datos no configurada", "en": "Database not configured"},
+	codeOdooMissing:        {"es": "Cliente Odoo no configurado", "en": "Odoo client not configured"},
+	codeOdooUnavailable:    {"es": "No se pudo autenticar con Odoo", "en": "Could not authenticate with Odoo"},
+	codeQuickpassMissing:   {"es": "Cliente Quickpass no configurado", "en": "Quickpass client not configured"},
+	codeUpstreamFailed:     {"es": "Error consultando %v", "en": "Error querying %v"},
+	codeAuthNotConfigured:  {"es": "Autenticación no configurada: defina API_KEY, JWT_SECRET o JWT_JWKS_URL, o configure la base de datos de claves", "en": "Authentication not configured: set API_KEY, JWT_SECRET or JWT_JWKS_URL, or configure the key database"},
+	codeMissingCredentials: {"es": "Faltan credenciales: use el header X-API-Key o Authorization: Bearer", "en": "Missing credentials: use the X-API-Key header or Authorization: Bearer"},
+	codeInvalidAPIKey:      {"es": "Clave de API inválida, revocada o expirada", "en": "Invalid, revoked or expired API key"},
+	codeInvalidToken:       {"es": "Token inválido o vencido", "en": "Invalid or expired token"},
+	codeForbidden:          {"es": "La credencial no tiene permiso para esta operación", "en": "The credential is not allowed to perform this operation"},
+	codeOperatorRequired:   {"es": "Solo las credenciales admin del tenant por defecto pueden administrar tenants y la configuración", "en": "Only admin credentials of the default tenant can manage tenants and configuration"},
+	codeSelfOnly:           {"es": "Solo puede consultar sus propios registros", "en": "You can only read your own records"},
+	codeEmployeeUnlinked:   {"es": "El usuario de Quickpass no está vinculado a un empleado de Odoo", "en": "The Quickpass user is not linked to an Odoo employee"},
+	codeTenantUnknown:      {"es": "Tenant desconocido: %v", "en": "Unknown tenant: %v"},
+	codeTenantDisabled:     {"es": "Tenant deshabilitado: %v", "en": "Tenant disabled: %v"},
+	codeTenantDenied:       {"es": "La credencial no tiene acceso a este tenant", "en": "The credential has no access to this tenant"},
+	codeTenantOrphan:       {"es": "La credencial pertenece a un tenant que no está configurado", "en": "The credential belongs to a tenant that is not configured"},
+	codeTenantNotFound:     {"es": "Tenant %v no encontrado", "en": "Tenant %v not found"},
+	codeTenantConflict:     {"es": "El tenant ya existe o está definido fuera de la API", "en": "The tenant already exists or is defined outside the API"},
+	codeTenantReadOnly:     {"es": "Los tenants no se pueden administrar por la API sin base de datos", "en": "Tenants cannot be managed through the API without a database"},
+	codeTenantIDMismatch:   {"es": "El id del cuerpo no coincide con el de la ruta: un tenant no se puede renombrar", "en": "The body id does not match the path: a tenant cannot be renamed"},
+	codeTenantCredentials:  {"es": "No se pudo autenticar con las credenciales del tenant", "en": "Could not authenticate with the tenant credentials"},
+	codeEmployeeNotFound:   {"es": "Empleado %v no encontrado", "en": "Employee %v not found"},
+	codeQuickpassUser:      {"es": "Usuario de Quickpass %v no encontrado", "en": "Quickpass user %v not found"},
+	codeMappingNotFound:    {"es": "No existe mapeo para el empleado %v", "en": "No mapping exists for employee %v"},
+	codeIdentityTaken:      {"es": "El empleado o el usuario de Quickpass ya están enlazados con otro registro", "en": "The employee or Quickpass user is already linked to another record"},
+	codeConflictNotFound:   {"es": "Conflicto %v no encontrado", "en": "Conflict %v not found"},
+	codeRunNotFound:        {"es": "Ejecución %v no encontrada", "en": "Run %v not found"},
+	codeRunNotActive:       {"es": "La ejecución %v ya terminó: no se puede cancelar", "en": "Run %v has already finished and cannot be cancelled"},
+	codeQueueFull:          {"es": "La cola de sincronización está llena; reintente más tarde", "en": "The sync queue is full; try again later"},
+	codePlanFailed:         {"es": "Error calculando el plan", "en": "Error computing the plan"},
+	codeUnknownFlow:        {"es": "Flujo de sincronización desconocido: %v", "en": "Unknown sync flow: %v"},
+	codeFeatureDisabled:    {"es": "Función deshabilitada: %v", "en": "Feature disabled: %v"},
+	codeDeadLetter:         {"es": "Elemento fallido %v no encontrado", "en": "Dead letter %v not found"},
+	codeReplayFailed:       {"es": "Error reprocesando elemento", "en": "Error replaying item"},
+	codeNotReplayable:      {"es": "El elemento no se puede reprocesar en su estado actual", "en": "The item cannot be replayed in its current state"},
+	codeAPIKeyNotFound:     {"es": "Clave %v no encontrada", "en": "API key %v not found"},
+	codeAPIKeyRevoked:      {"es": "La clave %v está revocada", "en": "API key %v is revoked"},
+	codeSubscription:       {"es": "Suscripción %v no encontrada", "en": "Subscription %v not found"},
+	codeSubscriptionKey:    {"es": "Las suscripciones requieren TENANTS_ENCRYPTION_KEY para cifrar su secreto", "en": "Subscriptions require TENANTS_ENCRYPTION_KEY to encrypt their secret"},
+	codeConfigRejected:     {"es": "Configuración rechazada, se mantiene la versión %v", "en": "Configuration rejected, version %v remains active"},
+	codeWebhookDisabled:    {"es": "Webhook de %v no configurado para el tenant %v", "en": "%v webhook not configured for tenant %v"},
+	codeWebhookSignature:   {"es": "Secreto o firma del webhook inválidos", "en": "Invalid webhook secret or signature"},
+	codeWebhookPayload:     {"es": "Evento de webhook inválido", "en": "Invalid webhook event"},
+	codeWebhookFailed:      {"es": "Error procesando evento", "en": "Error processing event"},
+	codeWebhookEvent:       {"es": "Evento %v no encontrado", "en": "Event %v not found"},
+}
+
+// errorBody es el formato uniforme de los errores: {"error": {"code": ..., "message": ..., ...}}
+type errorBody struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// sendError responde con el formato uniforme de error
+// El mensaje es el de code en el idioma de Accept-Language, completado con args; details lleva datos
+// adicionales para diagnosticar (un error se envía como texto)
+func (s *Server) sendError(w http.ResponseWriter, r *http.Request, status int, code string, details interface{}, args ...interface{}) {
+	if err, ok := details.(error); ok {
+		details = err.Error()
+	}
+	lang := language(r)
+	w.Header().Set("Content-Language", lang)
+	s.sendJSON(w, status, map[string]interface{}{
+		"error": errorBody{
+			Code:      code,
+			Message:   errorMessage(code, lang, args...),
+			Details:   details,
+			RequestID: logging.RequestID(r.Context()),
+		},
+	})
+}
+
+// errorMessage devuelve el mensaje de code en lang, o en el idioma por defecto si no está traducido
+func errorMessage(code, lang string, args ...interface{}) string {
+	messages, ok := errorMessages[code]
+	if !ok {
+		return code
+	}
+	message, ok := messages[lang]
+	if !ok {
+		message = messages[defaultLanguage]
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// language elige el idioma de los mensajes según Accept-Language (ej: "en-US,en;q=0.9,es;q=0.8")
+// Gana el idioma disponible con mayor q; sin ninguno disponible se responde en español
+func language(r *http.Request) string {
+	best, bestQ := defaultLanguage, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := errorMessages[codeNotFound][base]; ok && q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// routeErrors responde con el formato uniforme las peticiones que mux no puede enrutar: 404 si la
+// ruta no existe y 405 (con el header Allow que arma mux) si existe pero no con ese método
+func (s *Server) routeErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(&routeErrorWriter{ResponseWriter: w, server: s, request: r}, r)
+	})
+}
+
+// routeErrorWriter reemplaza las respuestas 404 y 405 de texto plano de http.ServeMux por el formato
+// uniforme; el resto (ej: las redirecciones de rutas con barra final) pasa sin cambios
+type routeErrorWriter struct {
+	http.ResponseWriter
+	server   *Server
+	request  *http.Request
+	replaced bool
+}
+
+func (w *routeErrorWriter) WriteHeader(status int) {
+	switch status {
+	case http.StatusNotFound:
+		w.replaced = true
+		w.server.sendError(w.ResponseWriter, w.request, status, codeNotFound, nil)
+	case http.StatusMethodNotAllowed:
+		w.replaced = true
+		allow := w.Header().Get("Allow")
+		w.server.sendError(w.ResponseWriter, w.request, status, codeMethodNotAllowed, map[string]interface{}{
+			"allowed_methods": strings.Split(strings.ReplaceAll(allow, " ", ""), ","),
+		}, allow)
+	default:
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *routeErrorWriter) Write(p []byte) (int, error) {
+	if w.replaced {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
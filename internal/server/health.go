@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+const (
+	// readinessCacheTTL es cuánto se reutiliza el resultado de una verificación de /readyz, para que
+	// las sondas de varios pods no consulten Odoo y Quickpass en cada petición
+	readinessCacheTTL = 10 * time.Second
+	// readinessCheckTimeout es el tiempo máximo de cada verificación
+	readinessCheckTimeout = 5 * time.Second
+)
+
+// checkResult es el resultado de una verificación de /readyz
+type checkResult struct {
+	Status string `json:"status"` // ok o error
+	// Critical indica que si la verificación falla el pod deja de estar listo
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// readinessCheck es una dependencia que se verifica en /readyz
+type readinessCheck struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context) error
+}
+
+// readiness guarda el último resultado de cada verificación y agrupa las sondas simultáneas
+type readiness struct {
+	group singleflight.Group
+
+	mu      sync.Mutex
+	results map[string]checkResult
+}
+
+func newReadiness() *readiness {
+	return &readiness{results: map[string]checkResult{}}
+}
+
+// check ejecuta las verificaciones cuyo resultado venció (en paralelo) y devuelve el de todas
+func (rd *readiness) check(checks []readinessCheck) map[string]checkResult {
+	rd.group.Do("readyz", func() (interface{}, error) {
+		rd.mu.Lock()
+		var stale []readinessCheck
+		for _, c := range checks {
+			if result, ok := rd.results[c.name]; !ok || time.Since(result.CheckedAt) >= readinessCacheTTL {
+				stale = append(stale, c)
+			}
+		}
+		rd.mu.Unlock()
+
+		var wg sync.WaitGroup
+		for _, c := range stale {
+			wg.Add(1)
+			go func(c readinessCheck) {
+				defer wg.Done()
+				result := runCheck(c)
+				rd.mu.Lock()
+				rd.results[c.name] = result
+				rd.mu.Unlock()
+			}(c)
+		}
+		wg.Wait()
+		return nil, nil
+	})
+
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	current := map[string]checkResult{}
+	for _, c := range checks {
+		if result, ok := rd.results[c.name]; ok {
+			current[c.name] = result
+		}
+	}
+	// Se olvidan los resultados de los tenants que ya no existen
+	rd.results = current
+	return current
+}
+
+func runCheck(c readinessCheck) checkResult {
+	ctx, cancel := context.WithTimeout(context.Background(), readinessCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.run(ctx)
+	result := checkResult{
+		Status:    "ok",
+		Critical:  c.critical,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// readinessChecks arma las verificaciones de la base de datos y de cada tenant habilitado
+// Las de Odoo y Quickpass solo son críticas en el tenant por defecto: que el ERP de un cliente
+// no responda no debe sacar de servicio a los pods que atienden a los demás
+func (s *Server) readinessChecks() []readinessCheck {
+	var checks []readinessCheck
+	if s.repo != nil {
+		checks = append(checks, readinessCheck{name: "repository", critical: true, run: s.repo.Ping})
+	}
+
+	defaultID := s.tenants.DefaultID()
+	for _, t := range s.tenants.List() {
+		if !t.Enabled() {
+			continue
+		}
+		critical := t.ID == defaultID
+		checks = append(checks,
+			readinessCheck{name: "odoo:" + t.ID, critical: critical, run: odooCheck(t)},
+			readinessCheck{name: "quickpass:" + t.ID, critical: critical, run: quickpassCheck(t)},
+		)
+		if t.Runner != nil {
+			checks = append(checks, readinessCheck{name: "scheduler:" + t.ID, critical: true, run: func(context.Context) error {
+				return t.Runner.Health()
+			}})
+		}
+	}
+	return checks
+}
+
+// odooCheck verifica que Odoo responda y acepte las credenciales del tenant
+// Si la autenticación había fallado al iniciar, se reintenta para que el tenant se recupere
+func odooCheck(t *tenant.Tenant) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if t.Odoo == nil {
+			return errors.New("cliente Odoo no configurado")
+		}
+		if err := t.Odoo.Ping(ctx); err != nil {
+			return err
+		}
+		if t.Odoo.UID() == 0 {
+			return t.Odoo.Authenticate(ctx)
+		}
+		return nil
+	}
+}
+
+// quickpassCheck verifica que la API de Quickpass responda; un error 4xx también indica que responde
+func quickpassCheck(t *tenant.Tenant) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if t.Quickpass == nil {
+			return errors.New("cliente Quickpass no configurado")
+		}
+		err := t.Quickpass.Ping(ctx)
+		var apiErr *quickpass.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+			return nil
+		}
+		return err
+	}
+}
+
+// handleLivez indica que el proceso está vivo y atiende peticiones (no revisa dependencias)
+// GET /livez
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "alive",
+		"time":   time.Now().Format(time.RFC3339),
+	})
+}
+
+// handleHealth es la sonda de vida anterior a /livez; se mantiene con su respuesta original para no
+// reiniciar los pods que la usan como livenessProbe cuando falla una dependencia
+// GET /health
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "healthy",
+		"time":   time.Now().Format(time.RFC3339),
+	})
+}
+
+// handleReadyz indica si el pod puede recibir tráfico: la base de datos, Odoo, Quickpass y la cola de
+// sincronización responden; los resultados se guardan readinessCacheTTL
+// GET /readyz -> 200 listo, 503 si falla alguna verificación crítica
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	results := s.readiness.check(s.readinessChecks())
+
+	ready := true
+	var failing []string
+	for name, result := range results {
+		if result.Status != "ok" {
+			failing = append(failing, name)
+			if result.Critical {
+				ready = false
+			}
+		}
+	}
+	sort.Strings(failing)
+
+	status, code := "ready", http.StatusOK
+	switch {
+	case !ready:
+		status, code = "not_ready", http.StatusServiceUnavailable
+	case len(failing) > 0:
+		status = "degraded"
+	}
+	response := map[string]interface{}{
+		"status": status,
+		"time":   time.Now().Format(time.RFC3339),
+		"checks": results,
+	}
+	if len(failing) > 0 {
+		response["failing"] = failing
+	}
+	s.sendJSON(w, code, response)
+}
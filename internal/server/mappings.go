@@ -0,0 +1,270 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+// linkMappingRequest es el cuerpo de POST /api/v1/admin/mappings
+type linkMappingRequest struct {
+	OdooEmployeeID  int    `json:"odoo_employee_id"`
+	QuickpassUserID string `json:"quickpass_user_id"`
+}
+
+// requireRepository responde 503 si no hay base de datos configurada
+func (s *Server) requireRepository(w http.ResponseWriter, r *http.Request) bool {
+	if s.repo == nil {
+		s.sendError(w, r, http.StatusServiceUnavailable, codeDatabaseMissing, nil)
+		return false
+	}
+	return true
+}
+
+// requireOdoo responde 503 si el cliente de Odoo no está disponible, autenticando si es necesario
+func (s *Server) requireOdoo(w http.ResponseWriter, r *http.Request, t *tenant.Tenant) bool {
+	if t.Odoo == nil {
+		s.sendError(w, r, http.StatusServiceUnavailable, codeOdooMissing, nil)
+		return false
+	}
+	if t.Odoo.UID() == 0 {
+		if err := t.Odoo.Authenticate(r.Context()); err != nil {
+			s.sendError(w, r, http.StatusServiceUnavailable, codeOdooUnavailable, err)
+			return false
+		}
+	}
+	return true
+}
+
+// requireQuickpass responde 503 si el cliente de Quickpass no está configurado
+func (s *Server) requireQuickpass(w http.ResponseWriter, r *http.Request, t *tenant.Tenant) bool {
+	if t.Quickpass == nil {
+		s.sendError(w, r, http.StatusServiceUnavailable, codeQuickpassMissing, nil)
+		return false
+	}
+	return true
+}
+
+// handleListMappings lista los mapeos
+// GET /api/v1/admin/mappings
+func (s *Server) handleListMappings(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	mappings, err := s.repo.ListEmployeeMappings(r.Context(), t.ID)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	for _, mapping := range mappings {
+		auditEmployees(r, mapping.OdooEmployeeID)
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"count":   len(mappings),
+		"data":    mappings,
+	})
+}
+
+// handleLinkMapping enlaza manualmente un empleado con un usuario de Quickpass
+// POST /api/v1/admin/mappings
+func (s *Server) handleLinkMapping(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	var req linkMappingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return
+	}
+	if req.OdooEmployeeID <= 0 || req.QuickpassUserID == "" {
+		s.sendError(w, r, http.StatusBadRequest, codeMissingParameter, nil, "odoo_employee_id, quickpass_user_id")
+		return
+	}
+
+	auditEmployees(r, req.OdooEmployeeID)
+	if !s.requireOdoo(w, r, t) || !s.requireQuickpass(w, r, t) {
+		return
+	}
+
+	// Verificar que ambos registros existan antes de enlazarlos
+	employee, err := odoo.NewEmployeeService(t.Odoo).GetEmployeeByID(r.Context(), req.OdooEmployeeID)
+	if err != nil {
+		s.sendError(w, r, http.StatusNotFound, codeEmployeeNotFound, err, req.OdooEmployeeID)
+		return
+	}
+	if _, err := t.Quickpass.GetUser(r.Context(), req.QuickpassUserID); err != nil {
+		s.sendError(w, r, http.StatusNotFound, codeQuickpassUser, err, req.QuickpassUserID)
+		return
+	}
+
+	mapping, err := t.Identity.LinkManual(r.Context(), t.ID, employee.ID, req.QuickpassUserID, employee.IdentificationID)
+	if err != nil {
+		if errors.Is(err, syncer.ErrIdentityTaken) {
+			s.sendError(w, r, http.StatusConflict, codeIdentityTaken, err)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	auditChanges(r, repository.AuditChange{EmployeeID: employee.ID, Field: "quickpass_user_id", After: mapping.QuickpassUserID})
+	s.sendJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    mapping,
+	})
+}
+
+// handleGetMapping obtiene el mapeo de un empleado
+// GET /api/v1/admin/mappings/{odoo_employee_id}
+func (s *Server) handleGetMapping(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	employeeID, ok := s.mappingEmployeeID(w, r)
+	if !ok {
+		return
+	}
+
+	mapping, err := s.repo.GetEmployeeMappingByOdooID(r.Context(), t.ID, employeeID)
+	if err != nil {
+		s.sendMappingError(w, r, err, employeeID)
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    mapping,
+	})
+}
+
+// handleDeleteMapping elimina el mapeo de un empleado
+// DELETE /api/v1/admin/mappings/{odoo_employee_id}
+func (s *Server) handleDeleteMapping(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	employeeID, ok := s.mappingEmployeeID(w, r)
+	if !ok {
+		return
+	}
+
+	previous, err := s.repo.GetEmployeeMappingByOdooID(r.Context(), t.ID, employeeID)
+	if err == nil {
+		err = s.repo.DeleteEmployeeMapping(r.Context(), t.ID, employeeID)
+	}
+	if err != nil {
+		s.sendMappingError(w, r, err, employeeID)
+		return
+	}
+	auditChanges(r, repository.AuditChange{EmployeeID: employeeID, Field: "quickpass_user_id", Before: previous.QuickpassUserID})
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// mappingEmployeeID lee el empleado de la ruta, exige la base de datos y lo registra en la auditoría
+func (s *Server) mappingEmployeeID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, ok := s.pathID(w, r, "odoo_employee_id")
+	if !ok || !s.requireRepository(w, r) {
+		return 0, false
+	}
+	auditEmployees(r, int(id))
+	return int(id), true
+}
+
+// sendMappingError responde 404 si el empleado no tiene mapeo o 500 ante otro error
+func (s *Server) sendMappingError(w http.ResponseWriter, r *http.Request, err error, employeeID int) {
+	if errors.Is(err, repository.ErrNotFound) {
+		s.sendError(w, r, http.StatusNotFound, codeMappingNotFound, nil, employeeID)
+		return
+	}
+	s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+}
+
+// handleMatchMappings ejecuta la asociación automática por RUT y email
+// POST /api/v1/admin/mappings/match
+func (s *Server) handleMatchMappings(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) || !s.requireOdoo(w, r, t) || !s.requireQuickpass(w, r, t) {
+		return
+	}
+
+	employees, err := odoo.NewEmployeeService(t.Odoo).GetAllEmployees(r.Context())
+	if err != nil {
+		s.sendError(w, r, http.StatusBadGateway, codeUpstreamFailed, err, "Odoo")
+		return
+	}
+	users, err := t.Quickpass.ListUsers(r.Context())
+	if err != nil {
+		s.sendError(w, r, http.StatusBadGateway, codeUpstreamFailed, err, "Quickpass")
+		return
+	}
+
+	result, err := t.Identity.Resolve(r.Context(), t.ID, employees, users)
+	if err == nil {
+		err = t.Identity.Apply(r.Context(), result)
+	}
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	for _, mapping := range result.NewMappings {
+		auditEmployees(r, mapping.OdooEmployeeID)
+		auditChanges(r, repository.AuditChange{EmployeeID: mapping.OdooEmployeeID, Field: "quickpass_user_id", After: mapping.QuickpassUserID})
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success":      true,
+		"matched":      len(result.Matched),
+		"new_mappings": result.NewMappings,
+		"unmatched":    len(result.Unmatched),
+		"conflicts":    result.Conflicts,
+	})
+}
+
+// handleMappingConflicts lista los conflictos de identidad
+// GET /api/v1/admin/mappings/conflicts?include_resolved=true
+func (s *Server) handleMappingConflicts(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	includeResolved := r.URL.Query().Get("include_resolved") == "true"
+	conflicts, err := s.repo.ListMappingConflicts(r.Context(), t.ID, includeResolved)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"count":   len(conflicts),
+		"data":    conflicts,
+	})
+}
+
+// handleResolveMappingConflict marca un conflicto como resuelto
+// POST /api/v1/admin/mappings/conflicts/{id}/resolve
+func (s *Server) handleResolveMappingConflict(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	id, ok := s.pathID(w, r, "id")
+	if !ok || !s.requireRepository(w, r) {
+		return
+	}
+
+	if err := s.repo.ResolveMappingConflict(r.Context(), t.ID, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, codeConflictNotFound, nil, id)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec("http_requests_total",
+		"Peticiones HTTP atendidas por método, ruta y código de respuesta", "method", "route", "status")
+	httpDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+		"Duración de las peticiones HTTP por método, ruta y código de respuesta", metrics.DefaultBuckets, "method", "route", "status")
+	authAttempts = metrics.NewCounterVec("auth_attempts_total",
+		"Intentos de autenticación en /api/v1 por tipo de credencial (api_key o jwt) y resultado (success, missing, invalid, forbidden o error)", "method", "result")
+	queueDepth = metrics.NewGaugeVec("queue_depth",
+		"Elementos en espera en las colas en memoria por tenant y cola (sync o webhooks)", "tenant", "queue")
+	breakerState = metrics.NewGaugeVec("circuit_breaker_state",
+		"Estado del circuit breaker de las llamadas a Odoo y Quickpass por tenant (0 cerrado, 1 semiabierto, 2 abierto)", "tenant", "target")
+)
+
+// knownMethods son los métodos HTTP que se registran tal cual en las métricas; los demás se agrupan en
+// "OTHER" para que un cliente no pueda crear una serie por cada método inventado
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// methodLabel devuelve el método de la petición para las etiquetas de las métricas
+func methodLabel(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return "OTHER"
+}
+
+// metricsMiddleware registra la cantidad y duración de las peticiones
+// La ruta es el patrón registrado en mux (no la URL), para no crear una serie por cada ID
+func (s *Server) metricsMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := routePattern(mux, r)
+		recorder := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		code := strconv.Itoa(status)
+		method := methodLabel(r.Method)
+		httpRequests.Inc(method, route, code)
+		httpDuration.Observe(time.Since(start).Seconds(), method, route, code)
+	})
+}
+
+// routePattern devuelve la ruta del patrón de mux que atiende la petición, sin el método ni el
+// prefijo /t/{tenant} (ej: "/api/v1/employees/{id}")
+func routePattern(mux *http.ServeMux, r *http.Request) string {
+	if rest, ok := strings.CutPrefix(r.URL.Path, tenantPrefix); ok {
+		_, path, _ := strings.Cut(rest, "/")
+		r = stripTenantPrefix(r, "/"+path)
+	}
+	if _, pattern := mux.Handler(r); pattern != "" {
+		if _, route, ok := strings.Cut(pattern, " "); ok {
+			pattern = route
+		}
+		return strings.TrimSuffix(pattern, "{$}")
+	}
+	return "unmatched"
+}
+
+// handleMetrics expone las métricas en el formato de texto de Prometheus
+// GET /metrics
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	// La profundidad de las colas y el estado de los circuitos se calculan al leer, así no quedan
+	// series de tenants eliminados
+	queueDepth.Reset()
+	breakerState.Reset()
+	for _, t := range s.tenants.List() {
+		if t.Odoo != nil {
+			breakerState.Set(float64(t.Odoo.BreakerState()), t.ID, "odoo")
+		}
+		if t.Quickpass != nil {
+			breakerState.Set(float64(t.Quickpass.BreakerState()), t.ID, "quickpass")
+		}
+		if t.Runner != nil {
+			queueDepth.Set(float64(t.Runner.QueueDepth()), t.ID, "sync")
+		}
+		if t.Processor != nil {
+			queueDepth.Set(float64(t.Processor.QueueDepth()), t.ID, "webhooks")
+		}
+	}
+	metrics.Handler().ServeHTTP(w, r)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"embed"
+	"net/http"
+)
+
+// openAPIFS contiene la especificación OpenAPI de la API y la página que la muestra
+// openapi_test.go verifica que la especificación y las rutas coincidan
+//
+//go:embed openapi
+var openAPIFS embed.FS
+
+// handleOpenAPI devuelve la especificación OpenAPI 3.1 de la API
+// GET /openapi.json
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	s.serveOpenAPIFile(w, "openapi/openapi.json", "application/json")
+}
+
+// handleDocs devuelve la documentación interactiva de la API, que carga /openapi.json
+// No usa recursos externos: funciona sin acceso a internet
+// GET /docs
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	s.serveOpenAPIFile(w, "openapi/index.html", "text/html; charset=utf-8")
+}
+
+func (s *Server) serveOpenAPIFile(w http.ResponseWriter, name, contentType string) {
+	data, err := openAPIFS.ReadFile(name)
+	if err != nil {
+		logger.Error("❌ Error leyendo la documentación de la API", "file", name, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(data)
+}
@@ -0,0 +1,597 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/config"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+// Prueba de contrato entre openapi/openapi.json y el servidor: las rutas registradas, los códigos de
+// error y las respuestas reales de los handlers deben coincidir con lo documentado
+
+// loadOpenAPI lee la especificación embebida
+func loadOpenAPI(t *testing.T) map[string]interface{} {
+	t.Helper()
+	data, err := openAPIFS.ReadFile("openapi/openapi.json")
+	if err != nil {
+		t.Fatalf("no se pudo leer la especificación: %v", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("la especificación no es JSON válido: %v", err)
+	}
+	if spec["openapi"] != "3.1.0" {
+		t.Fatalf("versión de OpenAPI inesperada: %v", spec["openapi"])
+	}
+	return spec
+}
+
+// specOperation traduce un patrón de http.ServeMux a la ruta y el método de la especificación
+// Las rutas raíz se registran sin método y se documentan como GET
+func specOperation(pattern string) (path, method string) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = http.MethodGet, pattern
+	}
+	if path = strings.TrimSuffix(path, "{$}"); path == "" {
+		path = "/"
+	}
+	return path, strings.ToLower(method)
+}
+
+// specOperations devuelve las operaciones documentadas como "método ruta"
+func specOperations(spec map[string]interface{}) map[string]map[string]interface{} {
+	operations := map[string]map[string]interface{}{}
+	for path, item := range spec["paths"].(map[string]interface{}) {
+		for method, op := range item.(map[string]interface{}) {
+			operations[method+" "+path] = op.(map[string]interface{})
+		}
+	}
+	return operations
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	spec := loadOpenAPI(t)
+	documented := specOperations(spec)
+
+	s := &Server{}
+	registered := map[string]bool{}
+	for _, rt := range append(s.routes(), s.rootRoutes()...) {
+		path, method := specOperation(rt.pattern)
+		key := method + " " + path
+		registered[key] = true
+		if _, ok := documented[key]; !ok {
+			t.Errorf("la ruta %q no está documentada en openapi.json (%s)", rt.pattern, key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("openapi.json documenta %s, pero el servidor no la registra", key)
+		}
+	}
+}
+
+func TestOpenAPIDocumentsEveryErrorCode(t *testing.T) {
+	spec := loadOpenAPI(t)
+	schema := resolveRef(t, spec, "#/components/schemas/ErrorCode")
+	documented := map[string]bool{}
+	for _, code := range schema["enum"].([]interface{}) {
+		documented[code.(string)] = true
+	}
+
+	for code := range errorMessages {
+		if !documented[code] {
+			t.Errorf("el código de error %q no está en ErrorCode", code)
+		}
+		delete(documented, code)
+	}
+	for code := range documented {
+		t.Errorf("ErrorCode documenta %q, pero el servidor no lo usa", code)
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	spec := loadOpenAPI(t)
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch value := node.(type) {
+		case map[string]interface{}:
+			if ref, ok := value["$ref"].(string); ok {
+				resolveRef(t, spec, ref)
+			}
+			for _, child := range value {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range value {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+}
+
+func TestOpenAPIMatchesHandlerResponses(t *testing.T) {
+	spec := loadOpenAPI(t)
+	c := newContractClient(t, spec)
+
+	// Raíz y servicio
+	c.expect(http.StatusOK, "GET", "/livez", "")
+	c.expect(http.StatusServiceUnavailable, "GET", "/readyz", "") // Odoo no responde
+	c.expect(http.StatusOK, "GET", "/health", "")
+	c.expect(http.StatusOK, "GET", "/metrics", "")
+	c.expect(http.StatusOK, "GET", "/openapi.json", "")
+	c.expect(http.StatusOK, "GET", "/docs", "")
+	c.expect(http.StatusOK, "GET", "/", "")
+	c.expect(http.StatusNotFound, "GET", "/", "", "X-Tenant-ID", "unknown")
+	c.expect(http.StatusServiceUnavailable, "GET", "/odoo/status", "")
+
+	// Empleados
+	c.expect(http.StatusUnauthorized, "GET", "/api/v1/employees", "", "X-API-Key", "")
+	c.expect(http.StatusUnauthorized, "GET", "/api/v1/employees", "", "X-API-Key", "wrong")
+	c.expect(http.StatusServiceUnavailable, "GET", "/api/v1/employees", "")
+	c.expect(http.StatusBadRequest, "GET", "/api/v1/employees/abc", "")
+	c.expect(http.StatusServiceUnavailable, "GET", "/api/v1/employees/7", "")
+
+	// Mapeos
+	c.expect(http.StatusOK, "GET", "/api/v1/admin/mappings", "")
+	c.expect(http.StatusBadRequest, "POST", "/api/v1/admin/mappings", "{}")
+	c.expect(http.StatusServiceUnavailable, "POST", "/api/v1/admin/mappings", `{"odoo_employee_id": 7, "quickpass_user_id": "qp-7"}`)
+	c.expect(http.StatusNotFound, "GET", "/api/v1/admin/mappings/7", "")
+	c.expect(http.StatusNotFound, "DELETE", "/api/v1/admin/mappings/7", "")
+	c.expect(http.StatusServiceUnavailable, "POST", "/api/v1/admin/mappings/match", "")
+	c.expect(http.StatusOK, "GET", "/api/v1/admin/mappings/conflicts?include_resolved=true", "")
+	c.expect(http.StatusNotFound, "POST", "/api/v1/admin/mappings/conflicts/9/resolve", "")
+
+	// Sincronización
+	c.expect(http.StatusNotFound, "POST", "/api/v1/sync/unknown", "")
+	c.expect(http.StatusBadGateway, "POST", "/api/v1/sync/employees?dry_run=true", "")
+	run := c.expect(http.StatusAccepted, "POST", "/api/v1/sync/employees", "")
+	runID := c.id(run)
+	c.expect(http.StatusOK, "GET", "/api/v1/sync/runs?flow=employees", "")
+	c.expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/sync/runs/%d?include_events=true", runID), "")
+	c.expect(http.StatusNotFound, "GET", "/api/v1/sync/runs/999", "")
+	c.expect(http.StatusAccepted, "POST", fmt.Sprintf("/api/v1/sync/runs/%d/cancel", runID), "")
+	c.expect(http.StatusNotFound, "POST", "/api/v1/sync/runs/999/cancel", "")
+
+	// Webhooks
+	c.expect(http.StatusUnauthorized, "POST", "/webhooks/odoo", `{"model": "hr.leave", "ids": [1]}`, "X-Webhook-Secret", "wrong")
+	c.expect(http.StatusBadRequest, "POST", "/webhooks/odoo", `{"ids": [1]}`, "X-Webhook-Secret", contractWebhookSecret)
+	c.expect(http.StatusAccepted, "POST", "/webhooks/odoo", `{"event_id": "e-1", "model": "hr.leave", "ids": [1]}`, "X-Webhook-Secret", contractWebhookSecret)
+	c.expect(http.StatusOK, "POST", "/webhooks/odoo", `{"event_id": "e-1", "model": "hr.leave", "ids": [1]}`, "X-Webhook-Secret", contractWebhookSecret)
+	c.expect(http.StatusServiceUnavailable, "POST", "/webhooks/quickpass", `{"id": "q-1", "type": "punch.recorded"}`)
+	events := c.expect(http.StatusOK, "GET", "/api/v1/admin/webhook-events?source=odoo", "")
+	eventID := c.id(events["data"].([]interface{})[0].(map[string]interface{}))
+	c.expect(http.StatusAccepted, "POST", fmt.Sprintf("/api/v1/admin/webhook-events/%d/replay", eventID), "")
+	c.expect(http.StatusNotFound, "POST", "/api/v1/admin/webhook-events/999/replay", "")
+
+	// Elementos fallidos
+	c.expect(http.StatusOK, "GET", "/api/v1/admin/dead-letters?status=dead", "")
+	c.expect(http.StatusNotFound, "GET", "/api/v1/admin/dead-letters/5", "")
+	c.expect(http.StatusNotFound, "PUT", "/api/v1/admin/dead-letters/5", `{"payload": {}}`)
+	c.expect(http.StatusNotFound, "DELETE", "/api/v1/admin/dead-letters/5", "")
+	c.expect(http.StatusNotFound, "POST", "/api/v1/admin/dead-letters/5/replay", "")
+
+	// Claves de API
+	c.expect(http.StatusOK, "GET", "/api/v1/admin/api-keys", "")
+	c.expect(http.StatusBadRequest, "POST", "/api/v1/admin/api-keys", `{"name": "BI", "scopes": ["unknown"]}`)
+	key := c.expect(http.StatusCreated, "POST", "/api/v1/admin/api-keys", `{"name": "BI", "scopes": ["employees:read"]}`)
+	keyID := c.id(key)
+	c.expect(http.StatusForbidden, "GET", "/api/v1/admin/api-keys", "", "X-API-Key", key["key"].(string))
+	c.expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/admin/api-keys/%d", keyID), "")
+	c.expect(http.StatusCreated, "POST", fmt.Sprintf("/api/v1/admin/api-keys/%d/rotate", keyID), `{"overlap_seconds": 0}`)
+	c.expect(http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/admin/api-keys/%d", keyID), "")
+	c.expect(http.StatusConflict, "POST", fmt.Sprintf("/api/v1/admin/api-keys/%d/rotate", keyID), "")
+	c.expect(http.StatusNotFound, "GET", "/api/v1/admin/api-keys/999", "")
+
+	// Tenants
+	acme := fmt.Sprintf(`{"id": "acme", "name": "ACME", "disabled": true,
+		"odoo": {"url": %q, "database": "acme", "username": "admin", "password": "secret"},
+		"quickpass": {"url": %q, "api_key": "qp-key"}}`, c.upstream, c.upstream)
+	c.expect(http.StatusOK, "GET", "/api/v1/admin/tenants", "")
+	c.expect(http.StatusBadRequest, "POST", "/api/v1/admin/tenants", `{"name": "sin id"}`)
+	c.expect(http.StatusCreated, "POST", "/api/v1/admin/tenants", acme)
+	c.expect(http.StatusConflict, "POST", "/api/v1/admin/tenants", acme)
+	c.expect(http.StatusOK, "GET", "/api/v1/admin/tenants/acme", "")
+	c.expect(http.StatusNotFound, "GET", "/api/v1/admin/tenants/unknown", "")
+	c.expect(http.StatusBadRequest, "PUT", "/api/v1/admin/tenants/acme", `{"id": "other"}`)
+	c.expect(http.StatusOK, "PUT", "/api/v1/admin/tenants/acme", acme)
+	c.expect(http.StatusUnprocessableEntity, "POST", "/api/v1/admin/tenants/acme/verify", "")
+	c.expect(http.StatusUnprocessableEntity, "POST", "/api/v1/admin/tenants/acme/enable", "")
+	c.expect(http.StatusOK, "POST", "/api/v1/admin/tenants/acme/disable", "")
+	c.expect(http.StatusForbidden, "GET", "/t/acme/", "")
+	c.expect(http.StatusOK, "DELETE", "/api/v1/admin/tenants/acme", "")
+	c.expect(http.StatusConflict, "DELETE", "/api/v1/admin/tenants/default", "")
+
+	// Configuración
+	c.expect(http.StatusOK, "GET", "/api/v1/admin/config", "")
+	c.expect(http.StatusOK, "POST", "/api/v1/admin/config/reload", "")
+
+	// Suscripciones
+	c.expect(http.StatusBadRequest, "POST", "/api/v1/admin/subscriptions", `{"url": "ftp://example"}`)
+	sub := c.expect(http.StatusCreated, "POST", "/api/v1/admin/subscriptions", fmt.Sprintf(`{"url": %q, "events": ["employee.*"]}`, c.subscriber))
+	subID := c.id(sub)
+	c.expect(http.StatusOK, "GET", "/api/v1/admin/subscriptions", "")
+	c.expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/admin/subscriptions/%d", subID), "")
+	c.expect(http.StatusOK, "PUT", fmt.Sprintf("/api/v1/admin/subscriptions/%d", subID), `{"description": "BI", "rotate_secret": true}`)
+	c.expect(http.StatusOK, "POST", fmt.Sprintf("/api/v1/admin/subscriptions/%d/ping", subID), "")
+	c.expect(http.StatusOK, "GET", fmt.Sprintf("/api/v1/admin/subscriptions/%d/deliveries", subID), "")
+	c.expect(http.StatusOK, "DELETE", fmt.Sprintf("/api/v1/admin/subscriptions/%d", subID), "")
+	c.expect(http.StatusNotFound, "GET", fmt.Sprintf("/api/v1/admin/subscriptions/%d", subID), "")
+
+	// Auditoría
+	c.expect(http.StatusOK, "GET", "/api/v1/audit?limit=10", "")
+	c.expect(http.StatusOK, "GET", "/api/v1/audit?format=jsonl", "")
+	c.expect(http.StatusBadRequest, "GET", "/api/v1/audit?action=delete", "")
+
+	// Portal
+	c.expect(http.StatusBadRequest, "GET", "/api/v1/portal/me", "")
+	c.expect(http.StatusServiceUnavailable, "GET", "/api/v1/portal/me?employee_id=7", "")
+	c.expect(http.StatusServiceUnavailable, "GET", "/api/v1/portal/payslips?employee_id=7", "")
+	c.expect(http.StatusBadRequest, "GET", "/api/v1/portal/leave-balances?employee_id=x", "")
+
+	// Las rutas inexistentes y los métodos no soportados también usan el formato de error
+	c.expectError(http.StatusNotFound, "GET", "/unknown")
+	c.expectError(http.StatusMethodNotAllowed, "PATCH", "/api/v1/employees")
+
+	// Los detalles del error llegan también a quien no ve datos personales (sin pii:read ni admin)
+	writer := c.expect(http.StatusCreated, "POST", "/api/v1/admin/api-keys", `{"name": "ERP", "scopes": ["employees:write"]}`)
+	failure := c.expectError(http.StatusMethodNotAllowed, "DELETE", "/api/v1/employees", "X-API-Key", writer["key"].(string))
+	details, _ := failure["error"].(map[string]interface{})["details"].(map[string]interface{})
+	if methods, _ := details["allowed_methods"].([]interface{}); len(methods) == 0 {
+		t.Errorf("DELETE /api/v1/employees sin pii:read: faltan los métodos permitidos en details: %v", failure)
+	}
+
+	// Cada operación documentada debe quedar cubierta por la prueba
+	for key := range specOperations(spec) {
+		if !c.exercised[key] {
+			t.Errorf("la prueba de contrato no ejercita %s", key)
+		}
+	}
+}
+
+// contractWebhookSecret es el secreto de los webhooks de Odoo en la prueba de contrato
+const contractWebhookSecret = "contract-webhook-secret"
+
+// contractClient envía peticiones al servidor completo y valida cada respuesta contra la especificación
+type contractClient struct {
+	t       *testing.T
+	spec    map[string]interface{}
+	handler http.Handler
+	lookup  *http.ServeMux
+
+	upstream   string // Odoo y Quickpass: responden 503 a todo
+	subscriber string // Recibe las notificaciones
+
+	exercised map[string]bool
+}
+
+func newContractClient(t *testing.T, spec map[string]interface{}) *contractClient {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(upstream.Close)
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(subscriber.Close)
+
+	for name, value := range map[string]string{
+		"CONFIG_FILE":       "",
+		"TENANTS_FILE":      "",
+		"ODOO_URL":          upstream.URL,
+		"ODOO_DATABASE":     "contract",
+		"ODOO_USERNAME":     "admin",
+		"ODOO_PASSWORD":     "secret",
+		"QUICKPASS_URL":     upstream.URL,
+		"QUICKPASS_API_KEY": "qp-key",
+		"API_KEY":           "contract-api-key",
+		"WEBHOOK_SECRET":    contractWebhookSecret,
+		// 32 bytes en base64, para poder crear tenants por la API
+		"TENANTS_ENCRYPTION_KEY": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+	} {
+		t.Setenv(name, value)
+	}
+	if err := logging.Setup(io.Discard, "text"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("configuración inválida: %v", err)
+	}
+	tenants, err := tenant.Load("", &cfg.Webhooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repository.NewSQLite(filepath.Join(t.TempDir(), "contract.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.Migrator().Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := NewServer(config.NewReloader(cfg, nil), tenants, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lookup := http.NewServeMux()
+	for _, rt := range append(srv.routes(), srv.rootRoutes()...) {
+		lookup.HandleFunc(rt.pattern, rt.handler)
+	}
+	return &contractClient{
+		t:          t,
+		spec:       spec,
+		handler:    srv.handler(),
+		lookup:     lookup,
+		upstream:   upstream.URL,
+		subscriber: subscriber.URL,
+		exercised:  map[string]bool{},
+	}
+}
+
+// do envía la petición con la clave de API del operador (headers la reemplazan o agregan otros)
+func (c *contractClient) do(method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-API-Key", "contract-api-key")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect envía la petición, verifica el código y que la respuesta esté documentada para la operación
+// Devuelve el cuerpo JSON decodificado (nil si no es un objeto JSON)
+func (c *contractClient) expect(status int, method, target, body string, headers ...string) map[string]interface{} {
+	c.t.Helper()
+	rec := c.do(method, target, body, headers...)
+	if rec.Code != status {
+		c.t.Fatalf("%s %s: código %d, se esperaba %d: %s", method, target, rec.Code, status, rec.Body.String())
+	}
+
+	path := strings.TrimPrefix(target, "/t/acme")
+	_, pattern := c.lookup.Handler(httptest.NewRequest(method, path, nil))
+	if pattern == "" {
+		c.t.Fatalf("%s %s no corresponde a ninguna ruta", method, target)
+	}
+	specPath, specMethod := specOperation(pattern)
+	key := specMethod + " " + specPath
+	c.exercised[key] = true
+
+	op := specOperations(c.spec)[key]
+	if op == nil {
+		c.t.Fatalf("%s %s: la operación %s no está documentada", method, target, key)
+	}
+	response, ok := op["responses"].(map[string]interface{})[fmt.Sprint(rec.Code)].(map[string]interface{})
+	if !ok {
+		c.t.Fatalf("%s %s: el código %d no está documentado en %s: %s", method, target, rec.Code, key, rec.Body.String())
+	}
+	return c.validateResponse(fmt.Sprintf("%s %s", method, target), response, rec)
+}
+
+// expectError verifica que una petición fuera de las rutas documentadas responda con el formato de error
+// Devuelve el cuerpo JSON decodificado
+func (c *contractClient) expectError(status int, method, target string, headers ...string) map[string]interface{} {
+	c.t.Helper()
+	rec := c.do(method, target, "", headers...)
+	if rec.Code != status {
+		c.t.Fatalf("%s %s: código %d, se esperaba %d", method, target, rec.Code, status)
+	}
+	response := resolveRef(c.t, c.spec, "#/components/responses/"+errorResponses[status])
+	return c.validateResponse(fmt.Sprintf("%s %s", method, target), response, rec)
+}
+
+// errorResponses relaciona los códigos HTTP con las respuestas de error de components/responses
+var errorResponses = map[int]string{
+	http.StatusNotFound:         "NotFound",
+	http.StatusMethodNotAllowed: "MethodNotAllowed",
+}
+
+// validateResponse verifica que el tipo de contenido esté documentado y que el cuerpo cumpla su esquema
+func (c *contractClient) validateResponse(name string, response map[string]interface{}, rec *httptest.ResponseRecorder) map[string]interface{} {
+	c.t.Helper()
+	if ref, ok := response["$ref"].(string); ok {
+		response = resolveRef(c.t, c.spec, ref)
+	}
+	mediaType, _, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil {
+		c.t.Fatalf("%s: Content-Type inválido %q", name, rec.Header().Get("Content-Type"))
+	}
+	content, _ := response["content"].(map[string]interface{})
+	media, ok := content[mediaType].(map[string]interface{})
+	if !ok {
+		c.t.Fatalf("%s: el tipo %s no está documentado para el código %d", name, mediaType, rec.Code)
+	}
+	schema, _ := media["schema"].(map[string]interface{})
+
+	switch mediaType {
+	case "application/json":
+		var value interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &value); err != nil {
+			c.t.Fatalf("%s: el cuerpo no es JSON: %v", name, err)
+		}
+		if problems := validateSchema(c.t, c.spec, schema, value, "$"); len(problems) > 0 {
+			c.t.Errorf("%s: la respuesta %d no cumple el esquema:\n  %s\n%s", name, rec.Code, strings.Join(problems, "\n  "), rec.Body.String())
+		}
+		object, _ := value.(map[string]interface{})
+		return object
+	case "application/x-ndjson":
+		scanner := bufio.NewScanner(bytes.NewReader(rec.Body.Bytes()))
+		for line := 1; scanner.Scan(); line++ {
+			var value interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &value); err != nil {
+				c.t.Fatalf("%s: la línea %d no es JSON: %v", name, line, err)
+			}
+			if problems := validateSchema(c.t, c.spec, schema, value, fmt.Sprintf("línea %d", line)); len(problems) > 0 {
+				c.t.Errorf("%s: %s", name, strings.Join(problems, "; "))
+			}
+		}
+	}
+	return nil
+}
+
+// id lee el campo id de la respuesta (o de su campo data)
+func (c *contractClient) id(body map[string]interface{}) int64 {
+	c.t.Helper()
+	if data, ok := body["data"].(map[string]interface{}); ok {
+		body = data
+	}
+	id, ok := body["id"].(float64)
+	if !ok {
+		c.t.Fatalf("la respuesta no tiene id: %v", body)
+	}
+	return int64(id)
+}
+
+// resolveRef devuelve el nodo de la especificación al que apunta una referencia local (#/...)
+func resolveRef(t *testing.T, spec map[string]interface{}, ref string) map[string]interface{} {
+	t.Helper()
+	var node interface{} = spec
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			t.Fatalf("referencia inválida: %s", ref)
+		}
+		node = object[part]
+	}
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		t.Fatalf("referencia sin destino: %s", ref)
+	}
+	return object
+}
+
+// validateSchema verifica un valor contra el subconjunto de JSON Schema que usa la especificación:
+// $ref, type (uno o varios), enum, oneOf, required, properties, additionalProperties e items
+func validateSchema(t *testing.T, spec, schema map[string]interface{}, value interface{}, at string) []string {
+	t.Helper()
+	if schema == nil {
+		return nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		return validateSchema(t, spec, resolveRef(t, spec, ref), value, at)
+	}
+
+	if options, ok := schema["oneOf"].([]interface{}); ok {
+		for _, option := range options {
+			if len(validateSchema(t, spec, option.(map[string]interface{}), value, at)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: no cumple ninguna de las opciones de oneOf", at)}
+	}
+
+	if declared, ok := schema["type"]; ok {
+		var types []string
+		switch declared := declared.(type) {
+		case string:
+			types = []string{declared}
+		case []interface{}:
+			for _, name := range declared {
+				types = append(types, name.(string))
+			}
+		}
+		if !matchesType(types, value) {
+			return []string{fmt.Sprintf("%s: se esperaba %s, se recibió %s", at, strings.Join(types, " o "), jsonType(value))}
+		}
+	}
+
+	if options, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range options {
+			if option == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v no es uno de los valores documentados", at, value)}
+		}
+	}
+
+	var problems []string
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := value[name.(string)]; !ok {
+					problems = append(problems, fmt.Sprintf("%s: falta el campo obligatorio %s", at, name))
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				problems = append(problems, validateSchema(t, spec, property, value[name], at+"."+name)...)
+			} else if additional != nil {
+				problems = append(problems, validateSchema(t, spec, additional, value[name], at+"."+name)...)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range value {
+				problems = append(problems, validateSchema(t, spec, items, item, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+func matchesType(types []string, value interface{}) bool {
+	actual := jsonType(value)
+	for _, name := range types {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType devuelve el tipo JSON Schema de un valor decodificado por encoding/json
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// portalEmployeeID resuelve de qué empleado son los registros pedidos al portal
+// Un token de empleado solo puede consultar los suyos: el ID sale de sus claims (o del mapeo de identidad
+// si el token trae quickpass_user_id) y un ?employee_id distinto se rechaza con 403
+// Un token de servicio o una clave de API con employees:read debe indicar ?employee_id
+func (s *Server) portalEmployeeID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	principal := auth.FromContext(r.Context())
+	requested := 0
+	if value := r.URL.Query().Get("employee_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			s.sendError(w, r, http.StatusBadRequest, codeInvalidParameter, nil, "employee_id")
+			return 0, false
+		}
+		requested = id
+		// También se audita el intento de ver registros ajenos
+		auditEmployees(r, requested)
+	}
+
+	if principal == nil || !principal.IsEmployee() {
+		if requested == 0 {
+			s.sendError(w, r, http.StatusBadRequest, codeMissingParameter, nil, "employee_id")
+			return 0, false
+		}
+		return requested, true
+	}
+
+	employeeID := principal.EmployeeID
+	if employeeID == 0 {
+		// Sin base de datos no se puede resolver quickpass_user_id
+		if !s.requireRepository(w, r) {
+			return 0, false
+		}
+		mapping, err := s.repo.GetEmployeeMappingByQuickpassID(r.Context(), currentTenant(r).ID, principal.QuickpassUserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				s.sendError(w, r, http.StatusForbidden, codeEmployeeUnlinked, nil)
+				return 0, false
+			}
+			s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+			return 0, false
+		}
+		employeeID = mapping.OdooEmployeeID
+	}
+
+	if requested != 0 && requested != employeeID {
+		s.sendError(w, r, http.StatusForbidden, codeSelfOnly, nil)
+		return 0, false
+	}
+	return employeeID, true
+}
+
+// portalPreamble resuelve el empleado y prepara la respuesta privada
+func (s *Server) portalPreamble(w http.ResponseWriter, r *http.Request) (int, bool) {
+	t := currentTenant(r)
+	// Datos personales: que ningún proxy ni navegador los guarde
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	employeeID, ok := s.portalEmployeeID(w, r)
+	if !ok {
+		return 0, false
+	}
+	auditEmployees(r, employeeID)
+	if !s.requireOdoo(w, r, t) {
+		return 0, false
+	}
+	return employeeID, true
+}
+
+// handlePortalMe devuelve los datos básicos del empleado autenticado
+// GET /api/v1/portal/me
+func (s *Server) handlePortalMe(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	employeeID, ok := s.portalPreamble(w, r)
+	if !ok {
+		return
+	}
+
+	employee, err := odoo.NewEmployeeService(t.Odoo).GetEmployeeByID(r.Context(), employeeID)
+	if err != nil {
+		s.sendError(w, r, http.StatusNotFound, codeEmployeeNotFound, err, employeeID)
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"id":                employee.ID,
+			"name":              employee.Name,
+			"identification_id": employee.IdentificationID,
+			"work_email":        employee.WorkEmail,
+			"work_phone":        employee.WorkPhone,
+		},
+	})
+}
+
+// handlePortalPayslips devuelve las liquidaciones de sueldo confirmadas del empleado
+// GET /api/v1/portal/payslips?limit=12
+func (s *Server) handlePortalPayslips(w http.ResponseWriter, r *http.Request) {
+	if !s.configs.Current().Sync.PayrollSync {
+		s.sendError(w, r, http.StatusServiceUnavailable, codeFeatureDisabled, "ENABLE_PAYROLL_SYNC=false", "payslips")
+		return
+	}
+	t := currentTenant(r)
+	employeeID, ok := s.portalPreamble(w, r)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 120 {
+		limit = 12
+	}
+
+	payslips, err := odoo.NewPayslipService(t.Odoo).GetEmployeePayslips(r.Context(), employeeID, limit)
+	if err != nil {
+		s.sendError(w, r, http.StatusBadGateway, codeUpstreamFailed, err, "Odoo")
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"employee_id": employeeID,
+		"count":       len(payslips),
+		"data":        payslips,
+	})
+}
+
+// handlePortalLeaveBalances devuelve los días asignados, tomados y disponibles por tipo de ausencia
+// GET /api/v1/portal/leave-balances
+func (s *Server) handlePortalLeaveBalances(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	employeeID, ok := s.portalPreamble(w, r)
+	if !ok {
+		return
+	}
+
+	balances, err := odoo.NewLeaveService(t.Odoo).GetBalances(r.Context(), employeeID)
+	if err != nil {
+		s.sendError(w, r, http.StatusBadGateway, codeUpstreamFailed, err, "Odoo")
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"employee_id": employeeID,
+		"count":       len(balances),
+		"data":        balances,
+	})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/redact"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+)
+
+// redactingWriter retiene la respuesta para aplicarle la política de datos personales antes de enviarla
+type redactingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *redactingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// redactMiddleware elimina o enmascara los datos personales de las respuestas de /api/v1
+// según los scopes del llamador (redact.DefaultPolicy); debe ir después de authMiddleware
+// Quien tiene pii:read, admin o es el propio empleado en el portal recibe la respuesta sin cambios
+func (s *Server) redactMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.FromContext(r.Context())
+		if principal == nil || redact.DefaultPolicy.Exempt(principal.HasScope) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &redactingWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		body := recorder.body.Bytes()
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			body = redact.DefaultPolicy.JSON(body, principal.HasScope)
+		}
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(recorder.status)
+		w.Write(body)
+	})
+}
+
+// redactPlan aplica la política a las descripciones y diffs de un plan antes de mostrarlo,
+// así el diff en texto tampoco expone datos personales
+func redactPlan(r *http.Request, plan *syncer.Plan) {
+	principal := auth.FromContext(r.Context())
+	if principal == nil || redact.DefaultPolicy.Exempt(principal.HasScope) {
+		return
+	}
+	for _, change := range plan.Changes {
+		change.Description = redact.DefaultPolicy.Field("description", change.Description, principal.HasScope)
+		change.Reason = redact.Text(change.Reason)
+		for i := range change.Fields {
+			field := &change.Fields[i]
+			field.Before = redact.DefaultPolicy.Field(field.Field, field.Before, principal.HasScope)
+			field.After = redact.DefaultPolicy.Field(field.Field, field.After, principal.HasScope)
+		}
+	}
+}
@@ -1,65 +1,299 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/config"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
 	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
 )
 
+var logger = logging.For("server")
+
 type Server struct {
-	port       string
-	odooClient *odoo.Client
-	// quickpassClient *quickpass.Client
+	configs  *config.Reloader
+	tenants  *tenant.Registry
+	repo     repository.Repository
+	notifier *notify.Dispatcher
+	// subscriptions guarda las suscripciones con su secreto cifrado (nil sin base de datos)
+	subscriptions *notify.SealedStore
+	auth          *auth.Authenticator
+
+	readiness *readiness
+
+	mu         sync.Mutex
 	httpServer *http.Server
 }
 
-func NewServer(port string, odooClient *odoo.Client) *Server {
-	return &Server{
-		port:       port,
-		odooClient: odooClient,
+// NewServer crea el servidor con la configuración y los tenants indicados (ver config.Load y tenant.Load)
+// Cada tenant recibe sus propios clientes de Odoo y Quickpass, motor de sincronización y procesador de webhooks
+// Las recargas de configuración (ver config.Reloader) se aplican a los tenants y a la sincronización sin reiniciar
+func NewServer(configs *config.Reloader, tenants *tenant.File, repo repository.Repository) (*Server, error) {
+	cfg := configs.Current()
+	var notifier *notify.Dispatcher
+	var subscriptions *notify.SealedStore
+	var publisher syncer.Notifier
+	var keys repository.APIKeyStore
+	if repo != nil {
+		keys = repo
+
+		// Los secretos de las suscripciones se cifran con la misma clave que las credenciales de los tenants
+		sealer, err := secret.SealerFromEnv("TENANTS_ENCRYPTION_KEY")
+		if err != nil {
+			logger.Warn("⚠️ No se podrán crear ni editar suscripciones", "error", err)
+		} else if sealer == nil {
+			logger.Warn("⚠️ TENANTS_ENCRYPTION_KEY no configurada: no se podrán crear ni editar suscripciones")
+		}
+		subscriptions = notify.NewSealedStore(repo, sealer)
+
+		notifier = notify.NewDispatcher(subscriptions, &cfg.Notify)
+		publisher = notifier
+	}
+
+	registry := tenant.NewRegistry(repo, publisher, &cfg.Webhooks)
+	registry.SetSyncDefaults(cfg.Sync.RetryPolicy(), cfg.Sync.Flows(), cfg.Sync.Interval)
+	if err := registry.Load(tenants); err != nil {
+		return nil, fmt.Errorf("error al cargar los tenants: %w", err)
+	}
+	if err := registry.LoadStored(context.Background()); err != nil {
+		return nil, fmt.Errorf("error al cargar los tenants: %w", err)
+	}
+	if subscriptions != nil {
+		sealStoredSubscriptions(subscriptions, registry)
+	}
+
+	if cfg.Auth.BootstrapKey.IsZero() {
+		logger.Warn("⚠️ API_KEY no configurada: /api/v1 solo acepta claves creadas previamente en la base de datos")
+	}
+
+	srv := &Server{
+		configs:       configs,
+		tenants:       registry,
+		repo:          repo,
+		notifier:      notifier,
+		subscriptions: subscriptions,
+		// Las credenciales sin tenant (API_KEY, JWT sin claim tenant) pertenecen al tenant por defecto
+		auth:      auth.NewAuthenticator(keys, &cfg.Auth, registry.DefaultID()),
+		readiness: newReadiness(),
 		httpServer: &http.Server{
-			Addr: fmt.Sprintf(":%s", port),
+			Addr: cfg.Server.Addr(),
 		},
 	}
+	registerReloadHooks(configs, registry)
+	return srv, nil
 }
 
-func (s *Server) Start() error {
+// sealStoredSubscriptions cifra los secretos de las suscripciones guardadas sin cifrar por versiones anteriores
+// Un error no impide iniciar: esas suscripciones se siguen leyendo y se cifran al editarlas
+func sealStoredSubscriptions(subscriptions *notify.SealedStore, registry *tenant.Registry) {
+	for _, t := range registry.List() {
+		sealed, err := subscriptions.SealStored(context.Background(), t.ID)
+		if err != nil {
+			logger.Warn("⚠️ Error cifrando los secretos de las suscripciones", "tenant", t.ID, "error", err)
+			continue
+		}
+		if sealed > 0 {
+			logger.Info("🔐 Secretos de suscripciones cifrados", "tenant", t.ID, "count", sealed)
+		}
+	}
+}
+
+// route es una ruta del servidor: el patrón de http.ServeMux y su handler
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+// routes devuelve las rutas de la API; cada una está documentada en openapi/openapi.json (ver openapi_test.go)
+// Los patrones indican el método: mux responde 405 con el header Allow si la ruta existe con otro
+// método, y routeErrors convierte esas respuestas y los 404 al formato uniforme de error
+func (s *Server) routes() []route {
+	return []route{
+		// Rutas del servidor
+		{"GET /{$}", s.handleHome},
+		{"GET /odoo/status", s.handleOdooStatus},
+
+		// Rutas de empleados (API v1)
+		{"GET /api/v1/employees", s.handleGetEmployees},
+		{"GET /api/v1/employees/{id}", s.handleGetEmployeeByID},
+
+		// Administración de mapeos de identidad Odoo ↔ Quickpass
+		{"GET /api/v1/admin/mappings", s.handleListMappings},
+		{"POST /api/v1/admin/mappings", s.handleLinkMapping},
+		{"GET /api/v1/admin/mappings/{odoo_employee_id}", s.handleGetMapping},
+		{"DELETE /api/v1/admin/mappings/{odoo_employee_id}", s.handleDeleteMapping},
+		{"POST /api/v1/admin/mappings/match", s.handleMatchMappings},
+		{"GET /api/v1/admin/mappings/conflicts", s.handleMappingConflicts},
+		{"POST /api/v1/admin/mappings/conflicts/{id}/resolve", s.handleResolveMappingConflict},
+
+		// Sincronización
+		{"POST /api/v1/sync/{flow}", s.handleSync},
+		{"GET /api/v1/sync/runs", s.handleSyncRuns},
+		{"GET /api/v1/sync/runs/{id}", s.handleSyncRunByID},
+		{"POST /api/v1/sync/runs/{id}/cancel", s.handleCancelSyncRun},
+
+		// Webhooks entrantes
+		{"POST /webhooks/odoo", s.handleOdooWebhook},
+		{"POST /webhooks/quickpass", s.handleQuickpassWebhook},
+		{"GET /api/v1/admin/webhook-events", s.handleWebhookEvents},
+		{"POST /api/v1/admin/webhook-events/{id}/replay", s.handleReplayWebhookEvent},
+
+		// Cola de elementos fallidos
+		{"GET /api/v1/admin/dead-letters", s.handleDeadLetters},
+		{"GET /api/v1/admin/dead-letters/{id}", s.handleGetDeadLetter},
+		{"PUT /api/v1/admin/dead-letters/{id}", s.handleUpdateDeadLetter},
+		{"DELETE /api/v1/admin/dead-letters/{id}", s.handleDiscardDeadLetter},
+		{"POST /api/v1/admin/dead-letters/{id}/replay", s.handleReplayDeadLetter},
+
+		// Claves de acceso a la API
+		{"GET /api/v1/admin/api-keys", s.handleListAPIKeys},
+		{"POST /api/v1/admin/api-keys", s.handleCreateAPIKey},
+		{"GET /api/v1/admin/api-keys/{id}", s.handleGetAPIKey},
+		{"DELETE /api/v1/admin/api-keys/{id}", s.handleRevokeAPIKey},
+		{"POST /api/v1/admin/api-keys/{id}/rotate", s.handleRotateAPIKey},
+
+		// Administración de tenants (solo credenciales admin del tenant por defecto)
+		{"GET /api/v1/admin/tenants", s.handleListTenants},
+		{"POST /api/v1/admin/tenants", s.handleCreateTenant},
+		{"GET /api/v1/admin/tenants/{id}", s.handleGetTenant},
+		{"PUT /api/v1/admin/tenants/{id}", s.handleReplaceTenant},
+		{"DELETE /api/v1/admin/tenants/{id}", s.handleDeleteTenant},
+		{"POST /api/v1/admin/tenants/{id}/verify", s.handleVerifyTenant},
+		{"POST /api/v1/admin/tenants/{id}/disable", s.handleDisableTenant},
+		{"POST /api/v1/admin/tenants/{id}/enable", s.handleEnableTenant},
+
+		// Configuración vigente y recarga sin reiniciar (también con SIGHUP o al modificar el archivo)
+		{"GET /api/v1/admin/config", s.handleConfig},
+		{"POST /api/v1/admin/config/reload", s.handleConfigReload},
+
+		// Suscripciones a notificaciones de cambios
+		{"GET /api/v1/admin/subscriptions", s.handleListSubscriptions},
+		{"POST /api/v1/admin/subscriptions", s.handleCreateSubscription},
+		{"GET /api/v1/admin/subscriptions/{id}", s.handleGetSubscription},
+		{"PUT /api/v1/admin/subscriptions/{id}", s.handleUpdateSubscription},
+		{"DELETE /api/v1/admin/subscriptions/{id}", s.handleDeleteSubscription},
+		{"POST /api/v1/admin/subscriptions/{id}/ping", s.handlePingSubscription},
+		{"GET /api/v1/admin/subscriptions/{id}/deliveries", s.handleSubscriptionDeliveries},
+
+		// Auditoría de accesos a datos de empleados
+		{"GET /api/v1/audit", s.handleAudit},
+
+		// Portal del empleado (JWT emitido por Quickpass): cada empleado ve solo sus registros
+		{"GET /api/v1/portal/me", s.handlePortalMe},
+		{"GET /api/v1/portal/payslips", s.handlePortalPayslips},
+		{"GET /api/v1/portal/leave-balances", s.handlePortalLeaveBalances},
+	}
+}
+
+// rootRoutes son las sondas de Kubernetes, las métricas y la documentación de la API: quedan fuera de
+// los middlewares porque no dependen del tenant por defecto, no requieren credenciales y no llenan el log
+func (s *Server) rootRoutes() []route {
+	return []route{
+		{"/livez", s.handleLivez},
+		{"/readyz", s.handleReadyz},
+		{"/health", s.handleHealth}, // Compatibilidad: sonda de vida anterior a /livez
+		{"/metrics", s.handleMetrics},
+		{"/openapi.json", s.handleOpenAPI},
+		{"/docs", s.handleDocs},
+	}
+}
+
+// handler arma el enrutador del servidor: las rutas raíz y, para el resto, las de la API con sus middlewares
+func (s *Server) handler() http.Handler {
 	mux := http.NewServeMux()
+	for _, rt := range s.routes() {
+		mux.HandleFunc(rt.pattern, rt.handler)
+	}
 
-	// Rutas del servidor
-	mux.HandleFunc("/", s.handleHome)
-	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/odoo/status", s.handleOdooStatus)
+	root := http.NewServeMux()
+	for _, rt := range s.rootRoutes() {
+		root.HandleFunc(rt.pattern, rt.handler)
+	}
+	root.Handle("/", s.metricsMiddleware(mux, s.tracingMiddleware(mux, s.requestIDMiddleware(s.loggingMiddleware(s.tenantMiddleware(s.auditMiddleware(s.authMiddleware(s.redactMiddleware(s.routeErrors(mux))))))))))
+	return root
+}
 
-	// Rutas de empleados (API v1)
-	mux.HandleFunc("/api/v1/employees", s.handleGetEmployees)
-	mux.HandleFunc("/api/v1/employees/", s.handleGetEmployeeByID) // Con trailing slash para capturar /employees/{id}
+func (s *Server) Start() error {
+	// El http.Server se crea en NewServer para que Shutdown funcione aunque se llame antes que Start
+	s.mu.Lock()
+	s.httpServer.Handler = s.handler()
+	s.httpServer.ReadTimeout = 15 * time.Second
+	s.httpServer.WriteTimeout = 15 * time.Second
+	s.httpServer.IdleTimeout = 60 * time.Second
+	s.mu.Unlock()
 
-	s.httpServer = &http.Server{
-		Addr:         ":" + s.port,
-		Handler:      s.loggingMiddleware(mux),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	// Iniciar en cada tenant la cola de ejecuciones, los reintentos automáticos de elementos fallidos
+	// y el procesamiento asíncrono de webhooks de Quickpass
+	s.tenants.Start()
+
+	// Iniciar el envío de notificaciones a suscriptores
+	if s.notifier != nil {
+		s.notifier.Start()
 	}
 
-	log.Printf("🚀 Servidor iniciado en http://localhost:%s\n", s.port)
-	return s.httpServer.ListenAndServe()
+	logger.Info("🚀 Servidor iniciado", "addr", "http://"+s.httpServer.Addr, "environment", s.configs.Current().Environment)
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		s.tenants.Stop()
+		if s.notifier != nil {
+			s.notifier.Stop()
+		}
+		return err
+	}
+	return nil
 }
 
-// Middleware de logging
+// Shutdown apaga el servidor de forma ordenada: deja de aceptar conexiones y espera las peticiones
+// en curso, luego detiene los procesos de los tenants (la sincronización en curso termina en un punto
+// seguro) y por último las notificaciones; lo que no termine antes de que venza ctx se cancela
+// Start devuelve nil apenas se llama a Shutdown; la base de datos la cierra quien la abrió
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	var errs []error
+	if err := httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("error al esperar las peticiones en curso: %w", err))
+	}
+	if err := s.tenants.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("error al detener la sincronización: %w", err))
+	}
+	if s.notifier != nil {
+		if err := s.notifier.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("error al detener las notificaciones: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Middleware de logging: una línea por petición con el código de respuesta y la duración
+// (el request_id lo agrega el contexto, ver requestIDMiddleware)
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		log.Printf("📥 %s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(w, r)
-		log.Printf("📤 %s %s - %v", r.Method, r.URL.Path, time.Since(start))
+		logger.DebugContext(r.Context(), "📥 Petición recibida", "method", r.Method, "path", r.URL.Path)
+		recorder := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger.InfoContext(r.Context(), "📤 Petición atendida", "method", r.Method, "path", r.URL.Path,
+			"status", status, "duration_ms", time.Since(start).Milliseconds())
 	})
 }
 
@@ -74,41 +308,18 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 	s.sendJSON(w, http.StatusOK, response)
 }
 
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	response := map[string]interface{}{
-		"status": "healthy",
-		"time":   time.Now().Format(time.RFC3339),
-	}
-	s.sendJSON(w, http.StatusOK, response)
-}
-
 // handleOdooStatus verifica la conexión con Odoo
 func (s *Server) handleOdooStatus(w http.ResponseWriter, r *http.Request) {
-	if s.odooClient == nil {
-		s.sendJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
-			"status":  "error",
-			"message": "Cliente Odoo no configurado",
-		})
+	t := currentTenant(r)
+	if !s.requireOdoo(w, r, t) {
 		return
 	}
 
-	// Verificar si ya está autenticado
-	if s.odooClient.UID == 0 {
-		err := s.odooClient.Authenticate()
-		if err != nil {
-			s.sendJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
-				"status":  "error",
-				"message": fmt.Sprintf("Error autenticando con Odoo: %v", err),
-			})
-			return
-		}
-	}
-
 	response := map[string]interface{}{
 		"status":      "connected",
-		"client_name": s.odooClient.ClientName,
-		"uid":         s.odooClient.UID,
-		"database":    s.odooClient.Database,
+		"client_name": t.Odoo.ClientName,
+		"uid":         t.Odoo.UID(),
+		"database":    t.Odoo.Database,
 	}
 	s.sendJSON(w, http.StatusOK, response)
 }
@@ -118,52 +329,30 @@ func (s *Server) sendJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("❌ Error encoding JSON: %v", err)
+		logger.Error("❌ Error codificando la respuesta JSON", "error", err)
 	}
 }
 
 // handleGetEmployees obtiene todos los empleados de Odoo
 // GET /api/v1/employees
 func (s *Server) handleGetEmployees(w http.ResponseWriter, r *http.Request) {
-	// Solo permitir método GET
-	if r.Method != http.MethodGet {
-		s.sendJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{
-			"error": "Método no permitido. Use GET",
-		})
-		return
-	}
-
-	// Verificar cliente de Odoo
-	if s.odooClient == nil {
-		s.sendJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
-			"error": "Cliente Odoo no configurado",
-		})
+	t := currentTenant(r)
+	if !s.requireOdoo(w, r, t) {
 		return
 	}
 
-	// Autenticar si es necesario
-	if s.odooClient.UID == 0 {
-		if err := s.odooClient.Authenticate(); err != nil {
-			s.sendJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
-				"error": fmt.Sprintf("Error autenticando con Odoo: %v", err),
-			})
-			return
-		}
-	}
-
-	// Crear servicio de empleados
-	employeeService := odoo.NewEmployeeService(s.odooClient)
-
 	// Obtener todos los empleados
-	employees, err := employeeService.GetAllEmployees()
+	employees, err := odoo.NewEmployeeService(t.Odoo).GetAllEmployees(r.Context())
 	if err != nil {
-		s.sendJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"error": fmt.Sprintf("Error obteniendo empleados: %v", err),
-		})
+		s.sendError(w, r, http.StatusBadGateway, codeUpstreamFailed, err, "Odoo")
 		return
 	}
 
 	// Responder con los empleados
+	for _, employee := range employees {
+		auditEmployees(r, employee.ID)
+	}
+
 	s.sendJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"count":   len(employees),
@@ -174,59 +363,22 @@ func (s *Server) handleGetEmployees(w http.ResponseWriter, r *http.Request) {
 // handleGetEmployeeByID obtiene un empleado específico por ID
 // GET /api/v1/employees/{id}
 func (s *Server) handleGetEmployeeByID(w http.ResponseWriter, r *http.Request) {
-	// Solo permitir método GET
-	if r.Method != http.MethodGet {
-		s.sendJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{
-			"error": "Método no permitido. Use GET",
-		})
-		return
-	}
-
-	// Extraer ID de la URL
-	// /api/v1/employees/123 -> 123
-	path := strings.TrimPrefix(r.URL.Path, "/api/v1/employees/")
-	if path == "" || path == r.URL.Path {
-		// Si no hay ID, redirigir al listado completo
-		s.handleGetEmployees(w, r)
+	t := currentTenant(r)
+	id, ok := s.pathID(w, r, "id")
+	if !ok {
 		return
 	}
-
-	// Convertir ID a int
-	employeeID, err := strconv.Atoi(path)
-	if err != nil {
-		s.sendJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error": "ID de empleado inválido",
-		})
+	employeeID := int(id)
+	if !s.requireOdoo(w, r, t) {
 		return
 	}
 
-	// Verificar cliente de Odoo
-	if s.odooClient == nil {
-		s.sendJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
-			"error": "Cliente Odoo no configurado",
-		})
-		return
-	}
-
-	// Autenticar si es necesario
-	if s.odooClient.UID == 0 {
-		if err := s.odooClient.Authenticate(); err != nil {
-			s.sendJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
-				"error": fmt.Sprintf("Error autenticando con Odoo: %v", err),
-			})
-			return
-		}
-	}
-
-	// Crear servicio de empleados
-	employeeService := odoo.NewEmployeeService(s.odooClient)
+	auditEmployees(r, employeeID)
 
 	// Obtener empleado por ID
-	employee, err := employeeService.GetEmployeeByID(employeeID)
+	employee, err := odoo.NewEmployeeService(t.Odoo).GetEmployeeByID(r.Context(), employeeID)
 	if err != nil {
-		s.sendJSON(w, http.StatusNotFound, map[string]interface{}{
-			"error": fmt.Sprintf("Empleado no encontrado: %v", err),
-		})
+		s.sendError(w, r, http.StatusNotFound, codeEmployeeNotFound, err, employeeID)
 		return
 	}
 
@@ -236,3 +388,14 @@ func (s *Server) handleGetEmployeeByID(w http.ResponseWriter, r *http.Request) {
 		"data":    employee,
 	})
 }
+
+// pathID lee un ID numérico positivo de la ruta (ej: {id} en /api/v1/employees/{id})
+// Si no es válido responde 400 y devuelve false
+func (s *Server) pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id <= 0 {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidParameter, nil, name)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// subscriptionRequest es el cuerpo de POST y PUT; en PUT los campos omitidos no cambian
+type subscriptionRequest struct {
+	URL          *string  `json:"url"`
+	Events       []string `json:"events"`
+	Description  *string  `json:"description"`
+	Active       *bool    `json:"active"`
+	Secret       *string  `json:"secret"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+// handleListSubscriptions lista las suscripciones a notificaciones de cambios
+// GET /api/v1/admin/subscriptions
+func (s *Server) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	subs, err := s.subscriptions.ListSubscriptions(r.Context(), t.ID)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"count":   len(subs),
+		"data":    subs,
+	})
+}
+
+// handleCreateSubscription registra una suscripción a notificaciones de cambios
+// POST /api/v1/admin/subscriptions  {"url": "https://...", "events": ["employee.*"], "description": "..."}
+func (s *Server) handleCreateSubscription(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	var req subscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return
+	}
+
+	sub := &repository.Subscription{Tenant: t.ID, Active: true, Secret: notify.GenerateSecret()}
+	if req.URL == nil {
+		req.URL = new(string)
+	}
+	if err := applySubscriptionRequest(sub, &req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeValidationFailed, err)
+		return
+	}
+
+	if err := s.subscriptions.CreateSubscription(r.Context(), sub); err != nil {
+		s.sendSubscriptionError(w, r, err)
+		return
+	}
+
+	// El secreto solo se muestra al crear o rotar: el suscriptor lo necesita para verificar las firmas
+	s.sendJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    sub,
+		"secret":  sub.Secret,
+	})
+}
+
+// handleGetSubscription consulta una suscripción
+// GET /api/v1/admin/subscriptions/{id}
+func (s *Server) handleGetSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.subscription(w, r)
+	if !ok {
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    sub,
+	})
+}
+
+// handleUpdateSubscription edita url, events, description, active o el secreto de una suscripción
+// PUT /api/v1/admin/subscriptions/{id}
+func (s *Server) handleUpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.subscription(w, r)
+	if !ok {
+		return
+	}
+
+	var req subscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return
+	}
+	if err := applySubscriptionRequest(sub, &req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeValidationFailed, err)
+		return
+	}
+	if err := s.subscriptions.UpdateSubscription(r.Context(), sub); err != nil {
+		s.sendSubscriptionError(w, r, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"data":    sub,
+	}
+	if req.RotateSecret || req.Secret != nil {
+		response["secret"] = sub.Secret
+	}
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// handleDeleteSubscription elimina una suscripción y su registro de entregas
+// DELETE /api/v1/admin/subscriptions/{id}
+func (s *Server) handleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.subscription(w, r)
+	if !ok {
+		return
+	}
+	if err := s.repo.DeleteSubscription(r.Context(), sub.ID); err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Suscripción %d eliminada", sub.ID),
+	})
+}
+
+// handlePingSubscription envía un evento de prueba y devuelve el resultado
+// POST /api/v1/admin/subscriptions/{id}/ping
+func (s *Server) handlePingSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.subscription(w, r)
+	if !ok {
+		return
+	}
+	delivery, err := s.notifier.Ping(r.Context(), sub)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	// 200 aunque el suscriptor falle: el resultado del intento viene en la entrega
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": delivery.Status == repository.DeliveryDelivered,
+		"data":    delivery,
+	})
+}
+
+// handleSubscriptionDeliveries devuelve el registro de entregas de una suscripción
+// GET /api/v1/admin/subscriptions/{id}/deliveries?status=failed&limit=50
+func (s *Server) handleSubscriptionDeliveries(w http.ResponseWriter, r *http.Request) {
+	sub, ok := s.subscription(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	deliveries, err := s.repo.ListDeliveries(r.Context(), repository.DeliveryFilter{
+		Tenant:         sub.Tenant,
+		SubscriptionID: sub.ID,
+		Status:         query.Get("status"),
+		Limit:          limit,
+	})
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"count":   len(deliveries),
+		"data":    deliveries,
+	})
+}
+
+// subscription obtiene la suscripción de la ruta, verificando que pertenezca al cliente actual
+func (s *Server) subscription(w http.ResponseWriter, r *http.Request) (*repository.Subscription, bool) {
+	t := currentTenant(r)
+	id, ok := s.pathID(w, r, "id")
+	if !ok || !s.requireRepository(w, r) {
+		return nil, false
+	}
+
+	sub, err := s.subscriptions.GetSubscription(r.Context(), id)
+	if err == nil && sub.Tenant == t.ID {
+		return sub, true
+	}
+	if err == nil || errors.Is(err, repository.ErrNotFound) {
+		s.sendError(w, r, http.StatusNotFound, codeSubscription, nil, id)
+		return nil, false
+	}
+	s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+	return nil, false
+}
+
+// sendSubscriptionError responde el error al guardar una suscripción
+func (s *Server) sendSubscriptionError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, notify.ErrNoEncryptionKey) {
+		s.sendError(w, r, http.StatusServiceUnavailable, codeSubscriptionKey, err)
+		return
+	}
+	s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+}
+
+// applySubscriptionRequest valida y copia a la suscripción los campos presentes en la petición
+func applySubscriptionRequest(sub *repository.Subscription, req *subscriptionRequest) error {
+	if req.URL != nil {
+		parsed, err := url.Parse(strings.TrimSpace(*req.URL))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("url inválida: debe ser una URL http(s) absoluta")
+		}
+		sub.URL = parsed.String()
+	}
+	if req.Events != nil || sub.ID == 0 {
+		if err := notify.ValidateFilters(req.Events); err != nil {
+			return err
+		}
+		sub.Events = req.Events
+	}
+	if req.Description != nil {
+		sub.Description = *req.Description
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	switch {
+	case req.Secret != nil && len(*req.Secret) < 16:
+		return fmt.Errorf("el secreto debe tener al menos 16 caracteres")
+	case req.Secret != nil:
+		sub.Secret = *req.Secret
+	case req.RotateSecret:
+		sub.Secret = notify.GenerateSecret()
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+// requireEngine responde 503 si el motor de sincronización no está disponible
+func (s *Server) requireEngine(w http.ResponseWriter, r *http.Request, t *tenant.Tenant) bool {
+	if t.Engine == nil {
+		s.sendError(w, r, http.StatusServiceUnavailable, codeDatabaseMissing, nil)
+		return false
+	}
+	return true
+}
+
+// handleSync ejecuta un flujo de sincronización o calcula su plan
+// POST /api/v1/sync/{flow}                           -> encola una ejecución (202)
+// POST /api/v1/sync/{flow}?dry_run=true[&format=text] -> devuelve el plan sin escribir
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	flow := r.PathValue("flow")
+	if !s.requireEngine(w, r, t) {
+		return
+	}
+	if _, ok := t.Engine.Flow(flow); !ok {
+		s.sendError(w, r, http.StatusNotFound, codeUnknownFlow, map[string]interface{}{
+			"available_flows": t.Engine.Flows(),
+		}, flow)
+		return
+	}
+
+	if r.URL.Query().Get("dry_run") == "true" {
+		s.handleSyncPlan(w, r, flow)
+		return
+	}
+
+	run, err := t.Runner.Enqueue(r.Context(), t.ID, flow)
+	if err != nil {
+		if errors.Is(err, syncer.ErrQueueFull) {
+			s.sendError(w, r, http.StatusServiceUnavailable, codeQueueFull, err)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	w.Header().Set("Location", tenantPath(r, fmt.Sprintf("/api/v1/sync/runs/%d", run.ID)))
+	s.sendJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// handleSyncPlan responde con el plan del flujo sin escribir nada
+func (s *Server) handleSyncPlan(w http.ResponseWriter, r *http.Request, flow string) {
+	t := currentTenant(r)
+	plan, err := t.Engine.Plan(r.Context(), t.ID, flow)
+	if err != nil {
+		if errors.Is(err, syncer.ErrUnknownFlow) {
+			s.sendError(w, r, http.StatusNotFound, codeUnknownFlow, err, flow)
+			return
+		}
+		s.sendError(w, r, http.StatusBadGateway, codePlanFailed, err)
+		return
+	}
+	redactPlan(r, plan)
+
+	if r.URL.Query().Get("format") == "text" || strings.HasPrefix(r.Header.Get("Accept"), "text/plain") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, plan.Text())
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"dry_run": true,
+		"plan":    plan,
+		"diff":    plan.Text(),
+	})
+}
+
+// handleSyncRuns lista el historial de ejecuciones
+// GET /api/v1/sync/runs?flow=employees&status=failed&limit=50
+func (s *Server) handleSyncRuns(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	runs, err := s.repo.ListSyncRuns(r.Context(), repository.SyncRunFilter{
+		Tenant: t.ID,
+		Flow:   query.Get("flow"),
+		Status: query.Get("status"),
+		Limit:  limit,
+	})
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"count":   len(runs),
+		"data":    runs,
+	})
+}
+
+// handleSyncRunByID obtiene el detalle de una ejecución
+// GET /api/v1/sync/runs/{id}[?include_events=true]
+func (s *Server) handleSyncRunByID(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	id, ok := s.pathID(w, r, "id")
+	if !ok || !s.requireRepository(w, r) {
+		return
+	}
+
+	run, err := s.repo.GetSyncRun(r.Context(), id)
+	if err != nil || run.Tenant != t.ID {
+		s.sendRunLookupError(w, r, id, err)
+		return
+	}
+
+	// Errores por registro de esta ejecución
+	errorsLog, err := s.repo.ListEvents(r.Context(), repository.EventLogFilter{
+		RunID: &id,
+		Level: repository.LevelError,
+		Limit: 1000,
+	})
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"data":    run,
+		"errors":  errorsLog,
+	}
+	if run.StartedAt != nil && run.FinishedAt != nil {
+		response["duration_ms"] = run.FinishedAt.Sub(*run.StartedAt).Milliseconds()
+	}
+
+	if r.URL.Query().Get("include_events") == "true" {
+		events, err := s.repo.ListEvents(r.Context(), repository.EventLogFilter{RunID: &id, Limit: 1000})
+		if err != nil {
+			s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+			return
+		}
+		response["events"] = events
+	}
+
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// handleCancelSyncRun cancela una ejecución pendiente o en curso
+// POST /api/v1/sync/runs/{id}/cancel
+func (s *Server) handleCancelSyncRun(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	id, ok := s.pathID(w, r, "id")
+	if !ok || !s.requireEngine(w, r, t) {
+		return
+	}
+
+	run, err := s.repo.GetSyncRun(r.Context(), id)
+	if err != nil || run.Tenant != t.ID {
+		s.sendRunLookupError(w, r, id, err)
+		return
+	}
+
+	if err := t.Runner.Cancel(r.Context(), id); err != nil {
+		if errors.Is(err, syncer.ErrRunNotActive) {
+			s.sendError(w, r, http.StatusConflict, codeRunNotActive, err, id)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	s.sendJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Cancelación solicitada para la ejecución %d", id),
+	})
+}
+
+// sendRunLookupError responde al fallar la búsqueda de una ejecución
+func (s *Server) sendRunLookupError(w http.ResponseWriter, r *http.Request, id int64, err error) {
+	if err == nil || errors.Is(err, repository.ErrNotFound) {
+		s.sendError(w, r, http.StatusNotFound, codeRunNotFound, nil, id)
+		return
+	}
+	s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+}
@@ -0,0 +1,384 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/auth"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+// tenantPrefix es el prefijo de ruta que selecciona un tenant: /t/{tenant}/api/v1/...
+const tenantPrefix = "/t/"
+
+type tenantKey struct{}
+
+// tenantSelection es el tenant que atiende la petición y cómo se eligió
+type tenantSelection struct {
+	tenant   *tenant.Tenant
+	explicit bool   // Indicado por ruta o por X-Tenant-ID
+	prefix   string // "/t/{tenant}" si se eligió por ruta, para armar los enlaces de la respuesta
+}
+
+// tenantMiddleware selecciona el tenant de la petición, en este orden:
+//  1. el prefijo de ruta /t/{tenant}/ (se quita antes de enrutar)
+//  2. el header X-Tenant-ID
+//  3. el tenant de la credencial (clave de API o claim tenant del JWT), que resuelve authMiddleware
+//  4. el tenant por defecto
+func (s *Server) tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selection := &tenantSelection{}
+		id := ""
+		if rest, ok := strings.CutPrefix(r.URL.Path, tenantPrefix); ok {
+			var path string
+			id, path, _ = strings.Cut(rest, "/")
+			if id == "" {
+				s.sendError(w, r, http.StatusNotFound, codeNotFound, nil)
+				return
+			}
+			selection.prefix = tenantPrefix + id
+			r = stripTenantPrefix(r, "/"+path)
+		} else {
+			id = strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
+		}
+
+		if id == "" {
+			selection.tenant = s.tenants.Default()
+		} else {
+			t, ok := s.tenants.Get(id)
+			if !ok {
+				s.sendError(w, r, http.StatusNotFound, codeTenantUnknown, nil, id)
+				return
+			}
+			selection.tenant = t
+			selection.explicit = true
+		}
+		if !s.requireEnabledTenant(w, r, selection.tenant) {
+			return
+		}
+
+		traceAttribute(r, "tenant", selection.tenant.ID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, selection)))
+	})
+}
+
+// stripTenantPrefix devuelve la petición con la ruta sin el prefijo /t/{tenant}
+func stripTenantPrefix(r *http.Request, path string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = path
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
+
+// requireEnabledTenant responde 403 si el tenant está deshabilitado
+func (s *Server) requireEnabledTenant(w http.ResponseWriter, r *http.Request, t *tenant.Tenant) bool {
+	if !t.Enabled() {
+		s.sendError(w, r, http.StatusForbidden, codeTenantDisabled, nil, t.ID)
+		return false
+	}
+	return true
+}
+
+// bindTenant aplica el tenant de la credencial a la petición
+// Sin tenant explícito se usa el de la credencial; con uno explícito, la credencial debe pertenecer a él,
+// salvo las credenciales admin del tenant por defecto, que operan todos los tenants
+func (s *Server) bindTenant(w http.ResponseWriter, r *http.Request, principal *auth.Principal) (*http.Request, bool) {
+	selection := tenantSelectionFrom(r.Context())
+	current := selection.tenant
+	switch {
+	case principal.Tenant == current.ID:
+		return r, true
+
+	case !selection.explicit:
+		t, ok := s.tenants.Get(principal.Tenant)
+		if !ok {
+			logger.WarnContext(r.Context(), "🚫 Credencial de un tenant inexistente", "auth_method", principal.Method, "principal", principal.Name, "tenant", principal.Tenant)
+			s.sendError(w, r, http.StatusForbidden, codeTenantOrphan, nil)
+			return nil, false
+		}
+		if !s.requireEnabledTenant(w, r, t) {
+			return nil, false
+		}
+		auditTenant(r, t.ID)
+		traceAttribute(r, "tenant", t.ID)
+		bound := &tenantSelection{tenant: t, prefix: selection.prefix}
+		return r.WithContext(context.WithValue(r.Context(), tenantKey{}, bound)), true
+
+	case principal.Tenant == s.tenants.DefaultID() && principal.HasScope(auth.ScopeAdmin):
+		return r, true
+	}
+
+	logger.WarnContext(r.Context(), "🚫 Credencial sin acceso al tenant", "auth_method", principal.Method, "principal", principal.Name,
+		"principal_tenant", principal.Tenant, "tenant", current.ID)
+	s.sendError(w, r, http.StatusForbidden, codeTenantDenied, nil)
+	return nil, false
+}
+
+// tenantSelectionFrom devuelve la selección de tenant de la petición
+func tenantSelectionFrom(ctx context.Context) *tenantSelection {
+	selection, _ := ctx.Value(tenantKey{}).(*tenantSelection)
+	return selection
+}
+
+// currentTenant devuelve el tenant que atiende la petición
+func currentTenant(r *http.Request) *tenant.Tenant {
+	return tenantSelectionFrom(r.Context()).tenant
+}
+
+// tenantPath antepone a una ruta de la API el prefijo /t/{tenant} si la petición lo usó
+func tenantPath(r *http.Request, path string) string {
+	if selection := tenantSelectionFrom(r.Context()); selection != nil {
+		return selection.prefix + path
+	}
+	return path
+}
+
+// tenantView es un tenant en las respuestas de la API; los secretos se serializan enmascarados
+type tenantView struct {
+	*tenant.Config
+	// Managed indica que se creó por la API; los de TENANTS_FILE o del entorno son de solo lectura
+	Managed bool `json:"managed"`
+}
+
+func newTenantView(t *tenant.Tenant) *tenantView {
+	return &tenantView{Config: t.Config, Managed: t.Managed}
+}
+
+// requireOperator exige una credencial del tenant por defecto: los admin de un cliente no administran
+// a los demás ni la configuración del servicio
+func (s *Server) requireOperator(w http.ResponseWriter, r *http.Request) bool {
+	if principal := auth.FromContext(r.Context()); principal == nil || principal.Tenant != s.tenants.DefaultID() {
+		s.sendError(w, r, http.StatusForbidden, codeOperatorRequired, nil)
+		return false
+	}
+	return true
+}
+
+// handleListTenants lista los tenants
+// GET /api/v1/admin/tenants
+func (s *Server) handleListTenants(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOperator(w, r) {
+		return
+	}
+
+	tenants := s.tenants.List()
+	views := make([]*tenantView, 0, len(tenants))
+	for _, t := range tenants {
+		views = append(views, newTenantView(t))
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"default": s.tenants.DefaultID(),
+		"count":   len(views),
+		"data":    views,
+	})
+}
+
+// handleCreateTenant crea un tenant
+// POST /api/v1/admin/tenants  {"id": "acme", "name": "ACME", "odoo": {...}, "quickpass": {...}}
+func (s *Server) handleCreateTenant(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOperator(w, r) {
+		return
+	}
+
+	var config tenant.Config
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return
+	}
+	if !s.checkTenantConfig(w, r, &config) {
+		return
+	}
+
+	t, err := s.tenants.Create(r.Context(), &config)
+	if err != nil {
+		s.sendTenantError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/api/v1/admin/tenants/"+t.ID)
+	s.sendJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    newTenantView(t),
+	})
+}
+
+// handleGetTenant consulta un tenant
+// GET /api/v1/admin/tenants/{id}
+func (s *Server) handleGetTenant(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.managedTenant(w, r)
+	if !ok {
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    newTenantView(current),
+	})
+}
+
+// handleReplaceTenant reemplaza la configuración de un tenant; los secretos vacíos o "***" no cambian
+// PUT /api/v1/admin/tenants/{id}
+func (s *Server) handleReplaceTenant(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.managedTenant(w, r)
+	if !ok {
+		return
+	}
+
+	var config tenant.Config
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return
+	}
+	if config.ID == "" {
+		config.ID = current.ID
+	}
+	if config.ID != current.ID {
+		s.sendError(w, r, http.StatusBadRequest, codeTenantIDMismatch, nil)
+		return
+	}
+	config.KeepSecrets(current.Config)
+	if !s.checkTenantConfig(w, r, &config) {
+		return
+	}
+	s.updateTenant(w, r, &config)
+}
+
+// handleDeleteTenant elimina la configuración de un tenant (los datos sincronizados se conservan)
+// DELETE /api/v1/admin/tenants/{id}
+func (s *Server) handleDeleteTenant(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.managedTenant(w, r)
+	if !ok {
+		return
+	}
+	if err := s.tenants.Delete(r.Context(), current.ID); err != nil {
+		s.sendTenantError(w, r, err)
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Tenant %s eliminado", current.ID),
+	})
+}
+
+// handleVerifyTenant prueba las credenciales guardadas sin cambiar nada
+// POST /api/v1/admin/tenants/{id}/verify
+func (s *Server) handleVerifyTenant(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.managedTenant(w, r)
+	if !ok {
+		return
+	}
+	if err := tenant.Verify(r.Context(), current.Config); err != nil {
+		s.sendTenantError(w, r, err)
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Credenciales de Odoo y Quickpass válidas",
+	})
+}
+
+// handleDisableTenant deja de atender peticiones y de sincronizar un tenant
+// POST /api/v1/admin/tenants/{id}/disable
+func (s *Server) handleDisableTenant(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.managedTenant(w, r)
+	if !ok {
+		return
+	}
+	config := *current.Config
+	config.Disabled = true
+	s.updateTenant(w, r, &config)
+}
+
+// handleEnableTenant verifica las credenciales de un tenant y lo vuelve a poner en marcha
+// POST /api/v1/admin/tenants/{id}/enable
+func (s *Server) handleEnableTenant(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.managedTenant(w, r)
+	if !ok {
+		return
+	}
+	config := *current.Config
+	config.Disabled = false
+	if !s.verifyTenantCredentials(w, r, &config) {
+		return
+	}
+	s.updateTenant(w, r, &config)
+}
+
+// managedTenant exige una credencial de operador y obtiene el tenant de la ruta
+func (s *Server) managedTenant(w http.ResponseWriter, r *http.Request) (*tenant.Tenant, bool) {
+	if !s.requireOperator(w, r) {
+		return nil, false
+	}
+	id := r.PathValue("id")
+	current, ok := s.tenants.Get(id)
+	if !ok {
+		s.sendError(w, r, http.StatusNotFound, codeTenantNotFound, nil, id)
+		return nil, false
+	}
+	return current, true
+}
+
+// updateTenant guarda la configuración y reemplaza el tenant en el registro
+func (s *Server) updateTenant(w http.ResponseWriter, r *http.Request, config *tenant.Config) {
+	t, err := s.tenants.Update(r.Context(), config)
+	if err != nil {
+		s.sendTenantError(w, r, err)
+		return
+	}
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    newTenantView(t),
+	})
+}
+
+// checkTenantConfig valida la configuración y, si el tenant queda habilitado, sus credenciales
+func (s *Server) checkTenantConfig(w http.ResponseWriter, r *http.Request, config *tenant.Config) bool {
+	if err := s.tenants.Manageable(); err != nil {
+		s.sendTenantError(w, r, err)
+		return false
+	}
+	if config.Name == "" {
+		config.Name = config.ID
+	}
+	if err := config.Validate(); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeValidationFailed, err)
+		return false
+	}
+	if config.Disabled {
+		return true
+	}
+	return s.verifyTenantCredentials(w, r, config)
+}
+
+// verifyTenantCredentials autentica con Odoo y Quickpass antes de guardar; responde 422 si fallan
+func (s *Server) verifyTenantCredentials(w http.ResponseWriter, r *http.Request, config *tenant.Config) bool {
+	if err := tenant.Verify(r.Context(), config); err != nil {
+		logger.WarnContext(r.Context(), "🚫 Credenciales del tenant rechazadas", "tenant", config.ID, "error", err)
+		s.sendTenantError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// sendTenantError responde con el código que corresponde a un error de la administración de tenants
+func (s *Server) sendTenantError(w http.ResponseWriter, r *http.Request, err error) {
+	var credentials *tenant.CredentialError
+	switch {
+	case errors.As(err, &credentials):
+		s.sendError(w, r, http.StatusUnprocessableEntity, codeTenantCredentials, credentials)
+	case errors.Is(err, tenant.ErrNotFound):
+		s.sendError(w, r, http.StatusNotFound, codeTenantNotFound, err, r.PathValue("id"))
+	case errors.Is(err, tenant.ErrExists), errors.Is(err, tenant.ErrStatic):
+		s.sendError(w, r, http.StatusConflict, codeTenantConflict, err)
+	case errors.Is(err, tenant.ErrNotManaged):
+		s.sendError(w, r, http.StatusServiceUnavailable, codeTenantReadOnly, err)
+	default:
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+	}
+}
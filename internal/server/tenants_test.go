@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/config"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+)
+
+// newTenantTestServer crea un servidor iniciado cuyo Odoo y Quickpass aceptan cualquier credencial
+func newTenantTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jsonrpc":
+			// authenticate devuelve el UID y search_count la cantidad de empleados
+			fmt.Fprint(w, `{"jsonrpc": "2.0", "id": 1, "result": 2}`)
+		case "/api/v1/users":
+			fmt.Fprint(w, `{"data": [], "next_page": 0}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	for name, value := range map[string]string{
+		"CONFIG_FILE":            "",
+		"TENANTS_FILE":           "",
+		"ODOO_URL":               upstream.URL,
+		"ODOO_DATABASE":          "default",
+		"ODOO_USERNAME":          "admin",
+		"ODOO_PASSWORD":          "secret",
+		"QUICKPASS_URL":          upstream.URL,
+		"QUICKPASS_API_KEY":      "qp-key",
+		"API_KEY":                "operator-key",
+		"TENANTS_ENCRYPTION_KEY": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+	} {
+		t.Setenv(name, value)
+	}
+	if err := logging.Setup(io.Discard, "text"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenants, err := tenant.Load("", &cfg.Webhooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repository.NewSQLite(filepath.Join(t.TempDir(), "sync.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.Migrator().Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := NewServer(config.NewReloader(cfg, nil), tenants, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.tenants.Start()
+	t.Cleanup(func() { srv.tenants.Shutdown(context.Background()) })
+	return srv, upstream.URL
+}
+
+// send envía una petición con la clave de operador y devuelve el código y el cuerpo decodificado
+func send(t *testing.T, handler http.Handler, method, target, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-API-Key", "operator-key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var decoded map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &decoded)
+	return rec.Code, decoded
+}
+
+// TestTenantHandlers recorre el ciclo de vida de un tenant por la API y verifica el efecto de cada
+// operación en el registro: que quede en marcha, que conserve los secretos, que se detenga y que se elimine
+func TestTenantHandlers(t *testing.T) {
+	srv, upstream := newTenantTestServer(t)
+	handler := srv.handler()
+	config := func(id, name, password string) string {
+		return fmt.Sprintf(`{"id": %q, "name": %q,
+			"odoo": {"url": %q, "database": %q, "username": "admin", "password": %q},
+			"quickpass": {"url": %q, "api_key": "qp-key"}}`, id, name, upstream, id, password, upstream)
+	}
+
+	// Alta: el tenant queda guardado con los secretos cifrados y en marcha
+	status, body := send(t, handler, "POST", "/api/v1/admin/tenants", config("acme", "ACME", "odoo-secret"))
+	if status != http.StatusCreated {
+		t.Fatalf("alta: código %d: %v", status, body)
+	}
+	if password := body["data"].(map[string]interface{})["odoo"].(map[string]interface{})["password"]; password != "***" {
+		t.Errorf("alta: la respuesta muestra la contraseña de Odoo: %v", password)
+	}
+	created, ok := srv.tenants.Get("acme")
+	if !ok || !created.Enabled() || !created.Managed {
+		t.Fatalf("alta: el tenant no quedó habilitado en el registro: %+v", created)
+	}
+	if err := created.Runner.Health(); err != nil {
+		t.Errorf("alta: el tenant no quedó en marcha: %v", err)
+	}
+	record, err := srv.repo.GetTenant(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(record.Config+record.Secrets, "odoo-secret") {
+		t.Error("alta: la contraseña de Odoo se guardó sin cifrar")
+	}
+	if status, _ := send(t, handler, "GET", "/t/acme/api/v1/sync/runs", ""); status != http.StatusOK {
+		t.Errorf("alta: el tenant no atiende peticiones: código %d", status)
+	}
+
+	// Cambio: "***" conserva el secreto guardado; el tenant anterior se detiene y el nuevo queda en marcha
+	status, body = send(t, handler, "PUT", "/api/v1/admin/tenants/acme", config("acme", "ACME S.A.", "***"))
+	if status != http.StatusOK {
+		t.Fatalf("cambio: código %d: %v", status, body)
+	}
+	updated, _ := srv.tenants.Get("acme")
+	if updated == created || updated.Name != "ACME S.A." {
+		t.Fatalf("cambio: el registro no tiene la nueva configuración: %+v", updated.Config)
+	}
+	if updated.Config.Odoo.Password.Reveal() != "odoo-secret" {
+		t.Error("cambio: no se conservó la contraseña de Odoo")
+	}
+	if err := created.Runner.Health(); err == nil {
+		t.Error("cambio: el tenant anterior sigue en marcha")
+	}
+	if err := updated.Runner.Health(); err != nil {
+		t.Errorf("cambio: el tenant nuevo no quedó en marcha: %v", err)
+	}
+
+	// Deshabilitar: deja de atender peticiones y de sincronizar
+	if status, body := send(t, handler, "POST", "/api/v1/admin/tenants/acme/disable", ""); status != http.StatusOK {
+		t.Fatalf("deshabilitar: código %d: %v", status, body)
+	}
+	disabled, _ := srv.tenants.Get("acme")
+	if disabled.Enabled() {
+		t.Error("deshabilitar: el tenant sigue habilitado")
+	}
+	if err := updated.Runner.Health(); err == nil {
+		t.Error("deshabilitar: el tenant sigue en marcha")
+	}
+	if status, _ := send(t, handler, "GET", "/t/acme/api/v1/sync/runs", ""); status != http.StatusForbidden {
+		t.Errorf("deshabilitar: código %d, se esperaba %d", status, http.StatusForbidden)
+	}
+
+	// Baja: se quita del registro y de la base de datos
+	if status, body := send(t, handler, "DELETE", "/api/v1/admin/tenants/acme", ""); status != http.StatusOK {
+		t.Fatalf("baja: código %d: %v", status, body)
+	}
+	if _, ok := srv.tenants.Get("acme"); ok {
+		t.Error("baja: el tenant sigue en el registro")
+	}
+	if _, err := srv.repo.GetTenant(context.Background(), "acme"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("baja: GetTenant() = %v, se esperaba %v", err, repository.ErrNotFound)
+	}
+	if status, _ := send(t, handler, "GET", "/t/acme/api/v1/sync/runs", ""); status != http.StatusNotFound {
+		t.Errorf("baja: código %d, se esperaba %d", status, http.StatusNotFound)
+	}
+
+	// Los tenants del entorno o de TENANTS_FILE no se modifican por la API
+	tests := []struct {
+		method, target, body string
+	}{
+		{"PUT", "/api/v1/admin/tenants/default", config("default", "Otro", "secret")},
+		{"POST", "/api/v1/admin/tenants/default/disable", ""},
+		{"DELETE", "/api/v1/admin/tenants/default", ""},
+	}
+	for _, tt := range tests {
+		if status, body := send(t, handler, tt.method, tt.target, tt.body); status != http.StatusConflict {
+			t.Errorf("%s %s: código %d, se esperaba %d: %v", tt.method, tt.target, status, http.StatusConflict, body)
+		}
+	}
+	if current, _ := srv.tenants.Get(tenant.DefaultID); !current.Enabled() {
+		t.Error("el tenant por defecto quedó deshabilitado")
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tracing"
+)
+
+var tracer = tracing.Tracer("server")
+
+// tracingMiddleware abre un span por petición que continúa la traza del traceparent recibido
+// Las llamadas a Odoo y Quickpass que haga la petición quedan como spans hijos
+func (s *Server) tracingMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routePattern(mux, r)
+		name := r.Method + " " + route
+		if route == "unmatched" {
+			name = r.Method
+		}
+		ctx, span := tracer.Start(tracing.Extract(r.Context(), r.Header), name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		recorder := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// traceAttribute agrega un atributo al span de la petición (ej: el tenant que la atiende)
+func traceAttribute(r *http.Request, key, value string) {
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String(key, value))
+}
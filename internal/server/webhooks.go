@@ -0,0 +1,401 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tenant"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/webhook"
+)
+
+// maxWebhookBody es el tamaño máximo aceptado para el cuerpo de un webhook
+const maxWebhookBody = 1 << 20 // 1 MB
+
+// odooWebhookFlows indica qué flujo sincroniza los cambios de cada modelo de Odoo
+// Los contratos afectan el estado del empleado (ej: término de contrato → archivado).
+// Las ausencias las publica el flujo time_off a los suscriptores como leave.updated
+var odooWebhookFlows = map[string]string{
+	"hr.employee": syncer.FlowEmployees,
+	"hr.contract": syncer.FlowEmployees,
+	"hr.leave":    syncer.FlowTimeOff,
+}
+
+// odooNotifyEvents indica qué evento se publica a los suscriptores al cambiar cada modelo de Odoo
+// Los cambios de hr.employee y hr.leave se publican al sincronizarse, solo si realmente cambiaron datos
+var odooNotifyEvents = map[string]string{
+	"hr.contract": notify.ContractUpdated,
+}
+
+// handleOdooWebhook recibe notificaciones de cambios desde acciones automatizadas de Odoo
+// y encola una sincronización de los empleados afectados
+// POST /webhooks/odoo
+func (s *Server) handleOdooWebhook(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if t.Webhooks == nil || t.Webhooks.OdooSecret.IsZero() {
+		s.sendError(w, r, http.StatusServiceUnavailable, codeWebhookDisabled, map[string]interface{}{
+			"settings": []string{"WEBHOOK_SECRET", "webhooks.odoo_secret"},
+		}, "Odoo", t.ID)
+		return
+	}
+
+	body, ok := s.readWebhookBody(w, r)
+	if !ok {
+		return
+	}
+
+	if !s.verifyOdooWebhook(r, body) {
+		logger.WarnContext(r.Context(), "🚫 Webhook de Odoo rechazado: secreto o firma inválidos", "tenant", t.ID, "remote_addr", r.RemoteAddr)
+		s.sendError(w, r, http.StatusUnauthorized, codeWebhookSignature, nil)
+		return
+	}
+
+	if !s.requireEngine(w, r, t) {
+		return
+	}
+
+	event, err := webhook.ParseOdooEvent(body, r.Header.Get("X-Odoo-Event-Id"))
+	if err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeWebhookPayload, err)
+		return
+	}
+
+	record, duplicate, err := s.recordWebhookEvent(r.Context(), t.ID, webhook.SourceOdoo, event.ID, event.Model, body)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+	if duplicate {
+		s.sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success":   true,
+			"duplicate": true,
+			"event_id":  event.ID,
+			"status":    record.Status,
+		})
+		return
+	}
+
+	run, err := s.dispatchOdooEvent(r.Context(), t, event)
+	processedAt := time.Now().UTC()
+	record.ProcessedAt = &processedAt
+	record.Attempts++
+	switch {
+	case err != nil:
+		record.Status = repository.WebhookFailed
+		record.Error = err.Error()
+	case run == nil:
+		record.Status = repository.WebhookIgnored
+	default:
+		record.Status = repository.WebhookProcessed
+		record.Error = ""
+	}
+	if err := s.repo.UpdateWebhookEvent(context.WithoutCancel(r.Context()), record); err != nil {
+		logger.WarnContext(r.Context(), "⚠️ Error actualizando evento de webhook", "tenant", record.Tenant, "event", record.ID, "error", err)
+	}
+
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, syncer.ErrQueueFull) {
+			status = http.StatusServiceUnavailable
+		}
+		s.sendError(w, r, status, codeWebhookFailed, map[string]interface{}{
+			"reason":   err.Error(),
+			"event_id": event.ID,
+		})
+		return
+	}
+
+	if run == nil {
+		s.sendJSON(w, http.StatusOK, map[string]interface{}{
+			"success":  true,
+			"event_id": event.ID,
+			"status":   record.Status,
+			"message":  fmt.Sprintf("Sin sincronización para el modelo %s", event.Model),
+		})
+		return
+	}
+
+	w.Header().Set("Location", tenantPath(r, fmt.Sprintf("/api/v1/sync/runs/%d", run.ID)))
+	s.sendJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success":  true,
+		"event_id": event.ID,
+		"status":   record.Status,
+		"data":     run,
+	})
+}
+
+// verifyOdooWebhook valida el webhook con alguno de estos mecanismos (comparación en tiempo constante):
+//   - X-Webhook-Signature: HMAC-SHA256 del cuerpo con WEBHOOK_SECRET ("sha256=<hex>")
+//   - X-Webhook-Secret: el secreto compartido
+//   - ?token=: el secreto compartido, para la acción "Enviar notificación webhook" de Odoo,
+//     que no permite agregar headers
+func (s *Server) verifyOdooWebhook(r *http.Request, body []byte) bool {
+	secret := currentTenant(r).Webhooks.OdooSecret.Reveal()
+	if signature := r.Header.Get("X-Webhook-Signature"); signature != "" {
+		return webhook.VerifySignature(secret, body, signature)
+	}
+	if provided := r.Header.Get("X-Webhook-Secret"); provided != "" {
+		return webhook.Equal(secret, provided)
+	}
+	return webhook.Equal(secret, r.URL.Query().Get("token"))
+}
+
+// readWebhookBody lee el cuerpo completo del webhook (necesario para verificar la firma)
+func (s *Server) readWebhookBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			s.sendError(w, r, http.StatusRequestEntityTooLarge, codePayloadTooLarge, err)
+			return nil, false
+		}
+		s.sendError(w, r, http.StatusBadRequest, codeInvalidBody, err)
+		return nil, false
+	}
+	return body, true
+}
+
+// recordWebhookEvent guarda el evento antes de procesarlo
+// Devuelve duplicate=true si el evento ya se había recibido y no falló; los fallidos se reprocesan
+func (s *Server) recordWebhookEvent(ctx context.Context, tenant, source, eventID, eventType string, body []byte) (*repository.WebhookEvent, bool, error) {
+	record := &repository.WebhookEvent{
+		Source:    source,
+		Tenant:    tenant,
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   body,
+	}
+	created, err := s.repo.CreateWebhookEvent(ctx, record)
+	if err != nil || created {
+		return record, false, err
+	}
+
+	existing, err := s.repo.GetWebhookEventByEventID(ctx, source, record.Tenant, eventID)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing.Status == repository.WebhookFailed {
+		logger.InfoContext(ctx, "🔁 Reprocesando evento que había fallado", "tenant", tenant, "source", source, "event_id", eventID)
+		return existing, false, nil
+	}
+	logger.InfoContext(ctx, "♻️ Evento duplicado, se ignora", "tenant", tenant, "source", source, "event_id", eventID)
+	return existing, true, nil
+}
+
+// dispatchOdooEvent encola la sincronización dirigida de los empleados o ausencias afectados por el evento
+// Devuelve nil si el modelo no tiene un flujo asociado
+func (s *Server) dispatchOdooEvent(ctx context.Context, t *tenant.Tenant, event *webhook.OdooEvent) (*repository.SyncRun, error) {
+	// Los contratos no se sincronizan con Quickpass, pero sí interesan a otros sistemas
+	if eventType, ok := odooNotifyEvents[event.Model]; ok {
+		t.Engine.Notify(ctx, t.ID, eventType, map[string]interface{}{
+			"model":        event.Model,
+			"ids":          event.RecordIDs,
+			"employee_ids": event.EmployeeIDs,
+		})
+	}
+
+	flow, ok := odooWebhookFlows[event.Model]
+	if !ok {
+		logger.InfoContext(ctx, "ℹ️ Webhook de Odoo ignorado: modelo no soportado", "tenant", t.ID, "model", event.Model)
+		return nil, nil
+	}
+	if _, ok := t.Engine.Flow(flow); !ok {
+		logger.InfoContext(ctx, "ℹ️ Webhook de Odoo ignorado: el flujo no está disponible", "tenant", t.ID, "model", event.Model, "flow", flow)
+		return nil, nil
+	}
+
+	if flow == syncer.FlowTimeOff {
+		// El flujo de ausencias recibe los IDs de hr.leave, no los de los empleados
+		logger.InfoContext(ctx, "🪝 Webhook de Odoo: sincronización dirigida", "tenant", t.ID, "model", event.Model, "ids", event.RecordIDs, "flow", flow)
+		return t.Runner.EnqueueTargets(ctx, t.ID, flow, event.RecordIDs)
+	}
+
+	employeeIDs := event.RecordIDs
+	if event.Model != "hr.employee" {
+		employeeIDs = event.EmployeeIDs
+		if len(employeeIDs) == 0 {
+			// El payload no trae employee_id: se lee desde Odoo
+			if t.Odoo == nil {
+				return nil, fmt.Errorf("cliente Odoo no configurado")
+			}
+			if t.Odoo.UID() == 0 {
+				if err := t.Odoo.Authenticate(ctx); err != nil {
+					return nil, fmt.Errorf("error autenticando con Odoo: %w", err)
+				}
+			}
+			ids, err := odoo.NewEmployeeService(t.Odoo).GetRelatedEmployeeIDs(ctx, event.Model, event.RecordIDs)
+			if err != nil {
+				return nil, err
+			}
+			employeeIDs = ids
+		}
+	}
+	if len(employeeIDs) == 0 {
+		logger.InfoContext(ctx, "ℹ️ Webhook de Odoo sin empleados asociados", "tenant", t.ID, "model", event.Model, "ids", event.RecordIDs)
+		return nil, nil
+	}
+
+	logger.InfoContext(ctx, "🪝 Webhook de Odoo: sincronización dirigida", "tenant", t.ID, "model", event.Model, "ids", event.RecordIDs,
+		"flow", flow, "employee_ids", employeeIDs)
+	return t.Runner.EnqueueTargets(ctx, t.ID, flow, employeeIDs)
+}
+
+// handleQuickpassWebhook recibe eventos de Quickpass (marcaciones, solicitudes de tiempo libre,
+// cambios de usuario). El evento se guarda antes de responder y se procesa en segundo plano
+// POST /webhooks/quickpass
+func (s *Server) handleQuickpassWebhook(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if t.Webhooks == nil || t.Webhooks.QuickpassSecret.IsZero() {
+		s.sendError(w, r, http.StatusServiceUnavailable, codeWebhookDisabled, map[string]interface{}{
+			"settings": []string{"QUICKPASS_WEBHOOK_SECRET", "webhooks.quickpass_secret"},
+		}, "Quickpass", t.ID)
+		return
+	}
+
+	body, ok := s.readWebhookBody(w, r)
+	if !ok {
+		return
+	}
+
+	// La firma cubre "<timestamp>.<cuerpo>" y el timestamp debe estar dentro de la tolerancia
+	err := webhook.VerifyTimestamped(t.Webhooks.QuickpassSecret.Reveal(), r.Header.Get("X-Quickpass-Timestamp"), body,
+		r.Header.Get("X-Quickpass-Signature"), t.Webhooks.Tolerance, time.Now())
+	if err != nil {
+		logger.WarnContext(r.Context(), "🚫 Webhook de Quickpass rechazado", "tenant", t.ID, "remote_addr", r.RemoteAddr, "error", err)
+		s.sendError(w, r, http.StatusUnauthorized, codeWebhookSignature, err)
+		return
+	}
+
+	if !s.requireEngine(w, r, t) {
+		return
+	}
+
+	event, err := webhook.ParseQuickpassEvent(body)
+	if err != nil {
+		s.sendError(w, r, http.StatusBadRequest, codeWebhookPayload, err)
+		return
+	}
+
+	record, duplicate, err := s.recordWebhookEvent(r.Context(), t.ID, webhook.SourceQuickpass, event.ID, event.Type, body)
+	if err != nil {
+		// Sin persistir no se confirma: Quickpass reintentará el envío
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"event_id":  event.ID,
+		"duplicate": duplicate,
+		"status":    record.Status,
+	}
+	if duplicate {
+		s.sendJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if record.Status == repository.WebhookFailed {
+		record.Status = repository.WebhookReceived
+		if err := s.repo.UpdateWebhookEvent(r.Context(), record); err != nil {
+			s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+			return
+		}
+		response["status"] = record.Status
+	}
+	t.Processor.Submit(record)
+	s.sendJSON(w, http.StatusAccepted, response)
+}
+
+// handleWebhookEvents lista los eventos recibidos por webhook
+// GET /api/v1/admin/webhook-events?source=quickpass&status=failed&limit=50
+func (s *Server) handleWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	if !s.requireRepository(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	events, err := s.repo.ListWebhookEvents(r.Context(), repository.WebhookEventFilter{
+		Tenant: t.ID,
+		Source: query.Get("source"),
+		Status: query.Get("status"),
+		Limit:  limit,
+	})
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	s.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"count":   len(events),
+		"data":    events,
+	})
+}
+
+// handleReplayWebhookEvent vuelve a procesar un evento guardado
+// POST /api/v1/admin/webhook-events/{id}/replay
+func (s *Server) handleReplayWebhookEvent(w http.ResponseWriter, r *http.Request) {
+	t := currentTenant(r)
+	id, ok := s.pathID(w, r, "id")
+	if !ok || !s.requireEngine(w, r, t) {
+		return
+	}
+
+	record, err := s.repo.GetWebhookEvent(r.Context(), id)
+	if err != nil || record.Tenant != t.ID {
+		if err == nil || errors.Is(err, repository.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, codeWebhookEvent, nil, id)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	record.Status = repository.WebhookReceived
+	record.Error = ""
+	if err := s.repo.UpdateWebhookEvent(r.Context(), record); err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, codeInternal, err)
+		return
+	}
+
+	switch record.Source {
+	case webhook.SourceQuickpass:
+		t.Processor.Submit(record)
+	case webhook.SourceOdoo:
+		var run *repository.SyncRun
+		event, err := webhook.ParseOdooEvent(record.Payload, record.EventID)
+		if err == nil {
+			run, err = s.dispatchOdooEvent(r.Context(), t, event)
+		}
+		processedAt := time.Now().UTC()
+		record.ProcessedAt = &processedAt
+		record.Attempts++
+		switch {
+		case err != nil:
+			record.Status = repository.WebhookFailed
+			record.Error = err.Error()
+		case run == nil:
+			record.Status = repository.WebhookIgnored
+		default:
+			record.Status = repository.WebhookProcessed
+		}
+		if err := s.repo.UpdateWebhookEvent(r.Context(), record); err != nil {
+			logger.WarnContext(r.Context(), "⚠️ Error actualizando evento de webhook", "tenant", record.Tenant, "event", record.ID, "error", err)
+		}
+	}
+
+	s.sendJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"data":    record,
+	})
+}
@@ -0,0 +1,341 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// FlowAttendance es el nombre del flujo de asistencias Quickpass → Odoo
+const FlowAttendance = "attendance"
+
+// punchEntity es la entidad usada en id_mappings para las marcaciones ya registradas
+// Entrada y salida apuntan a la misma asistencia, por eso cada tipo tiene su propia entidad
+func punchEntity(punchType string) string {
+	return "punch." + punchType
+}
+
+// attendanceLookback es cuánto se mira hacia atrás en la primera ejecución
+const attendanceLookback = 24 * time.Hour
+
+// AttendancePayload es una marcación lista para registrar en Odoo
+// Es el contenido que se guarda en la cola de fallidos y que un administrador puede editar
+type AttendancePayload struct {
+	PunchID         string    `json:"punch_id"`
+	QuickpassUserID string    `json:"quickpass_user_id"`
+	OdooEmployeeID  int       `json:"odoo_employee_id,omitempty"`
+	Type            string    `json:"type"` // check_in | check_out
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// AttendanceEvent es el contenido del evento attendance.recorded que reciben los suscriptores
+type AttendanceEvent struct {
+	AttendanceID    int       `json:"attendance_id"`
+	EmployeeID      int       `json:"employee_id"`
+	QuickpassUserID string    `json:"quickpass_user_id"`
+	PunchID         string    `json:"punch_id"`
+	Type            string    `json:"type"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// newAttendanceEvent arma el evento de una marcación registrada
+func newAttendanceEvent(attendanceID int, payload *AttendancePayload) *AttendanceEvent {
+	return &AttendanceEvent{
+		AttendanceID:    attendanceID,
+		EmployeeID:      payload.OdooEmployeeID,
+		QuickpassUserID: payload.QuickpassUserID,
+		PunchID:         payload.PunchID,
+		Type:            payload.Type,
+		Timestamp:       payload.Timestamp,
+	}
+}
+
+// attendanceState es la información del plan necesaria para aplicarlo
+type attendanceState struct {
+	watermark time.Time
+}
+
+// AttendanceFlow registra en hr.attendance las marcaciones de Quickpass
+type AttendanceFlow struct {
+	odooClient      *odoo.Client
+	quickpassClient *quickpass.Client
+	repo            repository.Repository
+}
+
+// NewAttendanceFlow crea el flujo de asistencias
+func NewAttendanceFlow(odooClient *odoo.Client, quickpassClient *quickpass.Client, repo repository.Repository) *AttendanceFlow {
+	return &AttendanceFlow{
+		odooClient:      odooClient,
+		quickpassClient: quickpassClient,
+		repo:            repo,
+	}
+}
+
+// Name implementa Flow
+func (f *AttendanceFlow) Name() string {
+	return FlowAttendance
+}
+
+// Plan implementa Flow: lista las marcaciones nuevas desde el último watermark
+func (f *AttendanceFlow) Plan(ctx context.Context, tenant string) (*Plan, error) {
+	if f.odooClient == nil || f.quickpassClient == nil {
+		return nil, fmt.Errorf("los clientes de Odoo y Quickpass deben estar configurados")
+	}
+
+	since := time.Now().UTC().Add(-attendanceLookback)
+	watermark, err := f.repo.GetWatermark(ctx, tenant, FlowAttendance)
+	switch {
+	case err == nil:
+		if t, err := time.Parse(time.RFC3339Nano, watermark.Value); err == nil {
+			since = t
+		}
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, err
+	}
+
+	punches, err := f.quickpassClient.ListPunches(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(punches, func(i, j int) bool {
+		return punches[i].Timestamp.Before(punches[j].Timestamp)
+	})
+
+	plan := NewPlan(tenant, FlowAttendance)
+	state := &attendanceState{watermark: since}
+	plan.state = state
+
+	for _, punch := range punches {
+		if punch.Timestamp.After(state.watermark) {
+			state.watermark = punch.Timestamp
+		}
+
+		change, err := f.planPunch(ctx, tenant, punch)
+		if err != nil {
+			return nil, err
+		}
+		plan.Add(change)
+	}
+	return plan, nil
+}
+
+// planPunch decide qué hacer con una marcación
+func (f *AttendanceFlow) planPunch(ctx context.Context, tenant string, punch *quickpass.Punch) (*Change, error) {
+	payload := &AttendancePayload{
+		PunchID:         punch.ID,
+		QuickpassUserID: punch.UserID,
+		Type:            punch.Type,
+		Timestamp:       punch.Timestamp,
+	}
+	change := &Change{
+		Ref:         punchRef(punch.ID),
+		Description: fmt.Sprintf("%s %s", punch.Type, punch.Timestamp.Format(time.RFC3339)),
+		payload:     payload,
+	}
+
+	// Marcación ya registrada en una ejecución anterior
+	existing, err := f.repo.GetMappingByQuickpassID(ctx, tenant, punchEntity(punch.Type), punch.ID)
+	if err == nil {
+		change.Action = ActionSkip
+		change.Reason = "ya registrada en " + attendanceRef(existing.OdooID)
+		return change, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	mapping, err := f.repo.GetEmployeeMappingByQuickpassID(ctx, tenant, punch.UserID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		// Se planifica igual: al aplicar queda en la cola de fallidos hasta que exista el mapeo
+		change.Action = ActionConflict
+		change.Reason = fmt.Sprintf("el usuario %s no tiene empleado asociado", punch.UserID)
+		return change, nil
+	case err != nil:
+		return nil, err
+	}
+
+	payload.OdooEmployeeID = mapping.OdooEmployeeID
+	change.Target = employeeRef(mapping.OdooEmployeeID)
+	switch punch.Type {
+	case quickpass.PunchCheckIn:
+		change.Action = ActionCreate
+		change.Fields = []FieldChange{{Field: "check_in", After: punch.Timestamp.UTC().Format(time.RFC3339)}}
+	case quickpass.PunchCheckOut:
+		change.Action = ActionUpdate
+		change.Reason = "cierra la asistencia abierta"
+		change.Fields = []FieldChange{{Field: "check_out", After: punch.Timestamp.UTC().Format(time.RFC3339)}}
+	default:
+		change.Action = ActionSkip
+		change.Reason = fmt.Sprintf("tipo de marcación desconocido: %s", punch.Type)
+		change.payload = nil
+	}
+	return change, nil
+}
+
+// Apply implementa Flow: registra las marcaciones y envía las fallidas a la cola de fallidos
+func (f *AttendanceFlow) Apply(ctx context.Context, run *Run, plan *Plan) error {
+	state, _ := plan.state.(*attendanceState)
+
+	for _, change := range plan.Changes {
+		if err := checkpoint(ctx); err != nil {
+			// No se avanza el watermark: la próxima ejecución retoma desde el último punto guardado
+			return err
+		}
+
+		payload, _ := change.payload.(*AttendancePayload)
+		switch change.Action {
+		case ActionSkip:
+			run.Skipped()
+			run.Logf(ctx, repository.LevelDebug, change.Ref, "omitido: %s", change.Reason)
+
+		case ActionConflict, ActionCreate, ActionUpdate:
+			attendanceID, err := f.post(ctx, run.Tenant, payload)
+			if payload != nil {
+				run.Audit(ctx, payload.OdooEmployeeID, attendanceAuditChanges(payload), err)
+			}
+			if err != nil {
+				run.Failed()
+				run.Logf(ctx, repository.LevelError, change.Ref, "error registrando asistencia: %v", err)
+				run.DeadLetter(ctx, change.Ref, payload, err)
+				continue
+			}
+			if change.Action == ActionUpdate {
+				run.Updated()
+			} else {
+				run.Created()
+			}
+			run.Logf(ctx, repository.LevelInfo, change.Ref, "registrada en %s (%s)", attendanceRef(attendanceID), payload.Type)
+			run.Notify(ctx, change.Ref, notify.AttendanceRecorded, newAttendanceEvent(attendanceID, payload))
+		}
+	}
+
+	if state != nil {
+		if err := f.repo.SetWatermark(ctx, run.Tenant, FlowAttendance, state.watermark.UTC().Format(time.RFC3339Nano)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replay implementa Replayer
+func (f *AttendanceFlow) Replay(ctx context.Context, tenant string, data json.RawMessage) error {
+	var payload AttendancePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	_, err := f.post(ctx, tenant, &payload)
+	return err
+}
+
+// RecordPunch registra una marcación individual (ej: recibida por webhook) sin esperar al próximo ciclo
+// Si falla, la marcación queda en la cola de fallidos igual que en una ejecución del flujo
+func (e *Engine) RecordPunch(ctx context.Context, tenant string, punch *quickpass.Punch) (int, error) {
+	registered, _ := e.Flow(FlowAttendance)
+	flow, ok := registered.(*AttendanceFlow)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownFlow, FlowAttendance)
+	}
+
+	payload := &AttendancePayload{
+		PunchID:         punch.ID,
+		QuickpassUserID: punch.UserID,
+		Type:            punch.Type,
+		Timestamp:       punch.Timestamp,
+	}
+	attendanceID, err := flow.post(ctx, tenant, payload)
+	entry := &repository.AuditEntry{Tenant: tenant, ActorID: "punch:" + punch.ID, ActorName: FlowAttendance, Changes: attendanceAuditChanges(payload)}
+	if auditErr := appendAudit(ctx, e.repo, entry, payload.OdooEmployeeID, err); auditErr != nil {
+		logger.WarnContext(ctx, "⚠️ Error registrando auditoría de la marcación", "tenant", tenant, "punch_id", punch.ID, "error", auditErr)
+	}
+	if err != nil {
+		if _, dlErr := e.deadLetters.Record(context.WithoutCancel(ctx), tenant, FlowAttendance, punchRef(punch.ID), payload, err); dlErr != nil {
+			logger.WarnContext(ctx, "⚠️ Error guardando la marcación en la cola de fallidos", "tenant", tenant, "punch_id", punch.ID, "error", dlErr)
+		}
+		return 0, err
+	}
+	e.Notify(ctx, tenant, notify.AttendanceRecorded, newAttendanceEvent(attendanceID, payload))
+	return attendanceID, nil
+}
+
+// attendanceAuditChanges describe una marcación como cambio auditado del empleado
+func attendanceAuditChanges(payload *AttendancePayload) []repository.AuditChange {
+	return []repository.AuditChange{{
+		EmployeeID: payload.OdooEmployeeID,
+		Field:      "attendance." + payload.Type,
+		After:      payload.Timestamp.UTC().Format(time.RFC3339),
+	}}
+}
+
+// post registra una marcación en Odoo y guarda su mapeo; es idempotente por punch_id
+func (f *AttendanceFlow) post(ctx context.Context, tenant string, payload *AttendancePayload) (int, error) {
+	if payload == nil || payload.PunchID == "" || payload.Timestamp.IsZero() {
+		return 0, fmt.Errorf("%w: faltan punch_id o timestamp", ErrInvalidPayload)
+	}
+
+	if existing, err := f.repo.GetMappingByQuickpassID(ctx, tenant, punchEntity(payload.Type), payload.PunchID); err == nil {
+		return existing.OdooID, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return 0, err
+	}
+
+	// Resolver el empleado si todavía no se conocía (ej: el mapeo se creó después)
+	if payload.OdooEmployeeID == 0 {
+		mapping, err := f.repo.GetEmployeeMappingByQuickpassID(ctx, tenant, payload.QuickpassUserID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, fmt.Errorf("%w: usuario de Quickpass %s", ErrUnmapped, payload.QuickpassUserID)
+		}
+		if err != nil {
+			return 0, err
+		}
+		payload.OdooEmployeeID = mapping.OdooEmployeeID
+	}
+
+	if f.odooClient.UID() == 0 {
+		if err := f.odooClient.Authenticate(ctx); err != nil {
+			return 0, fmt.Errorf("error autenticando con Odoo: %w", err)
+		}
+	}
+
+	service := odoo.NewAttendanceService(f.odooClient)
+	var attendanceID int
+	var err error
+	switch payload.Type {
+	case quickpass.PunchCheckIn:
+		attendanceID, err = service.CheckIn(ctx, payload.OdooEmployeeID, payload.Timestamp)
+	case quickpass.PunchCheckOut:
+		attendanceID, err = service.CheckOut(ctx, payload.OdooEmployeeID, payload.Timestamp)
+	default:
+		return 0, fmt.Errorf("%w: tipo de marcación desconocido %q", ErrInvalidPayload, payload.Type)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	err = f.repo.SaveMapping(ctx, &repository.IDMapping{
+		Tenant:      tenant,
+		Entity:      punchEntity(payload.Type),
+		OdooID:      attendanceID,
+		QuickpassID: payload.PunchID,
+	})
+	return attendanceID, err
+}
+
+// punchRef identifica una marcación en el registro de eventos
+func punchRef(id string) string {
+	return "quickpass.punch:" + id
+}
+
+// attendanceRef identifica una asistencia de Odoo
+func attendanceRef(id int) string {
+	return "hr.attendance:" + strconv.Itoa(id)
+}
@@ -0,0 +1,324 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// ErrNotReplayable se devuelve al reprocesar un elemento de un flujo que no soporta reproceso
+var ErrNotReplayable = errors.New("el flujo no permite reprocesar elementos")
+
+// Replayer es implementado por los flujos cuyos elementos fallidos se pueden reprocesar
+type Replayer interface {
+	// Replay procesa nuevamente un elemento a partir de su contenido guardado
+	Replay(ctx context.Context, tenant string, payload json.RawMessage) error
+}
+
+// RetryPolicy define cuántas veces y cada cuánto se reintenta un elemento fallido
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy devuelve la política por defecto (3 intentos, desde 5 segundos)
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   5 * time.Second,
+		MaxDelay:    time.Hour,
+	}
+}
+
+// NewRetryPolicyFromEnv crea la política desde MAX_RETRIES y RETRY_DELAY (segundos)
+func NewRetryPolicyFromEnv() (RetryPolicy, error) {
+	policy := DefaultRetryPolicy()
+
+	if value := envValue("MAX_RETRIES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return DefaultRetryPolicy(), fmt.Errorf("MAX_RETRIES inválido: %s", value)
+		}
+		policy.MaxAttempts = n
+	}
+	if value := envValue("RETRY_DELAY"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return DefaultRetryPolicy(), fmt.Errorf("RETRY_DELAY inválido: %s", value)
+		}
+		policy.BaseDelay = time.Duration(n) * time.Second
+	}
+	return policy, nil
+}
+
+// envValue lee una variable de entorno ignorando comentarios al final de la línea
+func envValue(key string) string {
+	value, _, _ := strings.Cut(os.Getenv(key), "#")
+	return strings.TrimSpace(value)
+}
+
+// Backoff devuelve la espera antes del reintento número "attempt" (1, 2, 3...)
+// La espera se duplica en cada intento hasta MaxDelay
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return delay
+}
+
+// DeadLetterQueue guarda los elementos que fallaron y los reintenta con backoff
+type DeadLetterQueue struct {
+	repo   repository.DeadLetterStore
+	engine *Engine
+
+	mu     sync.RWMutex
+	policy RetryPolicy
+
+	stop   chan struct{}
+	cancel context.CancelFunc // Cancela los reintentos en curso (ver Shutdown)
+	wg     sync.WaitGroup
+}
+
+// newDeadLetterQueue crea la cola asociada a un motor
+func newDeadLetterQueue(engine *Engine, repo repository.DeadLetterStore) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		repo:   repo,
+		engine: engine,
+		policy: DefaultRetryPolicy(),
+	}
+}
+
+// SetPolicy cambia la política de reintentos; se aplica a los próximos fallos y reintentos
+func (q *DeadLetterQueue) SetPolicy(policy RetryPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.policy = policy
+}
+
+// Policy devuelve la política de reintentos vigente
+func (q *DeadLetterQueue) Policy() RetryPolicy {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.policy
+}
+
+// Record registra (o actualiza) un elemento fallido
+// Los errores transitorios se programan para reintento; el resto queda como "dead"
+func (q *DeadLetterQueue) Record(ctx context.Context, tenant, flow, itemKey string, payload interface{}, cause error) (*repository.DeadLetter, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando elemento fallido: %w", err)
+	}
+
+	item, err := q.repo.GetDeadLetterByKey(ctx, tenant, flow, itemKey)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		item = &repository.DeadLetter{Tenant: tenant, Flow: flow, ItemKey: itemKey}
+	case err != nil:
+		return nil, err
+	}
+
+	item.Payload = data
+	q.markFailed(item, cause)
+	if err := q.repo.SaveDeadLetter(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// markFailed actualiza intentos, clase de error y próximo reintento
+func (q *DeadLetterQueue) markFailed(item *repository.DeadLetter, cause error) {
+	attemptAt := time.Now().UTC()
+	item.Attempts++
+	item.LastAttemptAt = &attemptAt
+	item.ErrorClass = ClassifyError(cause)
+	item.Error = cause.Error()
+
+	policy := q.Policy()
+	if IsRetryable(item.ErrorClass) && item.Attempts < policy.MaxAttempts {
+		next := attemptAt.Add(policy.Backoff(item.Attempts))
+		item.Status = repository.DeadLetterRetrying
+		item.NextRetryAt = &next
+		return
+	}
+	item.Status = repository.DeadLetterDead
+	item.NextRetryAt = nil
+}
+
+// Replay reprocesa un elemento; si payload no es nil reemplaza el contenido guardado
+func (q *DeadLetterQueue) Replay(ctx context.Context, id int64, payload json.RawMessage) (*repository.DeadLetter, error) {
+	item, err := q.repo.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		if !json.Valid(payload) {
+			return nil, fmt.Errorf("%w: el payload no es JSON válido", ErrInvalidPayload)
+		}
+		item.Payload = payload
+	}
+	return item, q.replay(ctx, item)
+}
+
+// replay ejecuta el reproceso y guarda el resultado
+func (q *DeadLetterQueue) replay(ctx context.Context, item *repository.DeadLetter) error {
+	flow, ok := q.engine.Flow(item.Flow)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlow, item.Flow)
+	}
+	replayer, ok := flow.(Replayer)
+	if !ok {
+		return ErrNotReplayable
+	}
+
+	replayErr := replayer.Replay(ctx, item.Tenant, item.Payload)
+	if replayErr != nil {
+		q.markFailed(item, replayErr)
+	} else {
+		attemptAt := time.Now().UTC()
+		item.Attempts++
+		item.LastAttemptAt = &attemptAt
+		item.Status = repository.DeadLetterResolved
+		item.NextRetryAt = nil
+		item.Error = ""
+		item.ErrorClass = ""
+	}
+
+	if err := q.repo.SaveDeadLetter(context.WithoutCancel(ctx), item); err != nil {
+		return err
+	}
+	return replayErr
+}
+
+// Discard descarta un elemento para que no se vuelva a procesar
+func (q *DeadLetterQueue) Discard(ctx context.Context, id int64) (*repository.DeadLetter, error) {
+	item, err := q.repo.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item.Status = repository.DeadLetterDiscarded
+	item.NextRetryAt = nil
+	if err := q.repo.SaveDeadLetter(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Update reemplaza el contenido de un elemento sin reprocesarlo
+func (q *DeadLetterQueue) Update(ctx context.Context, id int64, payload json.RawMessage) (*repository.DeadLetter, error) {
+	if !json.Valid(payload) {
+		return nil, fmt.Errorf("%w: el payload no es JSON válido", ErrInvalidPayload)
+	}
+	item, err := q.repo.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item.Payload = payload
+	if err := q.repo.SaveDeadLetter(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ProcessDue reintenta los elementos cuyo reintento venció y devuelve cuántos se resolvieron
+func (q *DeadLetterQueue) ProcessDue(ctx context.Context) (int, error) {
+	items, err := q.repo.DueDeadLetters(ctx, q.engine.tenant, time.Now().UTC(), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := q.replay(ctx, item); err != nil {
+			logger.WarnContext(ctx, "🔁 Reintento fallido", "tenant", item.Tenant, "flow", item.Flow, "item", item.ItemKey,
+				"attempt", item.Attempts, "max_attempts", q.Policy().MaxAttempts, "error", err)
+			continue
+		}
+		resolved++
+		logger.InfoContext(ctx, "✅ Elemento reprocesado", "tenant", item.Tenant, "flow", item.Flow, "item", item.ItemKey, "attempt", item.Attempts)
+	}
+	return resolved, nil
+}
+
+// Start inicia los reintentos automáticos en segundo plano
+func (q *DeadLetterQueue) Start() {
+	q.stop = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+	q.wg.Add(1)
+
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.Policy().BaseDelay)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-q.stop:
+				return
+			case <-ticker.C:
+				if _, err := q.ProcessDue(ctx); err != nil {
+					logger.WarnContext(ctx, "⚠️ Error procesando reintentos", "tenant", q.engine.tenant, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop detiene los reintentos automáticos
+func (q *DeadLetterQueue) Stop() {
+	if q.stop == nil {
+		return
+	}
+	close(q.stop)
+	q.wg.Wait()
+	q.cancel()
+	q.stop = nil
+}
+
+// Shutdown detiene los reintentos automáticos dejando terminar el elemento en curso
+// Si ctx vence antes, se cancelan los reintentos que falten del lote
+func (q *DeadLetterQueue) Shutdown(ctx context.Context) error {
+	if q.stop == nil {
+		return nil
+	}
+	close(q.stop)
+	err := waitOrCancel(ctx, &q.wg, q.cancel)
+	q.stop = nil
+	return err
+}
+
+// waitOrCancel espera a que terminen los procesos de wg; si ctx vence antes, llama a cancel y los espera
+func waitOrCancel(ctx context.Context, wg *sync.WaitGroup, cancel context.CancelFunc) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	defer cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return ctx.Err()
+	}
+}
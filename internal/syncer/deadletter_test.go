@@ -0,0 +1,33 @@
+package syncer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 5 * time.Second, MaxDelay: time.Minute}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{5, time.Minute},
+		{50, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, se esperaba %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	// La política por defecto nunca supera MaxDelay, aunque el número de intentos sea alto
+	defaults := DefaultRetryPolicy()
+	if got := defaults.Backoff(1000); got != defaults.MaxDelay {
+		t.Errorf("Backoff(1000) = %v, se esperaba %v", got, defaults.MaxDelay)
+	}
+}
@@ -0,0 +1,418 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/rut"
+)
+
+// FlowEmployees es el nombre del flujo de empleados Odoo → Quickpass
+const FlowEmployees = "employees"
+
+// EmployeeFlow sincroniza los hr.employee de Odoo como usuarios de Quickpass
+type EmployeeFlow struct {
+	odooClient      *odoo.Client
+	quickpassClient *quickpass.Client
+	repo            repository.Repository
+	identity        *IdentityResolver
+	fields          FieldMapping
+}
+
+// employeeChange son los datos necesarios para aplicar un cambio del flujo de empleados
+type employeeChange struct {
+	employeeID   int
+	user         *quickpass.User // Usuario que se envía a Quickpass
+	mapping      *repository.EmployeeMapping
+	hash         string
+	staleMapping bool // El mapeo apunta a un usuario que ya no existe en Quickpass
+	hashOnly     bool // Solo falta guardar el hash (Quickpass ya tiene los mismos datos)
+}
+
+// EmployeeEvent es el contenido de los eventos employee.* que reciben los suscriptores
+type EmployeeEvent struct {
+	EmployeeID      int           `json:"employee_id"`
+	QuickpassUserID string        `json:"quickpass_user_id"`
+	Changes         []FieldChange `json:"changes,omitempty"`
+}
+
+// NewEmployeeFlow crea el flujo de empleados
+func NewEmployeeFlow(odooClient *odoo.Client, quickpassClient *quickpass.Client, repo repository.Repository) *EmployeeFlow {
+	return &EmployeeFlow{
+		odooClient:      odooClient,
+		quickpassClient: quickpassClient,
+		repo:            repo,
+		identity:        NewIdentityResolver(repo),
+	}
+}
+
+// SetFieldMapping define los campos de Odoo que reemplazan la conversión por defecto (ver FieldMapping)
+func (f *EmployeeFlow) SetFieldMapping(fields FieldMapping) {
+	f.fields = fields
+}
+
+// toUser convierte un empleado al usuario de Quickpass aplicando el mapeo de campos del flujo
+func (f *EmployeeFlow) toUser(e *odoo.HrEmployee) *quickpass.User {
+	user := EmployeeToUser(e)
+	f.fields.apply(e, user)
+	return user
+}
+
+// Name implementa Flow
+func (f *EmployeeFlow) Name() string {
+	return FlowEmployees
+}
+
+// Plan implementa Flow: compara Odoo con Quickpass sin escribir en ninguno de los dos
+func (f *EmployeeFlow) Plan(ctx context.Context, tenant string) (*Plan, error) {
+	return f.plan(ctx, tenant, nil)
+}
+
+// PlanTargets implementa TargetedFlow: compara solo los empleados indicados
+// Un empleado indicado que Odoo ya no devuelve (archivado o eliminado) se propone para archivar
+func (f *EmployeeFlow) PlanTargets(ctx context.Context, tenant string, targets []int) (*Plan, error) {
+	return f.plan(ctx, tenant, targets)
+}
+
+// plan calcula el plan para todos los empleados (targets nil) o solo para los indicados
+func (f *EmployeeFlow) plan(ctx context.Context, tenant string, targets []int) (*Plan, error) {
+	if f.odooClient == nil || f.quickpassClient == nil {
+		return nil, fmt.Errorf("los clientes de Odoo y Quickpass deben estar configurados")
+	}
+	if f.odooClient.UID() == 0 {
+		if err := f.odooClient.Authenticate(ctx); err != nil {
+			return nil, fmt.Errorf("error autenticando con Odoo: %w", err)
+		}
+	}
+
+	service := odoo.NewEmployeeService(f.odooClient)
+	var employees []*odoo.HrEmployee
+	var err error
+	if targets == nil {
+		employees, err = service.GetAllEmployees(ctx)
+	} else {
+		employees, err = service.GetEmployeesByIDs(ctx, targets)
+	}
+	if err != nil {
+		return nil, err
+	}
+	users, err := f.quickpassClient.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Asociar identidades (en la primera ejecución se asocia por RUT y email)
+	identities, err := f.identity.Resolve(ctx, tenant, employees, users)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := NewPlan(tenant, FlowEmployees)
+	plan.state = identities
+
+	for _, identity := range identities.Matched {
+		plan.Add(f.planMatched(identity))
+	}
+	for _, employee := range identities.Unmatched {
+		plan.Add(f.planCreate(employee, nil))
+	}
+	for _, c := range identities.Conflicts {
+		plan.Add(&Change{
+			Action: ActionConflict,
+			Ref:    employeeRef(c.OdooEmployeeID),
+			Target: c.QuickpassUserID,
+			Reason: c.Reason + ": " + c.Details,
+		})
+	}
+
+	archives, err := f.planArchives(ctx, tenant, employees, users, targets)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range archives {
+		plan.Add(change)
+	}
+
+	return plan, nil
+}
+
+// planMatched decide qué hacer con un empleado que ya tiene usuario en Quickpass
+func (f *EmployeeFlow) planMatched(identity *Identity) *Change {
+	employee := identity.Employee
+	mapping := identity.Mapping
+	desired := f.toUser(employee)
+	hash := UserHash(desired)
+
+	// El usuario mapeado ya no existe en Quickpass: se vuelve a crear
+	if identity.User == nil {
+		return f.planCreate(employee, mapping)
+	}
+
+	change := &Change{
+		Ref:         employeeRef(employee.ID),
+		Target:      mapping.QuickpassUserID,
+		Description: employee.Name,
+		payload:     &employeeChange{employeeID: employee.ID, user: desired, mapping: mapping, hash: hash},
+	}
+
+	// Se compara primero con lo que Quickpass tiene ahora: si alguien cambió el usuario en Quickpass,
+	// el hash de la última sincronización coincide con Odoo pero el usuario ya no
+	currentHash := UserHash(identity.User)
+	switch {
+	case currentHash == hash && mapping.LastSyncedHash == hash:
+		change.Action = ActionSkip
+		change.Reason = fmt.Sprintf("sin cambios desde la última sincronización (hash %s)", shortHash(hash))
+
+	case currentHash == hash:
+		// Quickpass ya tiene los mismos datos; solo falta registrar el hash
+		change.Action = ActionSkip
+		change.Reason = fmt.Sprintf("Quickpass ya tiene los mismos datos (hash %s)", shortHash(hash))
+		change.payload.(*employeeChange).hashOnly = true
+
+	case mapping.LastSyncedHash == "":
+		change.Action = ActionUpdate
+		change.Reason = "primera sincronización"
+
+	case mapping.LastSyncedHash == hash:
+		change.Action = ActionUpdate
+		change.Reason = fmt.Sprintf("el usuario cambió en Quickpass (hash %s → %s)", shortHash(currentHash), shortHash(hash))
+
+	default:
+		change.Action = ActionUpdate
+		change.Reason = fmt.Sprintf("contenido cambió (hash %s → %s)", shortHash(mapping.LastSyncedHash), shortHash(hash))
+	}
+
+	if change.Action == ActionUpdate {
+		change.Fields = diffFields(userFields(identity.User), userFields(desired))
+	}
+	return change
+}
+
+// planCreate propone crear el usuario de un empleado sin usuario en Quickpass
+func (f *EmployeeFlow) planCreate(employee *odoo.HrEmployee, stale *repository.EmployeeMapping) *Change {
+	desired := f.toUser(employee)
+	change := &Change{
+		Action:      ActionCreate,
+		Ref:         employeeRef(employee.ID),
+		Description: fmt.Sprintf("%s (%s)", employee.Name, desired.RUT),
+		Fields:      diffFields(map[string]string{}, userFields(desired)),
+		payload: &employeeChange{
+			employeeID:   employee.ID,
+			user:         desired,
+			mapping:      stale,
+			hash:         UserHash(desired),
+			staleMapping: stale != nil,
+		},
+	}
+
+	switch {
+	case desired.RUT == "":
+		change.Action = ActionSkip
+		change.Reason = "el empleado no tiene RUT (identification_id)"
+		change.Fields = nil
+		change.payload = nil
+	case stale != nil:
+		change.Reason = fmt.Sprintf("el usuario %s ya no existe en Quickpass", stale.QuickpassUserID)
+	}
+	return change
+}
+
+// planArchives propone desactivar los usuarios cuyos empleados ya no están activos en Odoo
+// Si targets no es nil solo se consideran esos empleados
+func (f *EmployeeFlow) planArchives(ctx context.Context, tenant string, employees []*odoo.HrEmployee, users []*quickpass.User, targets []int) ([]*Change, error) {
+	mappings, err := f.repo.ListEmployeeMappings(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if targets != nil {
+		targeted := make(map[int]bool, len(targets))
+		for _, id := range targets {
+			targeted[id] = true
+		}
+		filtered := mappings[:0]
+		for _, m := range mappings {
+			if targeted[m.OdooEmployeeID] {
+				filtered = append(filtered, m)
+			}
+		}
+		mappings = filtered
+	}
+
+	// Protección: si Odoo no devuelve empleados es más probable un error que un despido masivo
+	if targets == nil && len(employees) == 0 && len(mappings) > 0 {
+		return nil, fmt.Errorf("Odoo no devolvió empleados activos; no se archivará ningún usuario")
+	}
+
+	active := make(map[int]bool, len(employees))
+	for _, e := range employees {
+		active[e.ID] = true
+	}
+	usersByID := make(map[string]*quickpass.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+
+	changes := []*Change{}
+	for _, m := range mappings {
+		user := usersByID[m.QuickpassUserID]
+		if active[m.OdooEmployeeID] || user == nil || !user.Active {
+			continue
+		}
+
+		archived := *user
+		archived.Active = false
+		changes = append(changes, &Change{
+			Action:      ActionArchive,
+			Ref:         employeeRef(m.OdooEmployeeID),
+			Target:      m.QuickpassUserID,
+			Description: fmt.Sprintf("%s %s (%s)", user.FirstName, user.LastName, user.RUT),
+			Reason:      "el empleado ya no está activo en Odoo",
+			Fields:      []FieldChange{{Field: "active", Before: "true", After: "false"}},
+			payload:     &employeeChange{employeeID: m.OdooEmployeeID, user: &archived, mapping: m, hash: UserHash(&archived)},
+		})
+	}
+	return changes, nil
+}
+
+// Apply implementa Flow: ejecuta los cambios del plan
+func (f *EmployeeFlow) Apply(ctx context.Context, run *Run, plan *Plan) error {
+	if identities, ok := plan.state.(*IdentityResult); ok {
+		if err := f.identity.Apply(ctx, identities); err != nil {
+			return err
+		}
+		for _, m := range identities.NewMappings {
+			run.Logf(ctx, repository.LevelInfo, employeeRef(m.OdooEmployeeID),
+				"asociado al usuario %s de Quickpass por %s", m.QuickpassUserID, m.MatchMethod)
+		}
+	}
+
+	for _, change := range plan.Changes {
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+
+		payload, _ := change.payload.(*employeeChange)
+		switch change.Action {
+		case ActionSkip:
+			run.Skipped()
+			run.Logf(ctx, repository.LevelDebug, change.Ref, "omitido: %s", change.Reason)
+			if payload != nil && payload.hashOnly {
+				f.saveHash(ctx, run, payload.mapping, payload.hash)
+			}
+
+		case ActionConflict:
+			run.Logf(ctx, repository.LevelWarn, change.Ref, "omitido: conflicto %s", change.Reason)
+
+		case ActionCreate:
+			f.applyCreate(ctx, run, change, payload)
+
+		case ActionUpdate, ActionArchive:
+			err := f.quickpassClient.UpdateUser(ctx, payload.mapping.QuickpassUserID, payload.user)
+			run.Audit(ctx, payload.employeeID, auditChanges(payload.employeeID, change.Fields), err)
+			if err != nil {
+				run.Failed()
+				run.Logf(ctx, repository.LevelError, change.Ref, "error actualizando usuario %s: %v", payload.mapping.QuickpassUserID, err)
+				continue
+			}
+			run.Updated()
+			verb, eventType := "actualizado", notify.EmployeeUpdated
+			if change.Action == ActionArchive {
+				verb, eventType = "archivado", notify.EmployeeArchived
+			}
+			run.Logf(ctx, repository.LevelInfo, change.Ref, "%s usuario %s: %s", verb, payload.mapping.QuickpassUserID, change.Reason)
+			f.saveHash(ctx, run, payload.mapping, payload.hash)
+			run.Notify(ctx, change.Ref, eventType, &EmployeeEvent{
+				EmployeeID:      payload.employeeID,
+				QuickpassUserID: payload.mapping.QuickpassUserID,
+				Changes:         change.Fields,
+			})
+		}
+	}
+	return nil
+}
+
+// applyCreate crea el usuario en Quickpass y guarda su mapeo
+func (f *EmployeeFlow) applyCreate(ctx context.Context, run *Run, change *Change, payload *employeeChange) {
+	if payload.staleMapping {
+		if err := f.repo.DeleteEmployeeMapping(ctx, run.Tenant, payload.employeeID); err != nil {
+			run.Failed()
+			run.Logf(ctx, repository.LevelError, change.Ref, "error eliminando mapeo obsoleto: %v", err)
+			return
+		}
+	}
+
+	created, err := f.quickpassClient.CreateUser(ctx, payload.user)
+	run.Audit(ctx, payload.employeeID, auditChanges(payload.employeeID, change.Fields), err)
+	if err != nil {
+		run.Failed()
+		run.Logf(ctx, repository.LevelError, change.Ref, "error creando usuario: %v", err)
+		return
+	}
+
+	run.Created()
+	run.Logf(ctx, repository.LevelInfo, change.Ref, "creado usuario %s en Quickpass", created.ID)
+
+	mapping := &repository.EmployeeMapping{
+		Tenant:          run.Tenant,
+		OdooEmployeeID:  payload.employeeID,
+		QuickpassUserID: created.ID,
+		RUT:             payload.user.RUT,
+		MatchMethod:     repository.MatchCreated,
+	}
+	f.saveHash(ctx, run, mapping, payload.hash)
+	run.Notify(ctx, change.Ref, notify.EmployeeCreated, &EmployeeEvent{
+		EmployeeID:      payload.employeeID,
+		QuickpassUserID: created.ID,
+		Changes:         change.Fields,
+	})
+}
+
+// saveHash guarda el hash sincronizado en el mapeo
+func (f *EmployeeFlow) saveHash(ctx context.Context, run *Run, mapping *repository.EmployeeMapping, hash string) {
+	syncedAt := time.Now().UTC()
+	mapping.LastSyncedHash = hash
+	mapping.LastSyncedAt = &syncedAt
+	if err := f.repo.SaveEmployeeMapping(ctx, mapping); err != nil {
+		run.Logf(ctx, repository.LevelError, employeeRef(mapping.OdooEmployeeID), "error guardando hash sincronizado: %v", err)
+	}
+}
+
+// EmployeeToUser convierte un empleado de Odoo al usuario que se envía a Quickpass
+func EmployeeToUser(e *odoo.HrEmployee) *quickpass.User {
+	user := &quickpass.User{
+		RUT:            rut.Normalize(e.IdentificationID),
+		FirstName:      e.FirstName,
+		LastName:       e.Surname,
+		SecondLastName: e.SecondSurname,
+		Email:          e.WorkEmail,
+		Phone:          e.WorkPhone,
+		Gender:         e.Gender,
+		PhotoURL:       e.PhotoURL,
+		Active:         true,
+	}
+	if e.BirthdayParsed != nil {
+		user.Birthday = e.BirthdayParsed.Format("2006-01-02")
+	}
+	if e.Nationality != nil {
+		user.Nationality = e.Nationality.Name
+	}
+	return user
+}
+
+// employeeRef identifica un empleado en el registro de eventos
+func employeeRef(id int) string {
+	return fmt.Sprintf("hr.employee:%d", id)
+}
+
+// shortHash abrevia un hash para los mensajes de log
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
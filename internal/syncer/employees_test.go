@@ -0,0 +1,161 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// fakeQuickpass guarda los usuarios en memoria y cuenta las actualizaciones recibidas
+type fakeQuickpass struct {
+	mu      sync.Mutex
+	users   map[string]*quickpass.User
+	updates int
+}
+
+func (f *fakeQuickpass) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/users":
+		users := []*quickpass.User{}
+		for _, u := range f.users {
+			users = append(users, u)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": users, "next_page": 0})
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/api/v1/users/"):
+		var user quickpass.User
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		user.ID = strings.TrimPrefix(r.URL.Path, "/api/v1/users/")
+		f.users[user.ID] = &user
+		f.updates++
+		fmt.Fprint(w, `{}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// edit modifica un usuario como si lo hubieran cambiado en Quickpass
+func (f *fakeQuickpass) edit(id string, change func(*quickpass.User)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	change(f.users[id])
+}
+
+func (f *fakeQuickpass) user(id string) quickpass.User {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.users[id]
+}
+
+// TestEmployeeFlowCorrectsQuickpassDrift cambia en Quickpass un usuario ya sincronizado: aunque Odoo no
+// cambió (el hash de la última sincronización coincide), la ejecución dirigida debe restaurar sus datos
+func TestEmployeeFlowCorrectsQuickpassDrift(t *testing.T) {
+	ctx := context.Background()
+	if err := logging.Setup(io.Discard, "text"); err != nil {
+		t.Fatal(err)
+	}
+
+	odooServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Params struct {
+				Service string `json:"service"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		var result interface{} = 2
+		if request.Params.Service == "object" {
+			// hr.employee.search_read del empleado indicado
+			result = []map[string]interface{}{{
+				"id": 7, "identification_id": "12.345.678-5", "name": "Juan Pérez Soto", "country_id": false,
+				"work_email": "jperez@empresa.cl", "work_phone": "+56 2 2345 6789", "image_1920": false,
+				"birthday": false, "gender": "male",
+			}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer odooServer.Close()
+	fake := &fakeQuickpass{users: map[string]*quickpass.User{
+		"u-7": {ID: "u-7", RUT: "12345678-5", FirstName: "Juan", LastName: "Pérez", Active: true},
+	}}
+	quickpassServer := httptest.NewServer(fake)
+	defer quickpassServer.Close()
+
+	repo, err := repository.NewSQLite(filepath.Join(t.TempDir(), "sync.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	if _, err := repo.Migrator().Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SaveEmployeeMapping(ctx, &repository.EmployeeMapping{
+		Tenant: "acme", OdooEmployeeID: 7, QuickpassUserID: "u-7", RUT: "12345678-5", MatchMethod: repository.MatchByRUT,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewEngine(repo)
+	engine.SetFlows(NewEmployeeFlow(
+		odoo.NewClient(&odoo.Config{URL: odooServer.URL, Database: "acme", Username: "admin", Password: secret.New("secret")}),
+		quickpass.NewClient(&quickpass.Config{URL: quickpassServer.URL, APIKey: secret.New("qp-key")}),
+		repo,
+	))
+	run := func() *repository.SyncRun {
+		t.Helper()
+		record, err := engine.NewTargetedRun(ctx, "acme", FlowEmployees, []int{7})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.ExecuteRun(ctx, record); err != nil {
+			t.Fatal(err)
+		}
+		return record
+	}
+
+	// Primera sincronización: se envían los datos de Odoo y se guarda el hash
+	if record := run(); record.Updated != 1 {
+		t.Fatalf("primera sincronización: %d actualizados, se esperaba 1", record.Updated)
+	}
+	synced := fake.user("u-7")
+
+	tests := []struct {
+		name    string
+		drift   func(*quickpass.User)
+		updated int
+	}{
+		{"sin cambios en ningún sistema", nil, 0},
+		{"email cambiado en Quickpass", func(u *quickpass.User) { u.Email = "otro@gmail.com" }, 1},
+		{"usuario desactivado en Quickpass", func(u *quickpass.User) { u.Active = false }, 1},
+		{"después de corregir", nil, 0},
+	}
+	for _, tt := range tests {
+		if tt.drift != nil {
+			fake.edit("u-7", tt.drift)
+		}
+		before := fake.updates
+		record := run()
+		if record.Updated != tt.updated || fake.updates-before != tt.updated {
+			t.Errorf("%s: %d actualizados y %d PUT a Quickpass, se esperaba %d", tt.name, record.Updated, fake.updates-before, tt.updated)
+		}
+		if got := fake.user("u-7"); got != synced {
+			t.Errorf("%s: Quickpass quedó con %+v, se esperaba %+v", tt.name, got, synced)
+		}
+	}
+}
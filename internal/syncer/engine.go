@@ -0,0 +1,409 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/metrics"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/redact"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/tracing"
+)
+
+var (
+	// ErrUnknownFlow se devuelve al pedir un flujo que no está registrado
+	ErrUnknownFlow = errors.New("flujo de sincronización desconocido")
+	// ErrNotTargetable se devuelve al pedir una ejecución dirigida a un flujo que no la soporta
+	ErrNotTargetable = errors.New("el flujo no permite sincronizar registros específicos")
+)
+
+var (
+	logger = logging.For("syncer")
+	tracer = tracing.Tracer("syncer")
+)
+
+var (
+	runDuration = metrics.NewHistogramVec("sync_run_duration_seconds",
+		"Duración de las ejecuciones de sincronización por tenant, flujo y estado final",
+		[]float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}, "tenant", "flow", "status")
+	runRecords = metrics.NewCounterVec("sync_records_total",
+		"Registros procesados por las ejecuciones de sincronización por tenant, flujo y resultado (created, updated, skipped o failed)",
+		"tenant", "flow", "result")
+)
+
+// Flow es un flujo de sincronización (empleados, asistencias, etc.)
+// Cada flujo primero calcula un plan sin escribir nada y luego lo aplica,
+// lo que permite ejecutar cualquier flujo en modo simulación (dry-run)
+type Flow interface {
+	// Name identifica el flujo en la API y en el historial (ej: "employees")
+	Name() string
+	// Plan compara ambos sistemas y devuelve los cambios propuestos sin escribir en ninguno
+	Plan(ctx context.Context, tenant string) (*Plan, error)
+	// Apply ejecuta los cambios del plan; los contadores y eventos se registran en run
+	Apply(ctx context.Context, run *Run, plan *Plan) error
+}
+
+// TargetedFlow es implementado por los flujos que pueden limitarse a algunos registros
+// (ej: los empleados que cambiaron según un webhook de Odoo)
+type TargetedFlow interface {
+	Flow
+	// PlanTargets calcula el plan solo para los registros de Odoo indicados
+	PlanTargets(ctx context.Context, tenant string, targets []int) (*Plan, error)
+}
+
+// Notifier publica los cambios aplicados por la sincronización para otros sistemas
+type Notifier interface {
+	// Publish notifica un evento (ej: "employee.updated") a los suscriptores interesados
+	Publish(ctx context.Context, tenant, eventType string, data interface{}) error
+}
+
+// Run es el contexto de una ejecución: acumula contadores y registra eventos
+type Run struct {
+	Tenant string
+	Record *repository.SyncRun
+
+	events      repository.EventLogStore
+	audit       repository.AuditStore
+	deadLetters *DeadLetterQueue
+	notifier    Notifier
+}
+
+// Logf registra un evento asociado a un registro concreto (ref puede ser vacío)
+func (r *Run) Logf(ctx context.Context, level, ref, format string, args ...interface{}) {
+	// El registro de eventos se consulta por la API: no guarda RUT ni emails en claro
+	message := redact.Text(fmt.Sprintf(format, args...))
+	// ctx ya lleva el flujo y la ejecución (ver ExecuteRun)
+	logger.Log(ctx, eventLevel(level), "🔄 "+message, "tenant", r.Tenant, "ref", ref)
+
+	if r.events == nil {
+		return
+	}
+	runID := r.Record.ID
+	event := &repository.EventLog{
+		RunID:     &runID,
+		Tenant:    r.Tenant,
+		Flow:      r.Record.Flow,
+		Level:     level,
+		RecordRef: ref,
+		Message:   message,
+	}
+	// Usar un contexto propio: el evento debe quedar registrado aunque la ejecución se cancele
+	if err := r.events.AppendEvent(context.WithoutCancel(ctx), event); err != nil {
+		logger.WarnContext(ctx, "⚠️ Error registrando evento de sincronización", "tenant", r.Tenant, "error", err)
+	}
+}
+
+// eventLevel traduce el nivel de un evento de sincronización al de slog
+func eventLevel(level string) slog.Level {
+	switch level {
+	case repository.LevelDebug:
+		return slog.LevelDebug
+	case repository.LevelWarn:
+		return slog.LevelWarn
+	case repository.LevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// DeadLetter guarda un elemento fallido para reintentarlo o revisarlo manualmente
+func (r *Run) DeadLetter(ctx context.Context, itemKey string, payload interface{}, cause error) {
+	if r.deadLetters == nil {
+		return
+	}
+	item, err := r.deadLetters.Record(context.WithoutCancel(ctx), r.Tenant, r.Record.Flow, itemKey, payload, cause)
+	if err != nil {
+		r.Logf(ctx, repository.LevelError, itemKey, "error guardando elemento fallido: %v", err)
+		return
+	}
+	r.Logf(ctx, repository.LevelWarn, itemKey, "enviado a la cola de fallidos #%d (%s, estado: %s)", item.ID, item.ErrorClass, item.Status)
+}
+
+// Notify publica un cambio aplicado; un error al publicar no detiene la ejecución
+func (r *Run) Notify(ctx context.Context, ref, eventType string, data interface{}) {
+	if r.notifier == nil {
+		return
+	}
+	if err := r.notifier.Publish(context.WithoutCancel(ctx), r.Tenant, eventType, data); err != nil {
+		r.Logf(ctx, repository.LevelError, ref, "error publicando evento %s: %v", eventType, err)
+	}
+}
+
+// Audit registra en la auditoría una escritura de datos de un empleado hecha por la ejecución
+// cause es el error de la escritura o nil si se aplicó
+func (r *Run) Audit(ctx context.Context, employeeID int, changes []repository.AuditChange, cause error) {
+	entry := &repository.AuditEntry{
+		Tenant:    r.Tenant,
+		ActorID:   fmt.Sprintf("run:%d", r.Record.ID),
+		ActorName: r.Record.Flow,
+		Changes:   changes,
+	}
+	if err := appendAudit(ctx, r.audit, entry, employeeID, cause); err != nil {
+		r.Logf(ctx, repository.LevelError, employeeRef(employeeID), "error registrando auditoría: %v", err)
+	}
+}
+
+// appendAudit completa y guarda una entrada de auditoría de una escritura de la sincronización
+func appendAudit(ctx context.Context, store repository.AuditStore, entry *repository.AuditEntry, employeeID int, cause error) error {
+	if store == nil {
+		return nil
+	}
+	entry.ActorMethod = "sync"
+	entry.Action = repository.AuditWrite
+	entry.EmployeeIDs = []int{}
+	if employeeID != 0 {
+		entry.EmployeeIDs = []int{employeeID}
+	}
+	entry.Outcome = repository.AuditSuccess
+	if cause != nil {
+		entry.Outcome = repository.AuditError
+	}
+	// La auditoría debe quedar registrada aunque la ejecución se cancele
+	return store.AppendAudit(context.WithoutCancel(ctx), entry)
+}
+
+// auditChanges convierte el diff de un plan en los cambios auditados de un empleado
+func auditChanges(employeeID int, fields []FieldChange) []repository.AuditChange {
+	changes := make([]repository.AuditChange, 0, len(fields))
+	for _, field := range fields {
+		changes = append(changes, repository.AuditChange{
+			EmployeeID: employeeID,
+			Field:      field.Field,
+			Before:     field.Before,
+			After:      field.After,
+		})
+	}
+	return changes
+}
+
+// Created, Updated, Skipped y Failed incrementan los contadores de la ejecución
+func (r *Run) Created() { r.Record.Created++ }
+func (r *Run) Updated() { r.Record.Updated++ }
+func (r *Run) Skipped() { r.Record.Skipped++ }
+func (r *Run) Failed()  { r.Record.Failed++ }
+
+// Engine registra los flujos disponibles y los ejecuta dejando historial
+type Engine struct {
+	tenant      string
+	repo        repository.Repository
+	deadLetters *DeadLetterQueue
+	notifier    Notifier
+
+	// Los flujos se pueden reemplazar al recargar la configuración (ver SetFlows)
+	mu    sync.RWMutex
+	flows map[string]Flow
+}
+
+// NewEngine crea un motor de sincronización
+func NewEngine(repo repository.Repository) *Engine {
+	engine := &Engine{
+		repo:  repo,
+		flows: map[string]Flow{},
+	}
+	engine.deadLetters = newDeadLetterQueue(engine, repo)
+	return engine
+}
+
+// DeadLetters devuelve la cola de elementos fallidos del motor
+func (e *Engine) DeadLetters() *DeadLetterQueue {
+	return e.deadLetters
+}
+
+// SetTenant limita al tenant indicado los procesos en segundo plano del motor (reintentos de elementos
+// fallidos, recuperación de ejecuciones interrumpidas y barrido de webhooks); vacío abarca todos los tenants
+func (e *Engine) SetTenant(tenant string) {
+	e.tenant = tenant
+}
+
+// Tenant devuelve el tenant al que está limitado el motor (vacío si abarca todos)
+func (e *Engine) Tenant() string {
+	return e.tenant
+}
+
+// SetNotifier define dónde se publican los cambios aplicados (nil deshabilita las notificaciones)
+func (e *Engine) SetNotifier(notifier Notifier) {
+	e.notifier = notifier
+}
+
+// Notify publica un cambio aplicado fuera de una ejecución (ej: una marcación recibida por webhook)
+func (e *Engine) Notify(ctx context.Context, tenant, eventType string, data interface{}) {
+	if e.notifier == nil {
+		return
+	}
+	if err := e.notifier.Publish(context.WithoutCancel(ctx), tenant, eventType, data); err != nil {
+		logger.WarnContext(ctx, "⚠️ Error publicando evento", "tenant", tenant, "event_type", eventType, "error", err)
+	}
+}
+
+// Register agrega un flujo al motor
+func (e *Engine) Register(flow Flow) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.flows[flow.Name()] = flow
+}
+
+// SetFlows reemplaza todos los flujos del motor; las ejecuciones en curso terminan con el flujo anterior
+func (e *Engine) SetFlows(flows ...Flow) {
+	registered := make(map[string]Flow, len(flows))
+	for _, flow := range flows {
+		registered[flow.Name()] = flow
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.flows = registered
+}
+
+// Flow obtiene un flujo por nombre
+func (e *Engine) Flow(name string) (Flow, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	flow, ok := e.flows[name]
+	return flow, ok
+}
+
+// Flows devuelve los nombres de los flujos registrados, ordenados
+func (e *Engine) Flows() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, 0, len(e.flows))
+	for name := range e.flows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Plan calcula el plan de un flujo sin escribir en Odoo, Quickpass ni en el historial
+func (e *Engine) Plan(ctx context.Context, tenant, name string) (*Plan, error) {
+	flow, ok := e.Flow(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFlow, name)
+	}
+	return flow.Plan(ctx, tenant)
+}
+
+// NewRun registra una ejecución pendiente de un flujo
+func (e *Engine) NewRun(ctx context.Context, tenant, name string) (*repository.SyncRun, error) {
+	return e.NewTargetedRun(ctx, tenant, name, nil)
+}
+
+// NewTargetedRun registra una ejecución pendiente limitada a algunos registros de Odoo
+// Con targets vacío equivale a NewRun
+func (e *Engine) NewTargetedRun(ctx context.Context, tenant, name string, targets []int) (*repository.SyncRun, error) {
+	flow, ok := e.Flow(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFlow, name)
+	}
+	if _, ok := flow.(TargetedFlow); len(targets) > 0 && !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotTargetable, name)
+	}
+
+	record := &repository.SyncRun{
+		Tenant:  tenant,
+		Flow:    name,
+		Status:  repository.RunStatusPending,
+		Targets: targets,
+	}
+	if err := e.repo.CreateSyncRun(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Execute registra y ejecuta un flujo de forma síncrona
+func (e *Engine) Execute(ctx context.Context, tenant, name string) (*repository.SyncRun, error) {
+	record, err := e.NewRun(ctx, tenant, name)
+	if err != nil {
+		return nil, err
+	}
+	return record, e.ExecuteRun(ctx, record)
+}
+
+// ExecuteRun ejecuta una ejecución previamente registrada y guarda su resultado
+// Si ctx se cancela o el servicio se apaga (ver Runner.Shutdown), la ejecución queda en estado "cancelled"
+func (e *Engine) ExecuteRun(ctx context.Context, record *repository.SyncRun) error {
+	flow, ok := e.Flow(record.Flow)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlow, record.Flow)
+	}
+
+	startedAt := time.Now().UTC()
+	record.Status = repository.RunStatusRunning
+	record.StartedAt = &startedAt
+	if err := e.repo.UpdateSyncRun(ctx, record); err != nil {
+		return err
+	}
+
+	// Las llamadas a Odoo y Quickpass de la ejecución quedan como spans hijos y sus mensajes llevan
+	// el flujo y la ejecución
+	ctx, span := tracer.Start(ctx, "sync "+record.Flow, trace.WithAttributes(
+		attribute.String("tenant", record.Tenant),
+		attribute.String("sync.flow", record.Flow),
+		attribute.Int64("sync.run_id", record.ID),
+		attribute.Int("sync.targets", len(record.Targets)),
+	))
+	ctx = logging.With(ctx, "flow", record.Flow, "run_id", record.ID)
+	run := &Run{Tenant: record.Tenant, Record: record, events: e.repo, audit: e.repo, deadLetters: e.deadLetters, notifier: e.notifier}
+	if len(record.Targets) > 0 {
+		logger.InfoContext(ctx, "▶️ Iniciando sincronización", "tenant", record.Tenant, "targets", record.Targets)
+	} else {
+		logger.InfoContext(ctx, "▶️ Iniciando sincronización", "tenant", record.Tenant)
+	}
+
+	var plan *Plan
+	var runErr error
+	if targeted, ok := flow.(TargetedFlow); ok && len(record.Targets) > 0 {
+		plan, runErr = targeted.PlanTargets(ctx, record.Tenant, record.Targets)
+	} else {
+		plan, runErr = flow.Plan(ctx, record.Tenant)
+	}
+	if runErr == nil {
+		runErr = flow.Apply(ctx, run, plan)
+	}
+
+	finishedAt := time.Now().UTC()
+	record.FinishedAt = &finishedAt
+	switch {
+	case runErr != nil && (ctx.Err() != nil || errors.Is(runErr, ErrShuttingDown)):
+		record.Status = repository.RunStatusCancelled
+		record.Error = runErr.Error()
+	case runErr != nil:
+		record.Status = repository.RunStatusFailed
+		record.Error = runErr.Error()
+	default:
+		record.Status = repository.RunStatusSucceeded
+	}
+
+	if err := e.repo.UpdateSyncRun(context.WithoutCancel(ctx), record); err != nil {
+		logger.WarnContext(ctx, "⚠️ Error guardando el resultado de la ejecución", "tenant", record.Tenant, "error", err)
+	}
+
+	runDuration.Observe(finishedAt.Sub(startedAt).Seconds(), record.Tenant, record.Flow, record.Status)
+	runRecords.Add(float64(record.Created), record.Tenant, record.Flow, "created")
+	runRecords.Add(float64(record.Updated), record.Tenant, record.Flow, "updated")
+	runRecords.Add(float64(record.Skipped), record.Tenant, record.Flow, "skipped")
+	runRecords.Add(float64(record.Failed), record.Tenant, record.Flow, "failed")
+
+	logger.InfoContext(ctx, "⏹️ Sincronización terminada", "tenant", record.Tenant, "status", record.Status,
+		"created", record.Created, "updated", record.Updated, "skipped", record.Skipped, "failed", record.Failed,
+		"duration_ms", finishedAt.Sub(startedAt).Milliseconds())
+	span.SetAttributes(
+		attribute.String("sync.status", record.Status),
+		attribute.Int("sync.created", record.Created),
+		attribute.Int("sync.updated", record.Updated),
+		attribute.Int("sync.skipped", record.Skipped),
+		attribute.Int("sync.failed", record.Failed),
+	)
+	tracing.End(span, runErr)
+
+	return runErr
+}
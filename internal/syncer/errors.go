@@ -0,0 +1,81 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+)
+
+// Clases de error de los elementos fallidos
+const (
+	ErrorClassTransient  = "transient"  // Red, timeouts, HTTP 5xx/429: se reintenta automáticamente
+	ErrorClassValidation = "validation" // Odoo rechazó los datos (ej: ValidationError por asistencia superpuesta)
+	ErrorClassAccess     = "access"     // Permisos insuficientes o credenciales inválidas
+	ErrorClassMapping    = "mapping"    // No existe el mapeo de identidad necesario
+	ErrorClassClient     = "client"     // Petición rechazada por Quickpass (HTTP 4xx)
+	ErrorClassPayload    = "payload"    // El contenido guardado no se puede interpretar
+	ErrorClassUnknown    = "unknown"
+)
+
+// ErrUnmapped indica que un elemento referencia a un usuario o empleado sin mapeo
+var ErrUnmapped = errors.New("no existe mapeo de identidad")
+
+// ErrInvalidPayload indica que el contenido de un elemento no es válido
+var ErrInvalidPayload = errors.New("contenido inválido")
+
+// ClassifyError determina la clase de un error para decidir si se reintenta
+func ClassifyError(err error) string {
+	var rpcErr *odoo.RPCError
+	var odooHTTPErr *odoo.HTTPError
+	var apiErr *quickpass.APIError
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrUnmapped):
+		return ErrorClassMapping
+	case errors.Is(err, ErrInvalidPayload):
+		return ErrorClassPayload
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTransient
+	case errors.As(err, &rpcErr):
+		switch {
+		case strings.Contains(rpcErr.Name, "ValidationError"), strings.Contains(rpcErr.Name, "UserError"):
+			return ErrorClassValidation
+		case strings.Contains(rpcErr.Name, "AccessError"), strings.Contains(rpcErr.Name, "AccessDenied"):
+			return ErrorClassAccess
+		}
+		return ErrorClassUnknown
+	case errors.As(err, &odooHTTPErr):
+		return classifyStatus(odooHTTPErr.StatusCode)
+	case errors.As(err, &apiErr):
+		return classifyStatus(apiErr.StatusCode)
+	case errors.As(err, &netErr):
+		return ErrorClassTransient
+	}
+	return ErrorClassUnknown
+}
+
+// classifyStatus clasifica un código de estado HTTP
+func classifyStatus(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests, status >= 500:
+		return ErrorClassTransient
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ErrorClassAccess
+	default:
+		return ErrorClassClient
+	}
+}
+
+// IsRetryable indica si una clase de error justifica reintentos automáticos
+// Los errores desconocidos se reintentan: es preferible agotar los intentos a perder el elemento
+func IsRetryable(class string) bool {
+	return class == ErrorClassTransient || class == ErrorClassUnknown
+}
@@ -0,0 +1,50 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		class     string
+		retryable bool
+	}{
+		{"sin error", nil, "", false},
+		{"sin mapeo", fmt.Errorf("usuario u-7: %w", ErrUnmapped), ErrorClassMapping, false},
+		{"contenido inválido", fmt.Errorf("%w: falta check_in", ErrInvalidPayload), ErrorClassPayload, false},
+		{"timeout", fmt.Errorf("llamando a Odoo: %w", context.DeadlineExceeded), ErrorClassTransient, true},
+		{"error de red", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrorClassTransient, true},
+		{"ValidationError de Odoo", &odoo.RPCError{Name: "odoo.exceptions.ValidationError", Message: "asistencia superpuesta"}, ErrorClassValidation, false},
+		{"UserError de Odoo", fmt.Errorf("creando asistencia: %w", &odoo.RPCError{Name: "odoo.exceptions.UserError"}), ErrorClassValidation, false},
+		{"AccessError de Odoo", &odoo.RPCError{Name: "odoo.exceptions.AccessError"}, ErrorClassAccess, false},
+		{"AccessDenied de Odoo", &odoo.RPCError{Name: "odoo.exceptions.AccessDenied"}, ErrorClassAccess, false},
+		{"otro error RPC de Odoo", &odoo.RPCError{Name: "builtins.KeyError"}, ErrorClassUnknown, true},
+		{"HTTP 502 de Odoo", &odoo.HTTPError{StatusCode: 502}, ErrorClassTransient, true},
+		{"HTTP 429 de Quickpass", &quickpass.APIError{StatusCode: 429}, ErrorClassTransient, true},
+		{"HTTP 503 de Quickpass", fmt.Errorf("enviando marcación: %w", &quickpass.APIError{StatusCode: 503}), ErrorClassTransient, true},
+		{"HTTP 401 de Quickpass", &quickpass.APIError{StatusCode: 401}, ErrorClassAccess, false},
+		{"HTTP 403 de Odoo", &odoo.HTTPError{StatusCode: 403}, ErrorClassAccess, false},
+		{"HTTP 422 de Quickpass", &quickpass.APIError{StatusCode: 422}, ErrorClassClient, false},
+		{"desconocido", errors.New("algo salió mal"), ErrorClassUnknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := ClassifyError(tt.err)
+			if class != tt.class {
+				t.Fatalf("ClassifyError() = %q, se esperaba %q", class, tt.class)
+			}
+			if IsRetryable(class) != tt.retryable {
+				t.Fatalf("IsRetryable(%q) = %v, se esperaba %v", class, !tt.retryable, tt.retryable)
+			}
+		})
+	}
+}
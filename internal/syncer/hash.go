@@ -0,0 +1,64 @@
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/rut"
+)
+
+// hashVersion se incluye en cada hash; cambiarlo fuerza a reenviar todos los registros
+const hashVersion = "v1"
+
+// Fingerprint calcula un hash determinista de un conjunto de campos
+// La serialización canónica ordena las claves, recorta espacios y antepone el largo
+// de cada valor, de modo que dos conjuntos distintos nunca producen la misma entrada
+func Fingerprint(fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(hashVersion)
+	b.WriteByte('\n')
+	for _, k := range keys {
+		v := strings.TrimSpace(fields[k])
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(len(v)))
+		b.WriteByte(':')
+		b.WriteString(v)
+		b.WriteByte('\n')
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// userFields devuelve los campos de un usuario de Quickpass que participan en el hash
+// Se excluyen el ID (lo asigna Quickpass) y PhotoURL (cambia sin que cambie la foto)
+func userFields(u *quickpass.User) map[string]string {
+	return map[string]string{
+		"rut":              rut.Normalize(u.RUT),
+		"first_name":       u.FirstName,
+		"last_name":        u.LastName,
+		"second_last_name": u.SecondLastName,
+		"email":            strings.ToLower(u.Email),
+		"phone":            u.Phone,
+		"birthday":         u.Birthday,
+		"gender":           u.Gender,
+		"nationality":      u.Nationality,
+		"active":           strconv.FormatBool(u.Active),
+	}
+}
+
+// UserHash calcula el hash del contenido que la sincronización envía a Quickpass
+func UserHash(u *quickpass.User) string {
+	return Fingerprint(userFields(u))
+}
@@ -0,0 +1,228 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/rut"
+)
+
+// Motivos de conflicto de identidad
+const (
+	ConflictDuplicateRUTOdoo      = "duplicate_rut_odoo"      // Dos empleados de Odoo con el mismo RUT
+	ConflictDuplicateRUTQuickpass = "duplicate_rut_quickpass" // Varios usuarios de Quickpass con el mismo RUT
+	ConflictDuplicateEmail        = "duplicate_email"         // Varios usuarios de Quickpass con el mismo email
+	ConflictAlreadyMapped         = "already_mapped"          // El usuario de Quickpass ya pertenece a otro empleado
+	ConflictRUTMismatch           = "rut_mismatch"            // Coincide el email o el mapeo, pero no el RUT
+)
+
+// ErrIdentityTaken se devuelve al enlazar un usuario de Quickpass que ya pertenece a otro empleado
+var ErrIdentityTaken = errors.New("el usuario de Quickpass ya está asociado a otro empleado")
+
+// Identity es un empleado de Odoo junto a su usuario de Quickpass (si se conoce)
+type Identity struct {
+	Employee *odoo.HrEmployee
+	User     *quickpass.User // nil si el usuario mapeado ya no existe en Quickpass
+	Mapping  *repository.EmployeeMapping
+}
+
+// IdentityResult es el resultado de resolver identidades para un conjunto de empleados
+type IdentityResult struct {
+	Matched     []*Identity                   // Empleados con usuario de Quickpass (mapeo previo o nuevo)
+	Unmatched   []*odoo.HrEmployee            // Empleados sin usuario en Quickpass
+	Conflicted  []*odoo.HrEmployee            // Empleados que no se pueden sincronizar hasta resolver el conflicto
+	NewMappings []*repository.EmployeeMapping // Mapeos encontrados automáticamente en esta resolución
+	Conflicts   []*repository.MappingConflict // Conflictos detectados
+}
+
+// IdentityResolver asocia empleados de Odoo con usuarios de Quickpass
+// Usa los mapeos guardados y, para los empleados sin mapeo, busca por RUT y luego por email
+type IdentityResolver struct {
+	store repository.EmployeeMappingStore
+}
+
+// NewIdentityResolver crea un nuevo resolvedor de identidades
+func NewIdentityResolver(store repository.EmployeeMappingStore) *IdentityResolver {
+	return &IdentityResolver{store: store}
+}
+
+// Resolve calcula las identidades sin escribir nada; use Apply para persistir el resultado
+func (r *IdentityResolver) Resolve(ctx context.Context, tenant string, employees []*odoo.HrEmployee, users []*quickpass.User) (*IdentityResult, error) {
+	mappings, err := r.store.ListEmployeeMappings(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	mappedByOdoo := make(map[int]*repository.EmployeeMapping, len(mappings))
+	claimed := make(map[string]int, len(mappings)) // quickpass_user_id -> odoo_employee_id
+	for _, m := range mappings {
+		mappedByOdoo[m.OdooEmployeeID] = m
+		claimed[m.QuickpassUserID] = m.OdooEmployeeID
+	}
+
+	usersByID := make(map[string]*quickpass.User, len(users))
+	usersByRUT := map[string][]*quickpass.User{}
+	usersByEmail := map[string][]*quickpass.User{}
+	for _, u := range users {
+		usersByID[u.ID] = u
+		if key := rut.Normalize(u.RUT); key != "" {
+			usersByRUT[key] = append(usersByRUT[key], u)
+		}
+		if key := normalizeEmail(u.Email); key != "" {
+			usersByEmail[key] = append(usersByEmail[key], u)
+		}
+	}
+
+	odooRUTs := map[string]int{}
+	for _, e := range employees {
+		if key := rut.Normalize(e.IdentificationID); key != "" {
+			odooRUTs[key]++
+		}
+	}
+
+	result := &IdentityResult{}
+	conflict := func(e *odoo.HrEmployee, userID, employeeRUT, reason, details string) {
+		result.Conflicts = append(result.Conflicts, &repository.MappingConflict{
+			Tenant:          tenant,
+			OdooEmployeeID:  e.ID,
+			QuickpassUserID: userID,
+			RUT:             employeeRUT,
+			Reason:          reason,
+			Details:         details,
+		})
+	}
+
+	for _, e := range employees {
+		employeeRUT := rut.Normalize(e.IdentificationID)
+
+		// 1. Mapeo existente
+		if m, ok := mappedByOdoo[e.ID]; ok {
+			user := usersByID[m.QuickpassUserID]
+			if user != nil {
+				if userRUT := rut.Normalize(user.RUT); userRUT != "" && employeeRUT != "" && userRUT != employeeRUT {
+					conflict(e, user.ID, employeeRUT, ConflictRUTMismatch,
+						fmt.Sprintf("el empleado tiene RUT %s y el usuario mapeado %s", employeeRUT, userRUT))
+				}
+			}
+			result.Matched = append(result.Matched, &Identity{Employee: e, User: user, Mapping: m})
+			continue
+		}
+
+		// 2. RUT repetido en Odoo: no se puede decidir automáticamente
+		if employeeRUT != "" && odooRUTs[employeeRUT] > 1 {
+			conflict(e, "", employeeRUT, ConflictDuplicateRUTOdoo,
+				fmt.Sprintf("%d empleados de Odoo tienen el RUT %s", odooRUTs[employeeRUT], employeeRUT))
+			result.Conflicted = append(result.Conflicted, e)
+			continue
+		}
+
+		// 3. Búsqueda por RUT y luego por email
+		method := repository.MatchByRUT
+		candidates := usersByRUT[employeeRUT]
+		if employeeRUT == "" {
+			candidates = nil
+		}
+		email := normalizeEmail(e.WorkEmail)
+		if len(candidates) == 0 && email != "" {
+			method = repository.MatchByEmail
+			candidates = usersByEmail[email]
+		}
+
+		switch {
+		case len(candidates) == 0:
+			result.Unmatched = append(result.Unmatched, e)
+			continue
+
+		case len(candidates) > 1:
+			reason := ConflictDuplicateRUTQuickpass
+			if method == repository.MatchByEmail {
+				reason = ConflictDuplicateEmail
+			}
+			for _, u := range candidates {
+				conflict(e, u.ID, employeeRUT, reason,
+					fmt.Sprintf("%d usuarios de Quickpass coinciden por %s", len(candidates), method))
+			}
+			result.Conflicted = append(result.Conflicted, e)
+			continue
+		}
+
+		user := candidates[0]
+		if owner, ok := claimed[user.ID]; ok && owner != e.ID {
+			conflict(e, user.ID, employeeRUT, ConflictAlreadyMapped,
+				fmt.Sprintf("el usuario ya está asociado al empleado %d", owner))
+			result.Conflicted = append(result.Conflicted, e)
+			continue
+		}
+
+		if method == repository.MatchByEmail {
+			if userRUT := rut.Normalize(user.RUT); userRUT != "" && employeeRUT != "" && userRUT != employeeRUT {
+				conflict(e, user.ID, employeeRUT, ConflictRUTMismatch,
+					fmt.Sprintf("coincide el email %s pero el RUT de Quickpass es %s", email, userRUT))
+				result.Conflicted = append(result.Conflicted, e)
+				continue
+			}
+		}
+
+		m := &repository.EmployeeMapping{
+			Tenant:          tenant,
+			OdooEmployeeID:  e.ID,
+			QuickpassUserID: user.ID,
+			RUT:             employeeRUT,
+			MatchMethod:     method,
+		}
+		claimed[user.ID] = e.ID
+		result.NewMappings = append(result.NewMappings, m)
+		result.Matched = append(result.Matched, &Identity{Employee: e, User: user, Mapping: m})
+	}
+
+	return result, nil
+}
+
+// Apply guarda los mapeos nuevos y registra los conflictos detectados
+func (r *IdentityResolver) Apply(ctx context.Context, result *IdentityResult) error {
+	for _, m := range result.NewMappings {
+		if err := r.store.SaveEmployeeMapping(ctx, m); err != nil {
+			return err
+		}
+	}
+	for _, c := range result.Conflicts {
+		if err := r.store.RecordMappingConflict(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LinkManual enlaza manualmente un empleado con un usuario de Quickpass
+// Reemplaza el mapeo previo del empleado, pero falla si el usuario pertenece a otro empleado
+func (r *IdentityResolver) LinkManual(ctx context.Context, tenant string, odooEmployeeID int, quickpassUserID, employeeRUT string) (*repository.EmployeeMapping, error) {
+	existing, err := r.store.GetEmployeeMappingByQuickpassID(ctx, tenant, quickpassUserID)
+	switch {
+	case err == nil && existing.OdooEmployeeID != odooEmployeeID:
+		return nil, fmt.Errorf("%w (empleado %d)", ErrIdentityTaken, existing.OdooEmployeeID)
+	case err != nil && !errors.Is(err, repository.ErrNotFound):
+		return nil, err
+	}
+
+	m := &repository.EmployeeMapping{
+		Tenant:          tenant,
+		OdooEmployeeID:  odooEmployeeID,
+		QuickpassUserID: quickpassUserID,
+		RUT:             rut.Normalize(employeeRUT),
+		MatchMethod:     repository.MatchManual,
+	}
+	if err := r.store.SaveEmployeeMapping(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// normalizeEmail normaliza un email para compararlo
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
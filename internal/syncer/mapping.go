@@ -0,0 +1,75 @@
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/rut"
+)
+
+// FieldMapping cambia de qué campo de hr.employee sale cada campo del usuario de Quickpass
+// Las claves son campos de Quickpass y los valores campos de Odoo (ej: {"email": "private_email"});
+// los campos que no aparecen usan la conversión por defecto de EmployeeToUser
+type FieldMapping map[string]string
+
+// mappingSources son los campos de hr.employee que se pueden usar como origen
+var mappingSources = map[string]func(e *odoo.HrEmployee) string{
+	"name":              func(e *odoo.HrEmployee) string { return e.Name },
+	"first_name":        func(e *odoo.HrEmployee) string { return e.FirstName },
+	"surname":           func(e *odoo.HrEmployee) string { return e.Surname },
+	"second_surname":    func(e *odoo.HrEmployee) string { return e.SecondSurname },
+	"identification_id": func(e *odoo.HrEmployee) string { return e.IdentificationID },
+	"work_email":        func(e *odoo.HrEmployee) string { return e.WorkEmail },
+	"private_email":     func(e *odoo.HrEmployee) string { return e.PrivateEmail },
+	"work_phone":        func(e *odoo.HrEmployee) string { return e.WorkPhone },
+	"private_phone":     func(e *odoo.HrEmployee) string { return e.PrivatePhone },
+	"gender":            func(e *odoo.HrEmployee) string { return e.Gender },
+}
+
+// mappingTargets son los campos del usuario de Quickpass que se pueden reasignar
+var mappingTargets = map[string]func(u *quickpass.User, value string){
+	"rut":              func(u *quickpass.User, value string) { u.RUT = rut.Normalize(value) },
+	"first_name":       func(u *quickpass.User, value string) { u.FirstName = value },
+	"last_name":        func(u *quickpass.User, value string) { u.LastName = value },
+	"second_last_name": func(u *quickpass.User, value string) { u.SecondLastName = value },
+	"email":            func(u *quickpass.User, value string) { u.Email = value },
+	"phone":            func(u *quickpass.User, value string) { u.Phone = value },
+	"gender":           func(u *quickpass.User, value string) { u.Gender = value },
+}
+
+// Validate verifica que todos los campos del mapeo existan
+func (m FieldMapping) Validate() error {
+	for target, source := range m {
+		if _, ok := mappingTargets[target]; !ok {
+			return fmt.Errorf("campo de Quickpass desconocido en el mapeo: %s (use %s)", target, mappingNames(mappingTargets))
+		}
+		if _, ok := mappingSources[source]; !ok {
+			return fmt.Errorf("campo de Odoo desconocido en el mapeo de %s: %s (use %s)", target, source, mappingNames(mappingSources))
+		}
+	}
+	return nil
+}
+
+// apply sobrescribe en el usuario los campos reasignados por el mapeo
+func (m FieldMapping) apply(e *odoo.HrEmployee, u *quickpass.User) {
+	for target, source := range m {
+		set, okTarget := mappingTargets[target]
+		get, okSource := mappingSources[source]
+		if okTarget && okSource {
+			set(u, get(e))
+		}
+	}
+}
+
+// mappingNames lista las claves de una tabla de campos, ordenadas
+func mappingNames[T any](fields map[string]T) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
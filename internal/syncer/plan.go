@@ -0,0 +1,135 @@
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Action es la operación que un plan propone para un registro
+type Action string
+
+// Acciones posibles de un plan
+const (
+	ActionCreate   Action = "create"
+	ActionUpdate   Action = "update"
+	ActionArchive  Action = "archive"
+	ActionSkip     Action = "skip"
+	ActionConflict Action = "conflict"
+)
+
+// actionLabels son las etiquetas del diff legible
+var actionLabels = map[Action]struct {
+	symbol string
+	label  string
+}{
+	ActionCreate:   {"+", "crear"},
+	ActionUpdate:   {"~", "actualizar"},
+	ActionArchive:  {"-", "archivar"},
+	ActionSkip:     {"=", "sin cambios"},
+	ActionConflict: {"!", "conflicto"},
+}
+
+// FieldChange es la diferencia de un campo entre el valor actual y el propuesto
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Change es una operación propuesta sobre un registro
+type Change struct {
+	Action      Action        `json:"action"`
+	Ref         string        `json:"ref"`              // Registro de origen (ej: "hr.employee:42")
+	Target      string        `json:"target,omitempty"` // Registro de destino (ej: ID de usuario Quickpass)
+	Description string        `json:"description,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	Fields      []FieldChange `json:"fields,omitempty"`
+
+	// payload contiene los datos que el flujo necesita para aplicar el cambio
+	payload interface{}
+}
+
+// Plan es el conjunto de cambios que un flujo aplicaría
+type Plan struct {
+	Tenant      string         `json:"tenant"`
+	Flow        string         `json:"flow"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Summary     map[Action]int `json:"summary"`
+	Changes     []*Change      `json:"changes"`
+
+	// state guarda información del flujo calculada durante el plan (ej: identidades nuevas)
+	state interface{}
+}
+
+// NewPlan crea un plan vacío
+func NewPlan(tenant, flow string) *Plan {
+	return &Plan{
+		Tenant:      tenant,
+		Flow:        flow,
+		GeneratedAt: time.Now().UTC(),
+		Summary:     map[Action]int{},
+		Changes:     []*Change{},
+	}
+}
+
+// Add agrega un cambio al plan
+func (p *Plan) Add(change *Change) {
+	p.Changes = append(p.Changes, change)
+	p.Summary[change.Action]++
+}
+
+// HasWrites indica si el plan contiene algún cambio que escriba en los sistemas
+func (p *Plan) HasWrites() bool {
+	return p.Summary[ActionCreate]+p.Summary[ActionUpdate]+p.Summary[ActionArchive] > 0
+}
+
+// Text genera un diff legible del plan; los registros sin cambios solo se cuentan
+func (p *Plan) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan de sincronización %s (tenant: %s) - %s\n\n", p.Flow, p.Tenant, p.GeneratedAt.Format(time.RFC3339))
+
+	for _, c := range p.Changes {
+		if c.Action == ActionSkip {
+			continue
+		}
+		label := actionLabels[c.Action]
+		line := fmt.Sprintf("%s %-11s %s", label.symbol, label.label, c.Ref)
+		if c.Target != "" {
+			line += " → " + c.Target
+		}
+		if c.Description != "" {
+			line += "  " + c.Description
+		}
+		if c.Reason != "" {
+			line += "  (" + c.Reason + ")"
+		}
+		b.WriteString(line + "\n")
+		for _, f := range c.Fields {
+			fmt.Fprintf(&b, "      %s: %q → %q\n", f.Field, f.Before, f.After)
+		}
+	}
+
+	actions := []Action{ActionCreate, ActionUpdate, ActionArchive, ActionConflict, ActionSkip}
+	parts := make([]string, 0, len(actions))
+	for _, a := range actions {
+		parts = append(parts, fmt.Sprintf("%d %s", p.Summary[a], actionLabels[a].label))
+	}
+	fmt.Fprintf(&b, "\nResumen: %s\n", strings.Join(parts, ", "))
+	return b.String()
+}
+
+// diffFields compara dos conjuntos de campos y devuelve las diferencias ordenadas
+func diffFields(before, after map[string]string) []FieldChange {
+	changes := []FieldChange{}
+	for field, value := range after {
+		if strings.TrimSpace(before[field]) != strings.TrimSpace(value) {
+			changes = append(changes, FieldChange{Field: field, Before: before[field], After: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
@@ -0,0 +1,357 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+var (
+	// ErrQueueFull se devuelve cuando la cola de ejecuciones está llena
+	ErrQueueFull = errors.New("la cola de sincronización está llena")
+	// ErrRunNotActive se devuelve al cancelar una ejecución que ya terminó
+	ErrRunNotActive = errors.New("la ejecución no está pendiente ni en curso")
+	// ErrShuttingDown se devuelve cuando una ejecución se detiene en un punto seguro porque el servicio se apaga
+	ErrShuttingDown = errors.New("ejecución detenida por el apagado del servicio")
+)
+
+// drainKey guarda en el contexto de una ejecución la señal de apagado ordenado del runner
+type drainKey struct{}
+
+// checkpoint indica si una ejecución debe detenerse antes de aplicar el próximo cambio:
+// porque se canceló (ctx) o porque el servicio se está apagando (ErrShuttingDown)
+// Los flujos lo revisan entre un registro y el siguiente, así nunca se interrumpe una escritura a medias
+func checkpoint(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if drain, ok := ctx.Value(drainKey{}).(context.Context); ok && drain.Err() != nil {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// Runner encola ejecuciones de sincronización y las procesa en segundo plano
+type Runner struct {
+	engine *Engine
+	repo   repository.SyncRunStore
+	queue  chan *repository.SyncRun
+
+	mu       sync.Mutex
+	active   map[int64]context.CancelFunc   // Ejecuciones en curso
+	canceled map[int64]bool                 // Ejecuciones pendientes canceladas antes de empezar
+	targeted map[string]*repository.SyncRun // Ejecución dirigida pendiente por tenant/flujo, para agrupar registros
+
+	ctx     context.Context
+	stop    context.CancelFunc
+	drain   context.Context // Se cancela al pedir un apagado ordenado (ver Shutdown)
+	drainF  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+	closed  bool
+}
+
+// NewRunner crea un procesador con una cola del tamaño indicado
+func NewRunner(engine *Engine, queueSize int) *Runner {
+	ctx, stop := context.WithCancel(context.Background())
+	drain, drainF := context.WithCancel(context.Background())
+	return &Runner{
+		engine:   engine,
+		repo:     engine.repo,
+		queue:    make(chan *repository.SyncRun, queueSize),
+		active:   map[int64]context.CancelFunc{},
+		canceled: map[int64]bool{},
+		targeted: map[string]*repository.SyncRun{},
+		ctx:      ctx,
+		stop:     stop,
+		drain:    drain,
+		drainF:   drainF,
+	}
+}
+
+// Start marca como fallidas las ejecuciones interrumpidas por un reinicio e inicia el worker
+// Las ejecuciones se procesan de a una para no escribir en paralelo sobre los mismos registros
+func (r *Runner) Start() {
+	r.recoverInterrupted()
+	r.Resume()
+}
+
+// Resume inicia el worker sin revisar las ejecuciones interrumpidas
+// Se usa cuando este runner reemplaza a otro que ya terminó las suyas (ver tenant.Registry): las
+// ejecuciones abiertas del tenant son las que se encolaron aquí mientras el anterior se detenía
+func (r *Runner) Resume() {
+	r.mu.Lock()
+	r.started = true
+	r.mu.Unlock()
+	r.wg.Add(1)
+	go r.work()
+}
+
+// QueueDepth devuelve la cantidad de ejecuciones que esperan en la cola
+func (r *Runner) QueueDepth() int {
+	return len(r.queue)
+}
+
+// Health indica si el runner puede aceptar ejecuciones: está iniciado, no se está apagando y la cola tiene lugar
+func (r *Runner) Health() error {
+	r.mu.Lock()
+	started, closed := r.started, r.closed
+	r.mu.Unlock()
+
+	switch {
+	case !started:
+		return errors.New("la cola de sincronización no está iniciada")
+	case closed:
+		return errors.New("la cola de sincronización se está deteniendo")
+	case len(r.queue) == cap(r.queue):
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// Stop cancela la ejecución en curso y espera a que el worker termine
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	r.stop()
+	r.wg.Wait()
+}
+
+// Shutdown deja de aceptar ejecuciones y pide a la ejecución en curso que se detenga en el próximo punto
+// seguro (entre un registro y el siguiente); las pendientes de la cola se marcan como canceladas
+// Si ctx vence antes de que termine, la ejecución se cancela como en Stop
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.drainF()
+
+	err := waitOrCancel(ctx, &r.wg, r.stop)
+	if err != nil {
+		logger.Warn("⚠️ La ejecución en curso no llegó a un punto seguro a tiempo y se canceló", "tenant", r.engine.tenant)
+	}
+	r.flush()
+	return err
+}
+
+// flush cierra como canceladas las ejecuciones que quedaron en la cola (el worker ya terminó)
+func (r *Runner) flush() {
+	for {
+		select {
+		case record := <-r.queue:
+			r.cancelQueued(record)
+		default:
+			return
+		}
+	}
+}
+
+// cancelQueued cierra una ejecución de la cola que no se va a ejecutar por el apagado del servicio
+func (r *Runner) cancelQueued(record *repository.SyncRun) {
+	r.mu.Lock()
+	r.releaseTargeted(record)
+	canceled := r.canceled[record.ID]
+	delete(r.canceled, record.ID)
+	r.mu.Unlock()
+	if !canceled {
+		r.finish(record, repository.RunStatusCancelled, ErrShuttingDown.Error())
+	}
+}
+
+// Enqueue registra una ejecución pendiente y la agrega a la cola
+func (r *Runner) Enqueue(ctx context.Context, tenant, flow string) (*repository.SyncRun, error) {
+	r.mu.Lock()
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		return nil, ErrQueueFull
+	}
+
+	record, err := r.engine.NewRun(ctx, tenant, flow)
+	if err != nil {
+		return nil, err
+	}
+
+	// Se devuelve una copia: el worker modifica el registro encolado apenas lo toma
+	queued := *record
+	select {
+	case r.queue <- record:
+		logger.InfoContext(ctx, "📋 Ejecución encolada", "tenant", tenant, "flow", flow, "run_id", queued.ID)
+		return &queued, nil
+	default:
+		r.finish(record, repository.RunStatusFailed, ErrQueueFull.Error())
+		return nil, ErrQueueFull
+	}
+}
+
+// EnqueueTargets encola una ejecución limitada a algunos registros de Odoo
+// Si ya hay una ejecución dirigida pendiente del mismo tenant y flujo, los registros se agregan a ella,
+// así una ráfaga de webhooks genera una sola ejecución en lugar de una por cambio
+func (r *Runner) EnqueueTargets(ctx context.Context, tenant, flow string, targets []int) (*repository.SyncRun, error) {
+	if len(targets) == 0 {
+		return r.Enqueue(ctx, tenant, flow)
+	}
+
+	key := tenant + "/" + flow
+	r.mu.Lock()
+	if pending, ok := r.targeted[key]; ok {
+		defer r.mu.Unlock()
+		pending.Targets = mergeTargets(pending.Targets, targets)
+		if err := r.repo.UpdateSyncRun(ctx, pending); err != nil {
+			return nil, err
+		}
+		logger.InfoContext(ctx, "📋 Registros agregados a la ejecución pendiente", "tenant", tenant, "flow", flow, "run_id", pending.ID, "targets", targets)
+		merged := *pending
+		return &merged, nil
+	}
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		return nil, ErrQueueFull
+	}
+
+	record, err := r.engine.NewTargetedRun(ctx, tenant, flow, mergeTargets(nil, targets))
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queued := *record
+	select {
+	case r.queue <- record:
+		r.targeted[key] = record
+		logger.InfoContext(ctx, "📋 Ejecución encolada", "tenant", tenant, "flow", flow, "run_id", queued.ID, "targets", queued.Targets)
+		return &queued, nil
+	default:
+		r.finish(record, repository.RunStatusFailed, ErrQueueFull.Error())
+		return nil, ErrQueueFull
+	}
+}
+
+// mergeTargets agrega IDs a una lista sin repetir
+func mergeTargets(current, extra []int) []int {
+	seen := make(map[int]bool, len(current))
+	for _, id := range current {
+		seen[id] = true
+	}
+	for _, id := range extra {
+		if !seen[id] {
+			seen[id] = true
+			current = append(current, id)
+		}
+	}
+	return current
+}
+
+// releaseTargeted deja de agrupar registros en una ejecución (debe llamarse con r.mu tomado)
+func (r *Runner) releaseTargeted(record *repository.SyncRun) {
+	key := record.Tenant + "/" + record.Flow
+	if pending, ok := r.targeted[key]; ok && pending.ID == record.ID {
+		delete(r.targeted, key)
+	}
+}
+
+// Cancel cancela una ejecución pendiente o en curso
+func (r *Runner) Cancel(ctx context.Context, id int64) error {
+	record, err := r.repo.GetSyncRun(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cancel, ok := r.active[id]; ok {
+		cancel()
+		logger.InfoContext(ctx, "🛑 Cancelando ejecución en curso", "tenant", record.Tenant, "run_id", id)
+		return nil
+	}
+
+	if record.Status != repository.RunStatusPending {
+		return ErrRunNotActive
+	}
+	r.canceled[id] = true
+	r.releaseTargeted(record)
+	r.finish(record, repository.RunStatusCancelled, "cancelada antes de iniciar")
+	return nil
+}
+
+// work procesa la cola hasta que se detenga el runner
+func (r *Runner) work() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-r.drain.Done():
+			return
+		case record := <-r.queue:
+			if r.drain.Err() != nil {
+				// Se pidió el apagado mientras había ejecuciones en la cola
+				r.cancelQueued(record)
+				return
+			}
+			r.process(record)
+		}
+	}
+}
+
+// process ejecuta una ejecución de la cola con un contexto cancelable
+func (r *Runner) process(record *repository.SyncRun) {
+	r.mu.Lock()
+	r.releaseTargeted(record)
+	if r.canceled[record.ID] {
+		delete(r.canceled, record.ID)
+		r.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(r.ctx, drainKey{}, r.drain))
+	r.active[record.ID] = cancel
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.active, record.ID)
+		r.mu.Unlock()
+		cancel()
+	}()
+
+	// El error ya queda registrado en la ejecución
+	_ = r.engine.ExecuteRun(ctx, record)
+}
+
+// finish cierra una ejecución que no llegó a ejecutarse
+func (r *Runner) finish(record *repository.SyncRun, status, message string) {
+	finishedAt := time.Now().UTC()
+	record.Status = status
+	record.Error = message
+	record.FinishedAt = &finishedAt
+	if err := r.repo.UpdateSyncRun(context.Background(), record); err != nil {
+		logger.Warn("⚠️ Error actualizando ejecución", "tenant", record.Tenant, "run_id", record.ID, "error", err)
+	}
+}
+
+// recoverInterrupted cierra las ejecuciones que quedaron abiertas tras un reinicio
+func (r *Runner) recoverInterrupted() {
+	ctx := context.Background()
+	for _, status := range []string{repository.RunStatusPending, repository.RunStatusRunning} {
+		runs, err := r.repo.ListSyncRuns(ctx, repository.SyncRunFilter{Tenant: r.engine.tenant, Status: status, Limit: 1000})
+		if err != nil {
+			logger.Warn("⚠️ Error revisando ejecuciones interrumpidas", "tenant", r.engine.tenant, "error", err)
+			return
+		}
+		for _, run := range runs {
+			r.finish(run, repository.RunStatusFailed, "interrumpida por reinicio del servicio")
+		}
+		if len(runs) > 0 {
+			logger.Warn("⚠️ Ejecuciones interrumpidas marcadas como fallidas tras el reinicio", "tenant", r.engine.tenant, "status", status, "count", len(runs))
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package syncer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// Scheduler encola cada Interval una ejecución completa de cada flujo registrado en el motor del tenant
+// Con intervalo cero no encola nada: los flujos solo se ejecutan a pedido (API) o por webhooks
+type Scheduler struct {
+	runner *Runner
+
+	mu       sync.Mutex
+	interval time.Duration
+	lastTick time.Time        // Último ciclo (o inicio); el próximo vence en lastTick + interval
+	last     map[string]int64 // Última ejecución encolada por flujo, para no encolar otra mientras siga abierta
+	reset    chan struct{}    // Avisa al ciclo que cambió el intervalo
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewScheduler crea un planificador deshabilitado que encola en runner; ver SetInterval
+func NewScheduler(runner *Runner) *Scheduler {
+	return &Scheduler{
+		runner: runner,
+		last:   map[string]int64{},
+		reset:  make(chan struct{}, 1),
+	}
+}
+
+// SetInterval cambia el intervalo en caliente (0 deshabilita la sincronización periódica)
+// El próximo ciclo vence un intervalo después del anterior: si ya pasó, se encola de inmediato
+func (s *Scheduler) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	changed := s.interval != interval
+	s.interval = interval
+	s.mu.Unlock()
+	if !changed {
+		return
+	}
+	select {
+	case s.reset <- struct{}{}:
+	default:
+	}
+}
+
+// Interval devuelve el intervalo vigente
+func (s *Scheduler) Interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
+
+// Start inicia el ciclo; el primero vence un intervalo después de iniciar
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	s.lastTick = time.Now()
+	s.mu.Unlock()
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go s.loop(s.stop)
+}
+
+// Stop detiene el ciclo; las ejecuciones ya encoladas siguen en la cola del runner
+func (s *Scheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	s.wg.Wait()
+	s.stop = nil
+}
+
+func (s *Scheduler) loop(stop chan struct{}) {
+	defer s.wg.Done()
+	for {
+		var timer *time.Timer
+		var due <-chan time.Time
+		if wait, enabled := s.untilNext(); enabled {
+			timer = time.NewTimer(wait)
+			due = timer.C
+		}
+
+		select {
+		case <-stop:
+		case <-s.reset:
+		case <-due:
+			s.mu.Lock()
+			s.lastTick = time.Now()
+			s.mu.Unlock()
+			s.Enqueue(context.Background())
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+}
+
+// untilNext devuelve cuánto falta para el próximo ciclo; enabled es false si el intervalo es cero
+func (s *Scheduler) untilNext() (wait time.Duration, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.interval <= 0 {
+		return 0, false
+	}
+	return max(time.Until(s.lastTick.Add(s.interval)), 0), true
+}
+
+// Enqueue encola una ejecución de cada flujo registrado que no tenga otra del planificador pendiente
+// o en curso, para que un flujo más lento que el intervalo no acumule ejecuciones en la cola
+func (s *Scheduler) Enqueue(ctx context.Context) {
+	tenant := s.runner.engine.Tenant()
+	for _, flow := range s.runner.engine.Flows() {
+		if s.open(ctx, flow) {
+			logger.DebugContext(ctx, "⏭️ Sincronización periódica omitida: la anterior sigue abierta", "tenant", tenant, "flow", flow)
+			continue
+		}
+		record, err := s.runner.Enqueue(ctx, tenant, flow)
+		if err != nil {
+			logger.WarnContext(ctx, "⚠️ Error encolando la sincronización periódica", "tenant", tenant, "flow", flow, "error", err)
+			continue
+		}
+		s.mu.Lock()
+		s.last[flow] = record.ID
+		s.mu.Unlock()
+	}
+}
+
+// open indica si la última ejecución que el planificador encoló para flow sigue pendiente o en curso
+func (s *Scheduler) open(ctx context.Context, flow string) bool {
+	s.mu.Lock()
+	id, ok := s.last[flow]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	record, err := s.runner.repo.GetSyncRun(ctx, id)
+	if err != nil {
+		return false
+	}
+	return record.Status == repository.RunStatusPending || record.Status == repository.RunStatusRunning
+}
@@ -0,0 +1,92 @@
+package syncer
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// countingFlow cuenta sus ejecuciones sin escribir en ningún sistema
+type countingFlow struct {
+	runs atomic.Int32
+}
+
+func (f *countingFlow) Name() string { return FlowEmployees }
+
+func (f *countingFlow) Plan(ctx context.Context, tenant string) (*Plan, error) {
+	f.runs.Add(1)
+	return NewPlan(tenant, FlowEmployees), nil
+}
+
+func (f *countingFlow) Apply(ctx context.Context, run *Run, plan *Plan) error { return nil }
+
+func newTestScheduler(t *testing.T) (*Scheduler, *Runner, *countingFlow) {
+	t.Helper()
+	if err := logging.Setup(io.Discard, "text"); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repository.NewSQLite(filepath.Join(t.TempDir(), "sync.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.Migrator().Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	flow := &countingFlow{}
+	engine := NewEngine(repo)
+	engine.SetTenant("acme")
+	engine.SetFlows(flow)
+	runner := NewRunner(engine, 10)
+	return NewScheduler(runner), runner, flow
+}
+
+// TestSchedulerSkipsOpenRuns verifica que no se encole otra ejecución mientras la anterior siga pendiente
+func TestSchedulerSkipsOpenRuns(t *testing.T) {
+	scheduler, runner, _ := newTestScheduler(t)
+
+	// Sin iniciar el runner las ejecuciones quedan pendientes en la cola
+	scheduler.Enqueue(context.Background())
+	scheduler.Enqueue(context.Background())
+	if depth := runner.QueueDepth(); depth != 1 {
+		t.Errorf("se encolaron %d ejecuciones, se esperaba 1", depth)
+	}
+}
+
+// TestSchedulerInterval verifica que el intervalo se aplique en caliente y que cero deshabilite el ciclo
+func TestSchedulerInterval(t *testing.T) {
+	scheduler, runner, flow := newTestScheduler(t)
+	runner.Start()
+	defer runner.Stop()
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if runs := flow.runs.Load(); runs != 0 {
+		t.Fatalf("con intervalo cero hubo %d ejecuciones", runs)
+	}
+
+	scheduler.SetInterval(10 * time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for flow.runs.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("hubo %d ejecuciones periódicas, se esperaban al menos 2", flow.runs.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	scheduler.SetInterval(0)
+	// La ejecución que ya estaba encolada puede terminar; después no debe haber otras
+	time.Sleep(50 * time.Millisecond)
+	runs := flow.runs.Load()
+	time.Sleep(100 * time.Millisecond)
+	if got := flow.runs.Load(); got != runs {
+		t.Errorf("tras deshabilitar hubo %d ejecuciones más", got-runs)
+	}
+}
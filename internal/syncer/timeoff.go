@@ -0,0 +1,350 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+)
+
+// FlowTimeOff es el nombre del flujo de ausencias: publica a los suscriptores los cambios de hr.leave en
+// Odoo. Deshabilitarlo también deja sin procesar las solicitudes de tiempo libre de Quickpass
+const FlowTimeOff = "time_off"
+
+// entityTimeOff es la entidad usada en id_mappings para las solicitudes ya registradas como hr.leave
+const entityTimeOff = "time_off"
+
+// leaveLookback es cuánto se mira hacia atrás en la primera ejecución
+const leaveLookback = 24 * time.Hour
+
+// TimeOffRecorder registra en Odoo las solicitudes de tiempo libre hechas en Quickpass
+type TimeOffRecorder struct {
+	odooClient *odoo.Client
+	repo       repository.Repository
+}
+
+// NewTimeOffRecorder crea el registrador de solicitudes de tiempo libre
+func NewTimeOffRecorder(odooClient *odoo.Client, repo repository.Repository) *TimeOffRecorder {
+	return &TimeOffRecorder{
+		odooClient: odooClient,
+		repo:       repo,
+	}
+}
+
+// Record crea el hr.leave de una solicitud y devuelve su ID; es idempotente por ID de solicitud
+func (t *TimeOffRecorder) Record(ctx context.Context, tenant string, req *quickpass.TimeOffRequest) (int, error) {
+	if req.ID == "" || req.UserID == "" || req.LeaveType == "" {
+		return 0, fmt.Errorf("%w: faltan id, user_id o leave_type", ErrInvalidPayload)
+	}
+	from, err := time.Parse("2006-01-02", req.DateFrom)
+	if err != nil {
+		return 0, fmt.Errorf("%w: date_from inválido: %s", ErrInvalidPayload, req.DateFrom)
+	}
+	to, err := time.Parse("2006-01-02", req.DateTo)
+	if err != nil || to.Before(from) {
+		return 0, fmt.Errorf("%w: date_to inválido: %s", ErrInvalidPayload, req.DateTo)
+	}
+
+	if existing, err := t.repo.GetMappingByQuickpassID(ctx, tenant, entityTimeOff, req.ID); err == nil {
+		return existing.OdooID, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return 0, err
+	}
+
+	mapping, err := t.repo.GetEmployeeMappingByQuickpassID(ctx, tenant, req.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, fmt.Errorf("%w: usuario de Quickpass %s", ErrUnmapped, req.UserID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if t.odooClient == nil {
+		return 0, fmt.Errorf("cliente Odoo no configurado")
+	}
+	if t.odooClient.UID() == 0 {
+		if err := t.odooClient.Authenticate(ctx); err != nil {
+			return 0, fmt.Errorf("error autenticando con Odoo: %w", err)
+		}
+	}
+
+	service := odoo.NewLeaveService(t.odooClient)
+	leaveTypeID, err := service.FindLeaveTypeID(ctx, req.LeaveType)
+	if err != nil {
+		return 0, err
+	}
+	if leaveTypeID == 0 {
+		return 0, fmt.Errorf("%w: el tipo de ausencia %q no existe en Odoo", ErrInvalidPayload, req.LeaveType)
+	}
+
+	description := req.Reason
+	if description == "" {
+		description = "Solicitud desde Quickpass " + req.ID
+	}
+	leaveID, err := service.Create(ctx, mapping.OdooEmployeeID, leaveTypeID, from, to, description)
+	if err != nil {
+		return 0, err
+	}
+
+	err = t.repo.SaveMapping(ctx, &repository.IDMapping{
+		Tenant:      tenant,
+		Entity:      entityTimeOff,
+		OdooID:      leaveID,
+		QuickpassID: req.ID,
+	})
+	return leaveID, err
+}
+
+// LeaveEvent es el contenido del evento leave.updated que reciben los suscriptores
+type LeaveEvent struct {
+	LeaveID      int     `json:"leave_id"`
+	EmployeeID   int     `json:"employee_id"`
+	LeaveTypeID  int     `json:"leave_type_id"`
+	LeaveType    string  `json:"leave_type"`
+	State        string  `json:"state"`
+	DateFrom     string  `json:"date_from"`
+	DateTo       string  `json:"date_to"`
+	NumberOfDays float64 `json:"number_of_days"`
+	// ChangedFields son los campos que cambiaron desde la última publicación (todos la primera vez)
+	ChangedFields []string `json:"changed_fields"`
+}
+
+// leavePayload es una ausencia lista para publicar
+type leavePayload struct {
+	leave  *odoo.HrLeave
+	fields map[string]string
+}
+
+// timeOffState es la información del plan necesaria para aplicarlo
+type timeOffState struct {
+	watermark time.Time
+}
+
+// TimeOffFlow publica las ausencias (hr.leave) creadas o modificadas en Odoo
+// Una ejecución completa revisa las modificadas desde la anterior; una dirigida, las indicadas por un
+// webhook. Solo se publican las que cambiaron desde su última publicación, así una misma modificación
+// recibida por webhook y vista luego por la ejecución periódica se publica una sola vez
+type TimeOffFlow struct {
+	odooClient *odoo.Client
+	repo       repository.Repository
+}
+
+// NewTimeOffFlow crea el flujo de ausencias
+func NewTimeOffFlow(odooClient *odoo.Client, repo repository.Repository) *TimeOffFlow {
+	return &TimeOffFlow{
+		odooClient: odooClient,
+		repo:       repo,
+	}
+}
+
+// Name implementa Flow
+func (f *TimeOffFlow) Name() string {
+	return FlowTimeOff
+}
+
+// Plan implementa Flow: revisa las ausencias modificadas desde el último watermark
+func (f *TimeOffFlow) Plan(ctx context.Context, tenant string) (*Plan, error) {
+	if err := f.connect(ctx); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().UTC().Add(-leaveLookback)
+	watermark, err := f.repo.GetWatermark(ctx, tenant, FlowTimeOff)
+	switch {
+	case err == nil:
+		if t, err := time.Parse(time.RFC3339Nano, watermark.Value); err == nil {
+			since = t
+		}
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, err
+	}
+
+	leaves, err := odoo.NewLeaveService(f.odooClient).GetLeavesChangedSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := NewPlan(tenant, FlowTimeOff)
+	state := &timeOffState{watermark: since}
+	plan.state = state
+	for _, leave := range leaves {
+		if leave.WriteDate.After(state.watermark) {
+			state.watermark = leave.WriteDate
+		}
+		change, err := f.planLeave(ctx, tenant, leave)
+		if err != nil {
+			return nil, err
+		}
+		plan.Add(change)
+	}
+	return plan, nil
+}
+
+// PlanTargets implementa TargetedFlow: revisa solo las ausencias indicadas (IDs de hr.leave)
+// No avanza el watermark: las demás ausencias modificadas quedan para la próxima ejecución completa
+func (f *TimeOffFlow) PlanTargets(ctx context.Context, tenant string, targets []int) (*Plan, error) {
+	if err := f.connect(ctx); err != nil {
+		return nil, err
+	}
+	leaves, err := odoo.NewLeaveService(f.odooClient).GetLeaves(ctx, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := NewPlan(tenant, FlowTimeOff)
+	found := map[int]bool{}
+	for _, leave := range leaves {
+		found[leave.ID] = true
+		change, err := f.planLeave(ctx, tenant, leave)
+		if err != nil {
+			return nil, err
+		}
+		plan.Add(change)
+	}
+	for _, id := range targets {
+		if !found[id] {
+			plan.Add(&Change{Action: ActionSkip, Ref: leaveRef(id), Reason: "no existe en Odoo"})
+		}
+	}
+	return plan, nil
+}
+
+// connect verifica que el cliente de Odoo esté configurado y autenticado
+func (f *TimeOffFlow) connect(ctx context.Context) error {
+	if f.odooClient == nil {
+		return fmt.Errorf("el cliente de Odoo debe estar configurado")
+	}
+	if f.odooClient.UID() == 0 {
+		if err := f.odooClient.Authenticate(ctx); err != nil {
+			return fmt.Errorf("error autenticando con Odoo: %w", err)
+		}
+	}
+	return nil
+}
+
+// planLeave compara una ausencia con la versión publicada por última vez
+func (f *TimeOffFlow) planLeave(ctx context.Context, tenant string, leave *odoo.HrLeave) (*Change, error) {
+	fields := leaveFields(leave)
+	change := &Change{
+		Ref:         leaveRef(leave.ID),
+		Target:      employeeRef(leave.EmployeeID),
+		Description: fmt.Sprintf("%s %s → %s", leave.LeaveType, leave.DateFrom, leave.DateTo),
+		payload:     &leavePayload{leave: leave, fields: fields},
+	}
+
+	published, err := f.repo.GetWatermark(ctx, tenant, leaveWatermark(leave.ID))
+	if errors.Is(err, repository.ErrNotFound) {
+		change.Action = ActionCreate
+		change.Fields = diffFields(nil, fields)
+		return change, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var before map[string]string
+	if err := json.Unmarshal([]byte(published.Value), &before); err != nil {
+		// Una versión ilegible se reemplaza publicando la actual
+		before = nil
+	}
+	change.Fields = diffFields(before, fields)
+	if len(change.Fields) == 0 {
+		change.Action = ActionSkip
+		change.Reason = "sin cambios desde la última publicación"
+		change.payload = nil
+		return change, nil
+	}
+	change.Action = ActionUpdate
+	return change, nil
+}
+
+// Apply implementa Flow: publica las ausencias que cambiaron y guarda la versión publicada
+func (f *TimeOffFlow) Apply(ctx context.Context, run *Run, plan *Plan) error {
+	state, _ := plan.state.(*timeOffState)
+
+	for _, change := range plan.Changes {
+		if err := checkpoint(ctx); err != nil {
+			// No se avanza el watermark: la próxima ejecución retoma desde el último punto guardado
+			return err
+		}
+
+		payload, _ := change.payload.(*leavePayload)
+		if change.Action == ActionSkip || payload == nil {
+			run.Skipped()
+			run.Logf(ctx, repository.LevelDebug, change.Ref, "omitido: %s", change.Reason)
+			continue
+		}
+
+		run.Notify(ctx, change.Ref, notify.LeaveUpdated, newLeaveEvent(payload.leave, change.Fields))
+		published, err := json.Marshal(payload.fields)
+		if err != nil {
+			return err
+		}
+		if err := f.repo.SetWatermark(ctx, run.Tenant, leaveWatermark(payload.leave.ID), string(published)); err != nil {
+			return err
+		}
+		if change.Action == ActionCreate {
+			run.Created()
+		} else {
+			run.Updated()
+		}
+		run.Logf(ctx, repository.LevelInfo, change.Ref, "publicada (%s, %s)", payload.leave.LeaveType, payload.leave.State)
+	}
+
+	if state != nil {
+		if err := f.repo.SetWatermark(ctx, run.Tenant, FlowTimeOff, state.watermark.UTC().Format(time.RFC3339Nano)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLeaveEvent arma el evento de una ausencia modificada
+func newLeaveEvent(leave *odoo.HrLeave, changes []FieldChange) *LeaveEvent {
+	changed := make([]string, 0, len(changes))
+	for _, c := range changes {
+		changed = append(changed, c.Field)
+	}
+	sort.Strings(changed)
+	return &LeaveEvent{
+		LeaveID:       leave.ID,
+		EmployeeID:    leave.EmployeeID,
+		LeaveTypeID:   leave.LeaveTypeID,
+		LeaveType:     leave.LeaveType,
+		State:         leave.State,
+		DateFrom:      leave.DateFrom,
+		DateTo:        leave.DateTo,
+		NumberOfDays:  leave.NumberOfDays,
+		ChangedFields: changed,
+	}
+}
+
+// leaveFields devuelve los campos de una ausencia que se comparan entre publicaciones
+func leaveFields(leave *odoo.HrLeave) map[string]string {
+	return map[string]string{
+		"employee_id":    strconv.Itoa(leave.EmployeeID),
+		"leave_type":     leave.LeaveType,
+		"state":          leave.State,
+		"date_from":      leave.DateFrom,
+		"date_to":        leave.DateTo,
+		"number_of_days": strconv.FormatFloat(leave.NumberOfDays, 'f', -1, 64),
+	}
+}
+
+// leaveWatermark es la clave con que se guarda la última versión publicada de una ausencia
+func leaveWatermark(id int) string {
+	return FlowTimeOff + "/" + leaveRef(id)
+}
+
+// leaveRef identifica una ausencia de Odoo
+func leaveRef(id int) string {
+	return "hr.leave:" + strconv.Itoa(id)
+}
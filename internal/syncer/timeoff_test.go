@@ -0,0 +1,156 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// recordingNotifier guarda los eventos publicados
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []*LeaveEvent
+}
+
+func (n *recordingNotifier) Publish(ctx context.Context, tenant, eventType string, data interface{}) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if event, ok := data.(*LeaveEvent); ok && eventType == notify.LeaveUpdated {
+		n.events = append(n.events, event)
+	}
+	return nil
+}
+
+func (n *recordingNotifier) take() []*LeaveEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	events := n.events
+	n.events = nil
+	return events
+}
+
+// TestTimeOffFlowPublishesChanges verifica que una ausencia se publique la primera vez y cada vez que
+// cambia, pero no al recibir de nuevo la misma versión
+func TestTimeOffFlowPublishesChanges(t *testing.T) {
+	ctx := context.Background()
+	if err := logging.Setup(io.Discard, "text"); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	leave := map[string]interface{}{
+		"id": 5, "employee_id": []interface{}{7, "Juan Pérez Soto"}, "holiday_status_id": []interface{}{3, "Vacaciones"},
+		"state": "confirm", "request_date_from": "2026-01-05", "request_date_to": "2026-01-09",
+		"number_of_days": 5, "write_date": "2026-01-02 10:00:00",
+	}
+	odooServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Params struct {
+				Service string `json:"service"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		var result interface{} = 2
+		if request.Params.Service == "object" {
+			// hr.leave.search_read: solo existe la ausencia 5
+			mu.Lock()
+			result = []map[string]interface{}{leave}
+			mu.Unlock()
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer odooServer.Close()
+
+	repo, err := repository.NewSQLite(filepath.Join(t.TempDir(), "sync.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	if _, err := repo.Migrator().Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &recordingNotifier{}
+	engine := NewEngine(repo)
+	engine.SetNotifier(notifier)
+	engine.SetFlows(NewTimeOffFlow(
+		odoo.NewClient(&odoo.Config{URL: odooServer.URL, Database: "acme", Username: "admin", Password: secret.New("secret")}),
+		repo,
+	))
+
+	tests := []struct {
+		name    string
+		change  func(map[string]interface{})
+		created int
+		updated int
+		changed []string
+	}{
+		{"primera publicación", nil, 1, 0, []string{"date_from", "date_to", "employee_id", "leave_type", "number_of_days", "state"}},
+		{"sin cambios", nil, 0, 0, nil},
+		{"ausencia aprobada", func(l map[string]interface{}) { l["state"] = "validate" }, 0, 1, []string{"state"}},
+		{"después de publicar", nil, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		if tt.change != nil {
+			mu.Lock()
+			tt.change(leave)
+			mu.Unlock()
+		}
+		// La ausencia 9 no existe en Odoo: se omite
+		record, err := engine.NewTargetedRun(ctx, "acme", FlowTimeOff, []int{5, 9})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.ExecuteRun(ctx, record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Created != tt.created || record.Updated != tt.updated {
+			t.Errorf("%s: %d creadas y %d actualizadas, se esperaban %d y %d", tt.name, record.Created, record.Updated, tt.created, tt.updated)
+		}
+		events := notifier.take()
+		if tt.changed == nil {
+			if len(events) != 0 {
+				t.Errorf("%s: se publicaron %d eventos, no se esperaba ninguno", tt.name, len(events))
+			}
+			continue
+		}
+		if len(events) != 1 {
+			t.Errorf("%s: se publicaron %d eventos, se esperaba 1", tt.name, len(events))
+			continue
+		}
+		if event := events[0]; event.LeaveID != 5 || event.EmployeeID != 7 || !reflect.DeepEqual(event.ChangedFields, tt.changed) {
+			t.Errorf("%s: evento %+v, se esperaban los campos %v", tt.name, event, tt.changed)
+		}
+	}
+
+	// La ejecución completa avanza el watermark hasta la última modificación vista
+	if err := repo.SetWatermark(ctx, "acme", FlowTimeOff, "2026-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	record, err := engine.Execute(ctx, "acme", FlowTimeOff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Created != 0 || record.Updated != 0 {
+		t.Errorf("ejecución completa: %d creadas y %d actualizadas, se esperaba ninguna", record.Created, record.Updated)
+	}
+	watermark, err := repo.GetWatermark(ctx, "acme", FlowTimeOff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if watermark.Value != "2026-01-02T10:00:00Z" {
+		t.Errorf("watermark = %q, se esperaba 2026-01-02T10:00:00Z", watermark.Value)
+	}
+}
@@ -0,0 +1,258 @@
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/webhook"
+)
+
+// DefaultID es el tenant que se crea desde las variables de entorno cuando no hay TENANTS_FILE
+const DefaultID = "default"
+
+// idPattern valida los identificadores de tenant (se usan en rutas /t/{tenant}/ y en la base de datos)
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Config es la configuración de un cliente (tenant): sus credenciales de Odoo y Quickpass,
+// los secretos de sus webhooks, cómo se sincroniza y qué campos se envían a Quickpass
+type Config struct {
+	ID            string              `json:"id"`
+	Name          string              `json:"name"`
+	Disabled      bool                `json:"disabled,omitempty"`
+	Odoo          *OdooSettings       `json:"odoo,omitempty"`
+	Quickpass     *QuickpassSettings  `json:"quickpass,omitempty"`
+	Webhooks      WebhookSecrets      `json:"webhooks"`
+	Sync          SyncSettings        `json:"sync"`
+	FieldMappings syncer.FieldMapping `json:"field_mappings,omitempty"`
+}
+
+// OdooSettings son las credenciales de Odoo de un tenant (API Key recomendada sobre usuario/contraseña)
+// Los secretos se leen en claro del JSON pero se serializan enmascarados (ver secret.Value)
+type OdooSettings struct {
+	URL      string       `json:"url"`
+	Database string       `json:"database"`
+	Username string       `json:"username,omitempty"`
+	Password secret.Value `json:"password"`
+	APIKey   secret.Value `json:"api_key"`
+}
+
+// QuickpassSettings son las credenciales de la API de Quickpass de un tenant
+type QuickpassSettings struct {
+	URL            string       `json:"url"`
+	APIKey         secret.Value `json:"api_key"`
+	APISecret      secret.Value `json:"api_secret"`
+	TimeoutSeconds int          `json:"timeout_seconds,omitempty"`
+}
+
+// WebhookSecrets son los secretos de los webhooks entrantes del tenant; vacío deshabilita el webhook
+type WebhookSecrets struct {
+	OdooSecret      secret.Value `json:"odoo_secret"`
+	QuickpassSecret secret.Value `json:"quickpass_secret"`
+}
+
+// SyncSettings ajusta la sincronización del tenant; los valores en cero usan la configuración global
+type SyncSettings struct {
+	// Flows son los flujos habilitados (vacío: todos)
+	Flows []string `json:"flows,omitempty"`
+	// MaxRetries y RetryDelaySeconds reemplazan MAX_RETRIES y RETRY_DELAY
+	MaxRetries        int `json:"max_retries,omitempty"`
+	RetryDelaySeconds int `json:"retry_delay_seconds,omitempty"`
+}
+
+// File es el archivo de tenants (TENANTS_FILE)
+type File struct {
+	// DefaultTenant atiende las peticiones que no indican tenant (vacío: el primero de la lista)
+	DefaultTenant string    `json:"default_tenant,omitempty"`
+	Tenants       []*Config `json:"tenants"`
+}
+
+// knownFlows son los flujos que se pueden habilitar por tenant
+var knownFlows = map[string]bool{
+	syncer.FlowEmployees:  true,
+	syncer.FlowAttendance: true,
+	syncer.FlowTimeOff:    true,
+}
+
+// Validate verifica la configuración de un tenant
+func (c *Config) Validate() error {
+	if !idPattern.MatchString(c.ID) {
+		return fmt.Errorf("id de tenant inválido %q: use minúsculas, números, - y _ (máximo 63)", c.ID)
+	}
+	if c.Odoo == nil {
+		return fmt.Errorf("tenant %s: falta la configuración de Odoo", c.ID)
+	}
+	if c.Odoo.URL == "" || c.Odoo.Database == "" {
+		return fmt.Errorf("tenant %s: odoo.url y odoo.database son obligatorios", c.ID)
+	}
+	if c.Odoo.APIKey.IsZero() && (c.Odoo.Username == "" || c.Odoo.Password.IsZero()) {
+		return fmt.Errorf("tenant %s: debe configurar odoo.api_key o odoo.username+odoo.password", c.ID)
+	}
+	if c.Quickpass == nil || c.Quickpass.URL == "" || c.Quickpass.APIKey.IsZero() {
+		return fmt.Errorf("tenant %s: quickpass.url y quickpass.api_key son obligatorios", c.ID)
+	}
+	if c.Quickpass.TimeoutSeconds < 0 {
+		return fmt.Errorf("tenant %s: quickpass.timeout_seconds inválido: %d", c.ID, c.Quickpass.TimeoutSeconds)
+	}
+	for _, flow := range c.Sync.Flows {
+		if !knownFlows[flow] {
+			return fmt.Errorf("tenant %s: flujo de sincronización desconocido: %s", c.ID, flow)
+		}
+	}
+	if c.Sync.MaxRetries < 0 || c.Sync.RetryDelaySeconds < 0 {
+		return fmt.Errorf("tenant %s: sync.max_retries y sync.retry_delay_seconds no pueden ser negativos", c.ID)
+	}
+	if err := c.FieldMappings.Validate(); err != nil {
+		return fmt.Errorf("tenant %s: %w", c.ID, err)
+	}
+	return nil
+}
+
+// FlowEnabled indica si el flujo está habilitado para el tenant
+func (c *Config) FlowEnabled(flow string) bool {
+	if len(c.Sync.Flows) == 0 {
+		return true
+	}
+	for _, name := range c.Sync.Flows {
+		if name == flow {
+			return true
+		}
+	}
+	return false
+}
+
+// odooConfig devuelve la configuración del cliente Odoo del tenant (nil si no tiene)
+func (c *Config) odooConfig() *odoo.Config {
+	if c.Odoo == nil {
+		return nil
+	}
+	return &odoo.Config{
+		URL:        c.Odoo.URL,
+		Database:   c.Odoo.Database,
+		Username:   c.Odoo.Username,
+		Password:   c.Odoo.Password,
+		APIKey:     c.Odoo.APIKey,
+		ClientID:   c.ID,
+		ClientName: c.Name,
+	}
+}
+
+// quickpassConfig devuelve la configuración del cliente Quickpass del tenant (nil si no tiene)
+func (c *Config) quickpassConfig() *quickpass.Config {
+	if c.Quickpass == nil {
+		return nil
+	}
+	timeout := 30 * time.Second
+	if c.Quickpass.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.Quickpass.TimeoutSeconds) * time.Second
+	}
+	return &quickpass.Config{
+		URL:       c.Quickpass.URL,
+		APIKey:    c.Quickpass.APIKey,
+		APISecret: c.Quickpass.APISecret,
+		Timeout:   timeout,
+	}
+}
+
+// Validate verifica todos los tenants del archivo, que sus IDs no se repitan y que exista el tenant por defecto
+func (f *File) Validate() error {
+	if len(f.Tenants) == 0 {
+		return errors.New("el archivo de tenants no define ningún tenant")
+	}
+	seen := map[string]bool{}
+	for i, config := range f.Tenants {
+		if config == nil {
+			return fmt.Errorf("tenant #%d vacío", i+1)
+		}
+		if err := config.Validate(); err != nil {
+			return err
+		}
+		if seen[config.ID] {
+			return fmt.Errorf("tenant %s repetido", config.ID)
+		}
+		seen[config.ID] = true
+	}
+	if f.DefaultTenant != "" && !seen[f.DefaultTenant] {
+		return fmt.Errorf("default_tenant %s no está en la lista de tenants", f.DefaultTenant)
+	}
+	return nil
+}
+
+// DefaultID devuelve el tenant que atiende las peticiones sin tenant
+func (f *File) DefaultID() string {
+	if f.DefaultTenant != "" || len(f.Tenants) == 0 {
+		return f.DefaultTenant
+	}
+	return f.Tenants[0].ID
+}
+
+// LoadFile lee y valida un archivo de tenants en formato JSON
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de tenants: %w", err)
+	}
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error al leer el archivo de tenants %s: %w", path, err)
+	}
+	for _, config := range file.Tenants {
+		if config != nil && config.Name == "" {
+			config.Name = config.ID
+		}
+	}
+	if err := file.Validate(); err != nil {
+		return nil, fmt.Errorf("archivo de tenants %s inválido: %w", path, err)
+	}
+	return &file, nil
+}
+
+// Load carga los tenants del archivo indicado o, si path está vacío, crea el tenant "default"
+// con las variables de entorno de Odoo, Quickpass y webhooks (la configuración de un solo cliente)
+func Load(path string, webhooks *webhook.Config) (*File, error) {
+	if path != "" {
+		return LoadFile(path)
+	}
+	return &File{DefaultTenant: DefaultID, Tenants: []*Config{configFromEnv(webhooks)}}, nil
+}
+
+// configFromEnv arma el tenant por defecto; sin Odoo o Quickpass configurados el servidor inicia igual
+func configFromEnv(webhooks *webhook.Config) *Config {
+	config := &Config{ID: DefaultID, Name: "Default Client"}
+
+	if odooConfig, err := odoo.NewConfigFromEnv(); err != nil {
+		logger.Warn("⚠️ Error configurando Odoo: el servidor iniciará sin conexión a Odoo", "error", err)
+	} else {
+		config.Name = odooConfig.ClientName
+		config.Odoo = &OdooSettings{
+			URL:      odooConfig.URL,
+			Database: odooConfig.Database,
+			Username: odooConfig.Username,
+			Password: odooConfig.Password,
+			APIKey:   odooConfig.APIKey,
+		}
+	}
+
+	if quickpassConfig, err := quickpass.NewConfigFromEnv(); err != nil {
+		logger.Warn("⚠️ Error configurando Quickpass: el servidor iniciará sin conexión a Quickpass", "error", err)
+	} else {
+		config.Quickpass = &QuickpassSettings{
+			URL:            quickpassConfig.URL,
+			APIKey:         quickpassConfig.APIKey,
+			APISecret:      quickpassConfig.APISecret,
+			TimeoutSeconds: int(quickpassConfig.Timeout / time.Second),
+		}
+	}
+
+	if webhooks != nil {
+		config.Webhooks = WebhookSecrets{OdooSecret: webhooks.OdooSecret, QuickpassSecret: webhooks.QuickpassSecret}
+	}
+	return config
+}
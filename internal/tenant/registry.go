@@ -0,0 +1,592 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/webhook"
+)
+
+var (
+	// ErrExists se devuelve al crear un tenant con un ID que ya está en uso
+	ErrExists = errors.New("ya existe un tenant con ese id")
+	// ErrNotFound se devuelve al modificar un tenant que no existe
+	ErrNotFound = errors.New("tenant no encontrado")
+	// ErrStatic se devuelve al modificar por la API un tenant definido en TENANTS_FILE o en el entorno
+	ErrStatic = errors.New("el tenant está definido en TENANTS_FILE o en las variables de entorno y no se puede modificar por la API")
+	// ErrNotManaged se devuelve cuando falta la base de datos o TENANTS_ENCRYPTION_KEY para guardar tenants
+	ErrNotManaged = errors.New("la administración de tenants requiere base de datos y TENANTS_ENCRYPTION_KEY")
+)
+
+var logger = logging.For("tenant")
+
+// Tenant agrupa los clientes y procesos de sincronización de un cliente
+// Cada tenant tiene su propio motor, cola de ejecuciones, reintentos y procesador de webhooks,
+// limitados a sus registros: nada de lo que ocurre en un tenant usa las credenciales de otro
+type Tenant struct {
+	ID        string
+	Name      string
+	Config    *Config
+	Odoo      *odoo.Client
+	Quickpass *quickpass.Client
+	Webhooks  *webhook.Config
+	// Managed indica que el tenant se creó con la API de administración y está guardado en la base de datos
+	Managed bool
+
+	// Sin base de datos no hay sincronización: estos campos quedan en nil
+	Identity  *syncer.IdentityResolver
+	Engine    *syncer.Engine
+	Runner    *syncer.Runner
+	Scheduler *syncer.Scheduler
+	Processor *webhook.Processor
+}
+
+// Enabled indica si el tenant atiende peticiones y sincroniza
+func (t *Tenant) Enabled() bool {
+	return !t.Config.Disabled
+}
+
+// start intenta autenticar con Odoo e inicia los procesos en segundo plano del tenant
+func (t *Tenant) start() {
+	t.authenticate()
+	t.run()
+}
+
+// authenticate intenta autenticar con Odoo; si falla, el tenant sigue activo sin conexión
+func (t *Tenant) authenticate() {
+	if t.Odoo == nil {
+		return
+	}
+	if err := t.Odoo.Authenticate(context.Background()); err != nil {
+		logger.Warn("⚠️ Error autenticando con Odoo: el tenant queda activo, pero sin conexión a Odoo", "tenant", t.ID, "error", err)
+	}
+}
+
+// run inicia la cola de ejecuciones, los reintentos y el procesador de webhooks del tenant
+func (t *Tenant) run() {
+	if t.Runner != nil {
+		t.Runner.Start()
+	}
+	t.runWorkers()
+}
+
+// takeOver inicia los procesos de un tenant que reemplaza a otro ya detenido (ver Registry.swap)
+// A diferencia de run no marca como interrumpidas las ejecuciones abiertas: el anterior cerró las suyas
+// y las que quedan las encoló este tenant mientras esperaba; los webhooks que el anterior no llegó a
+// procesar siguen guardados y los retoma el barrido del procesador
+func (t *Tenant) takeOver() {
+	if t.Runner != nil {
+		t.Runner.Resume()
+	}
+	t.runWorkers()
+}
+
+// runWorkers inicia los reintentos, la sincronización periódica y el procesador de webhooks del tenant
+func (t *Tenant) runWorkers() {
+	if t.Engine != nil {
+		t.Engine.DeadLetters().Start()
+	}
+	if t.Scheduler != nil {
+		t.Scheduler.Start()
+	}
+	if t.Processor != nil {
+		t.Processor.Start()
+	}
+}
+
+// stop detiene los procesos en segundo plano del tenant
+func (t *Tenant) stop() {
+	if t.Scheduler != nil {
+		t.Scheduler.Stop()
+	}
+	if t.Processor != nil {
+		t.Processor.Stop()
+	}
+	if t.Engine != nil {
+		t.Engine.DeadLetters().Stop()
+	}
+	if t.Runner != nil {
+		t.Runner.Stop()
+	}
+}
+
+// shutdown detiene los procesos del tenant de forma ordenada: primero la sincronización periódica y los
+// webhooks (que encolan ejecuciones), luego la ejecución en curso, que termina en un punto seguro, y por
+// último los reintentos
+func (t *Tenant) shutdown(ctx context.Context) error {
+	if t.Scheduler != nil {
+		t.Scheduler.Stop()
+	}
+	var errs []error
+	if t.Processor != nil {
+		errs = append(errs, t.Processor.Shutdown(ctx))
+	}
+	if t.Runner != nil {
+		errs = append(errs, t.Runner.Shutdown(ctx))
+	}
+	if t.Engine != nil {
+		errs = append(errs, t.Engine.DeadLetters().Shutdown(ctx))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("tenant %s: %w", t.ID, err)
+	}
+	return nil
+}
+
+// Registry contiene los tenants configurados
+type Registry struct {
+	repo      repository.Repository
+	notifier  syncer.Notifier
+	tolerance time.Duration
+	policy    syncer.RetryPolicy
+	flows     map[string]bool // Flujos habilitados para los tenants que no definen los suyos (nil: todos)
+	interval  time.Duration   // Intervalo de la sincronización periódica (0: deshabilitada)
+	sealer    *secret.Sealer
+
+	// admin serializa las altas, cambios y bajas de tenants
+	admin sync.Mutex
+
+	mu        sync.RWMutex
+	tenants   map[string]*Tenant
+	defaultID string
+	started   bool
+}
+
+// NewRegistry crea un registro vacío; repo y notifier son compartidos por todos los tenants
+// (todos los datos que guardan llevan el tenant) y webhooks aporta la tolerancia de las firmas
+func NewRegistry(repo repository.Repository, notifier syncer.Notifier, webhooks *webhook.Config) *Registry {
+	policy, err := syncer.NewRetryPolicyFromEnv()
+	if err != nil {
+		logger.Warn("⚠️ Política de reintentos inválida; se usa la por defecto", "error", err)
+	}
+	sealer, err := sealerFromEnv()
+	if err != nil {
+		logger.Warn("⚠️ No se podrán administrar tenants por la API", "error", err)
+	}
+	tolerance := webhook.DefaultTolerance
+	if webhooks != nil && webhooks.Tolerance > 0 {
+		tolerance = webhooks.Tolerance
+	}
+	return &Registry{
+		repo:      repo,
+		notifier:  notifier,
+		tolerance: tolerance,
+		policy:    policy,
+		sealer:    sealer,
+		tenants:   map[string]*Tenant{},
+	}
+}
+
+// SetSyncDefaults reemplaza la política de reintentos y los flujos habilitados de los tenants que
+// no definen los suyos y el intervalo de la sincronización periódica (ver config.SyncConfig); debe
+// llamarse antes de Load (ver ApplySyncDefaults)
+func (r *Registry) SetSyncDefaults(policy syncer.RetryPolicy, flows []string, interval time.Duration) {
+	enabled := make(map[string]bool, len(flows))
+	for _, flow := range flows {
+		enabled[flow] = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+	r.flows = enabled
+	r.interval = interval
+}
+
+// syncDefaults devuelve la política de reintentos, los flujos y el intervalo globales vigentes
+func (r *Registry) syncDefaults() (syncer.RetryPolicy, map[string]bool, time.Duration) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.policy, r.flows, r.interval
+}
+
+// Load crea los tenants del archivo (ya validado por LoadFile); debe llamarse antes de Start
+// El tenant "default" armado desde el entorno puede no tener Odoo ni Quickpass configurados
+func (r *Registry) Load(file *File) error {
+	if len(file.Tenants) == 0 {
+		return fmt.Errorf("no hay tenants configurados")
+	}
+
+	tenants := make(map[string]*Tenant, len(file.Tenants))
+	for _, config := range file.Tenants {
+		tenants[config.ID] = r.build(config)
+	}
+
+	if _, ok := tenants[file.DefaultID()]; !ok {
+		return fmt.Errorf("el tenant por defecto %s no está configurado", file.DefaultID())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tenants = tenants
+	r.defaultID = file.DefaultID()
+	return nil
+}
+
+// LoadStored agrega los tenants creados con la API de administración; debe llamarse después de Load
+// Un tenant guardado con el mismo ID que uno de TENANTS_FILE se ignora: el archivo tiene prioridad
+func (r *Registry) LoadStored(ctx context.Context) error {
+	if r.repo == nil {
+		return nil
+	}
+	records, err := r.repo.ListTenants(ctx)
+	if err != nil {
+		return fmt.Errorf("error al leer los tenants guardados: %w", err)
+	}
+	if len(records) > 0 && r.sealer == nil {
+		return fmt.Errorf("hay %d tenants guardados en la base de datos pero TENANTS_ENCRYPTION_KEY no está configurada", len(records))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, record := range records {
+		if _, exists := r.tenants[record.ID]; exists {
+			logger.Warn("⚠️ El tenant está en TENANTS_FILE y en la base de datos; se usa el del archivo", "tenant", record.ID)
+			continue
+		}
+		config, err := decodeRecord(record, r.sealer)
+		if err != nil {
+			return err
+		}
+		t := r.build(config)
+		t.Managed = true
+		r.tenants[t.ID] = t
+	}
+	return nil
+}
+
+// Manageable indica si se pueden crear tenants por la API (ErrNotManaged si no)
+func (r *Registry) Manageable() error {
+	if r.repo == nil || r.sealer == nil {
+		return ErrNotManaged
+	}
+	return nil
+}
+
+// Create guarda un tenant nuevo y lo pone en marcha sin reiniciar el servidor
+// La configuración debe estar validada (Config.Validate y, si se desea, Verify)
+func (r *Registry) Create(ctx context.Context, config *Config) (*Tenant, error) {
+	if err := r.Manageable(); err != nil {
+		return nil, err
+	}
+	r.admin.Lock()
+	defer r.admin.Unlock()
+
+	if _, exists := r.Get(config.ID); exists {
+		return nil, ErrExists
+	}
+	record, err := encodeRecord(config, r.sealer)
+	if err != nil {
+		return nil, err
+	}
+	created, err := r.repo.CreateTenant(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrExists
+	}
+
+	t := r.build(config)
+	t.Managed = true
+	r.swap(config.ID, t)
+	logger.Info("🏢 Tenant creado", "tenant", t.ID)
+	return t, nil
+}
+
+// Update reemplaza la configuración de un tenant guardado
+// El nuevo atiende las peticiones siguientes; el anterior se detiene cuando su ejecución en curso
+// llega a un punto seguro (se cancela si no llega en drainTimeout)
+func (r *Registry) Update(ctx context.Context, config *Config) (*Tenant, error) {
+	if err := r.Manageable(); err != nil {
+		return nil, err
+	}
+	r.admin.Lock()
+	defer r.admin.Unlock()
+
+	if _, err := r.managed(config.ID); err != nil {
+		return nil, err
+	}
+	record, err := encodeRecord(config, r.sealer)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.repo.UpdateTenant(ctx, record); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	t := r.build(config)
+	t.Managed = true
+	r.swap(config.ID, t)
+	logger.Info("🏢 Tenant actualizado", "tenant", t.ID)
+	return t, nil
+}
+
+// Delete detiene un tenant guardado y elimina su configuración
+// Los datos ya sincronizados (mapeos, ejecuciones, auditoría) se conservan en la base de datos
+func (r *Registry) Delete(ctx context.Context, id string) error {
+	if err := r.Manageable(); err != nil {
+		return err
+	}
+	r.admin.Lock()
+	defer r.admin.Unlock()
+
+	if _, err := r.managed(id); err != nil {
+		return err
+	}
+	if err := r.repo.DeleteTenant(ctx, id); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	r.swap(id, nil)
+	logger.Info("🏢 Tenant eliminado", "tenant", id)
+	return nil
+}
+
+// managed devuelve un tenant que se puede modificar por la API
+func (r *Registry) managed(id string) (*Tenant, error) {
+	t, ok := r.Get(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !t.Managed {
+		return nil, ErrStatic
+	}
+	return t, nil
+}
+
+// swap reemplaza el tenant id por next (nil lo quita) y, si el registro está iniciado, detiene de forma
+// ordenada los procesos del anterior (ver drain) y recién entonces inicia los del nuevo, para que nunca
+// trabajen los dos a la vez sobre las mismas ejecuciones, webhooks y reintentos
+// Las peticiones en curso terminan con el tenant anterior; las siguientes ya usan el nuevo, que encola
+// su trabajo y lo procesa apenas el anterior termina
+func (r *Registry) swap(id string, next *Tenant) {
+	// La autenticación con Odoo puede demorar: se hace antes de tomar el lock
+	if next != nil && next.Enabled() && r.isStarted() {
+		next.authenticate()
+	}
+
+	r.mu.Lock()
+	previous, ok := r.tenants[id]
+	running := ok && r.started && previous.Enabled()
+	if next == nil {
+		delete(r.tenants, id)
+	} else {
+		r.tenants[id] = next
+	}
+	r.mu.Unlock()
+
+	// Fuera del lock: detener el anterior espera su ejecución en curso y no debe bloquear a Get
+	if running {
+		r.drain(previous)
+	}
+	if next == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started && next.Enabled() {
+		next.takeOver()
+	}
+}
+
+// drainTimeout es el plazo para que el tenant reemplazado o eliminado termine su ejecución en curso
+const drainTimeout = 30 * time.Second
+
+// drain detiene el tenant reemplazado igual que Shutdown: su ejecución en curso termina en un punto
+// seguro (con su checkpoint) y solo se cancela si no llega a tiempo
+func (r *Registry) drain(t *Tenant) {
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if err := t.shutdown(ctx); err != nil {
+		logger.Warn("⚠️ El tenant anterior no terminó a tiempo", "tenant", t.ID, "error", err)
+	}
+}
+
+// isStarted indica si los procesos en segundo plano están iniciados
+func (r *Registry) isStarted() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.started
+}
+
+// build crea los clientes y el motor de sincronización de un tenant
+func (r *Registry) build(config *Config) *Tenant {
+	t := &Tenant{
+		ID:     config.ID,
+		Name:   config.Name,
+		Config: config,
+		Webhooks: &webhook.Config{
+			OdooSecret:      config.Webhooks.OdooSecret,
+			QuickpassSecret: config.Webhooks.QuickpassSecret,
+			Tolerance:       r.tolerance,
+		},
+	}
+	if odooConfig := config.odooConfig(); odooConfig != nil {
+		t.Odoo = odoo.NewClient(odooConfig)
+	}
+	if quickpassConfig := config.quickpassConfig(); quickpassConfig != nil {
+		t.Quickpass = quickpass.NewClient(quickpassConfig)
+	}
+	if r.repo == nil {
+		return t
+	}
+
+	engine := syncer.NewEngine(r.repo)
+	t.Engine = engine
+	engine.SetTenant(config.ID)
+	if r.notifier != nil {
+		engine.SetNotifier(r.notifier)
+	}
+
+	t.Identity = syncer.NewIdentityResolver(r.repo)
+	t.Runner = syncer.NewRunner(engine, 100)
+	t.Scheduler = syncer.NewScheduler(t.Runner)
+	t.Processor = webhook.NewProcessor(r.repo, engine, t.Runner, syncer.NewTimeOffRecorder(t.Odoo, r.repo), 500)
+	r.configureEngine(t, config)
+	return t
+}
+
+// configureEngine registra en el motor del tenant los flujos habilitados, su política de reintentos y el
+// intervalo de la sincronización periódica
+// Se usa al crear el tenant y al recargar la configuración, sin detener las ejecuciones en curso
+func (r *Registry) configureEngine(t *Tenant, config *Config) {
+	policy, flows, interval := r.syncDefaults()
+	enabled := func(flow string) bool {
+		if len(config.Sync.Flows) > 0 || flows == nil {
+			return config.FlowEnabled(flow)
+		}
+		return flows[flow]
+	}
+
+	var registered []syncer.Flow
+	if enabled(syncer.FlowEmployees) {
+		flow := syncer.NewEmployeeFlow(t.Odoo, t.Quickpass, r.repo)
+		flow.SetFieldMapping(config.FieldMappings)
+		registered = append(registered, flow)
+	}
+	if enabled(syncer.FlowAttendance) {
+		registered = append(registered, syncer.NewAttendanceFlow(t.Odoo, t.Quickpass, r.repo))
+	}
+	if enabled(syncer.FlowTimeOff) {
+		registered = append(registered, syncer.NewTimeOffFlow(t.Odoo, r.repo))
+	}
+	t.Engine.SetFlows(registered...)
+
+	if config.Sync.MaxRetries > 0 {
+		policy.MaxAttempts = config.Sync.MaxRetries
+	}
+	if config.Sync.RetryDelaySeconds > 0 {
+		policy.BaseDelay = time.Duration(config.Sync.RetryDelaySeconds) * time.Second
+	}
+	t.Engine.DeadLetters().SetPolicy(policy)
+	if t.Scheduler != nil {
+		t.Scheduler.SetInterval(interval)
+	}
+}
+
+// Get devuelve un tenant por ID
+func (r *Registry) Get(id string) (*Tenant, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tenants[id]
+	return t, ok
+}
+
+// Default devuelve el tenant que atiende las peticiones que no indican uno
+func (r *Registry) Default() *Tenant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tenants[r.defaultID]
+}
+
+// DefaultID devuelve el ID del tenant por defecto
+func (r *Registry) DefaultID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.defaultID
+}
+
+// List devuelve los tenants ordenados por ID
+func (r *Registry) List() []*Tenant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenants := make([]*Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants
+}
+
+// Start inicia los procesos en segundo plano de los tenants habilitados
+func (r *Registry) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tenants {
+		if t.Enabled() {
+			t.start()
+		}
+	}
+	r.started = true
+	logger.Info("🏢 Tenants cargados", "count", len(r.tenants), "default", r.defaultID)
+}
+
+// Shutdown detiene de forma ordenada los procesos de todos los tenants, en paralelo, hasta que vence ctx
+func (r *Registry) Shutdown(ctx context.Context) error {
+	r.admin.Lock()
+	defer r.admin.Unlock()
+
+	r.mu.Lock()
+	if !r.started {
+		r.mu.Unlock()
+		return nil
+	}
+	r.started = false
+	var running []*Tenant
+	for _, t := range r.tenants {
+		if t.Enabled() {
+			running = append(running, t)
+		}
+	}
+	r.mu.Unlock()
+
+	errs := make([]error, len(running))
+	var wg sync.WaitGroup
+	for i, t := range running {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = t.shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Stop detiene los procesos en segundo plano de todos los tenants
+func (r *Registry) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.started {
+		return
+	}
+	for _, t := range r.tenants {
+		if t.Enabled() {
+			t.stop()
+		}
+	}
+	r.started = false
+}
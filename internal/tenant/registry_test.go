@@ -0,0 +1,284 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/webhook"
+)
+
+// fakeOdoo simula el JSON-RPC de Odoo: autentica con UID 2, encuentra cualquier tipo de ausencia y
+// cuenta las ausencias creadas; con block, la búsqueda del tipo de ausencia espera a que se cierre
+type fakeOdoo struct {
+	*httptest.Server
+	block   chan struct{}
+	waiting chan struct{} // Recibe un valor cuando una búsqueda empieza a esperar
+	leaves  atomic.Int32
+}
+
+func newFakeOdoo(t *testing.T, block chan struct{}) *fakeOdoo {
+	f := &fakeOdoo{block: block, waiting: make(chan struct{}, 1)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Params struct {
+				Service string        `json:"service"`
+				Args    []interface{} `json:"args"`
+			} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var result interface{} = []interface{}{}
+		switch {
+		case request.Params.Service == "common":
+			result = 2
+		case request.Params.Args[3] == "hr.leave.type":
+			if f.block != nil {
+				f.waiting <- struct{}{}
+				<-f.block
+			}
+			result = []int{1}
+		case request.Params.Args[3] == "hr.leave" && request.Params.Args[4] == "create":
+			result = 100 + f.leaves.Add(1)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// blockingFlow es un flujo cuyo plan espera a que se cierre release
+type blockingFlow struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *blockingFlow) Name() string { return "blocking" }
+
+func (f *blockingFlow) Plan(ctx context.Context, tenant string) (*syncer.Plan, error) {
+	if f.started != nil {
+		f.started <- struct{}{}
+	}
+	if f.release != nil {
+		<-f.release
+	}
+	return &syncer.Plan{Tenant: tenant, Flow: f.Name()}, nil
+}
+
+func (f *blockingFlow) Apply(ctx context.Context, run *syncer.Run, plan *syncer.Plan) error {
+	return nil
+}
+
+// newTestRegistry crea un registro iniciado, con SQLite y administración de tenants habilitada
+func newTestRegistry(t *testing.T) (*Registry, repository.Repository) {
+	t.Helper()
+	t.Setenv("TENANTS_ENCRYPTION_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err := logging.Setup(io.Discard, "text"); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repository.NewSQLite(filepath.Join(t.TempDir(), "sync.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.Migrator().Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry(repo, nil, nil)
+	if err := r.Load(&File{Tenants: []*Config{{ID: DefaultID, Name: "Default"}}}); err != nil {
+		t.Fatal(err)
+	}
+	r.Start()
+	t.Cleanup(func() { r.Shutdown(context.Background()) })
+	return r, repo
+}
+
+func acmeConfig(odooURL string) *Config {
+	return &Config{
+		ID:   "acme",
+		Name: "ACME",
+		Odoo: &OdooSettings{URL: odooURL, Database: "acme", Username: "admin", Password: secret.New("secret")},
+	}
+}
+
+// waitFor espera una señal o falla la prueba
+func waitFor(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("tiempo agotado esperando %s", what)
+	}
+}
+
+// eventually reintenta check hasta que devuelva true o falla la prueba
+func eventually(t *testing.T, what string, check func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !check() {
+		if time.Now().After(deadline) {
+			t.Fatalf("tiempo agotado esperando %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRegistryUpdateWhileBusy reemplaza un tenant mientras tiene una ejecución y un webhook en curso:
+// el nuevo no debe empezar a trabajar hasta que el anterior termine, para no repetir el webhook
+// ni cerrar como interrumpida la ejecución del anterior
+func TestRegistryUpdateWhileBusy(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestRegistry(t)
+
+	release := make(chan struct{})
+	released := false
+	defer func() {
+		if !released {
+			close(release)
+		}
+	}()
+	before := newFakeOdoo(t, release)
+	after := newFakeOdoo(t, nil)
+
+	previous, err := r.Create(ctx, acmeConfig(before.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Una ejecución en curso
+	flow := &blockingFlow{started: make(chan struct{}, 1), release: release}
+	timeOff, _ := previous.Engine.Flow(syncer.FlowTimeOff)
+	previous.Engine.SetFlows(flow, timeOff)
+	run, err := previous.Runner.Enqueue(ctx, "acme", flow.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, flow.started, "la ejecución")
+
+	// Un webhook en curso: una solicitud de tiempo libre que espera a Odoo
+	if err := repo.SaveEmployeeMapping(ctx, &repository.EmployeeMapping{
+		Tenant: "acme", OdooEmployeeID: 7, QuickpassUserID: "u-7", RUT: "12345678-5", MatchMethod: repository.MatchByRUT,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	event := &repository.WebhookEvent{
+		Source: webhook.SourceQuickpass, Tenant: "acme", EventID: "evt-1", EventType: webhook.QuickpassTimeOffRequested,
+		Payload: json.RawMessage(`{"id": "evt-1", "type": "time_off.requested", "data": {"id": "req-1", "user_id": "u-7",
+			"leave_type": "Vacaciones", "date_from": "2026-01-05", "date_to": "2026-01-09"}}`),
+	}
+	if _, err := repo.CreateWebhookEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	previous.Processor.Submit(event)
+	waitFor(t, before.waiting, "el webhook")
+
+	updated := make(chan error, 1)
+	go func() {
+		_, err := r.Update(ctx, acmeConfig(after.URL))
+		updated <- err
+	}()
+
+	// Las peticiones siguientes ya usan el nuevo tenant, que encola pero no procesa mientras el anterior termina
+	var next *Tenant
+	eventually(t, "el reemplazo del tenant", func() bool {
+		next, _ = r.Get("acme")
+		return next != previous
+	})
+	if err := next.Runner.Health(); err == nil {
+		t.Fatal("el nuevo tenant empezó a procesar antes de que el anterior terminara")
+	}
+	next.Engine.SetFlows(&blockingFlow{})
+	queued, err := next.Runner.Enqueue(ctx, "acme", "blocking")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+	released = true
+	if err := <-updated; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := next.Runner.Health(); err != nil {
+		t.Errorf("el nuevo tenant no quedó en marcha: %v", err)
+	}
+	for _, id := range []int64{run.ID, queued.ID} {
+		eventually(t, "el fin de las ejecuciones", func() bool {
+			record, err := repo.GetSyncRun(ctx, id)
+			return err == nil && record.Status == repository.RunStatusSucceeded
+		})
+	}
+	stored, err := repo.GetWebhookEvent(ctx, event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != repository.WebhookProcessed || stored.Attempts != 1 {
+		t.Errorf("webhook: estado %s con %d intentos, se esperaba processed con 1", stored.Status, stored.Attempts)
+	}
+	if created := before.leaves.Load() + after.leaves.Load(); created != 1 {
+		t.Errorf("se crearon %d ausencias para el mismo webhook, se esperaba 1", created)
+	}
+}
+
+// TestRegistryDeleteWhileBusy elimina un tenant con una ejecución en curso: Delete espera a que termine
+func TestRegistryDeleteWhileBusy(t *testing.T) {
+	ctx := context.Background()
+	r, repo := newTestRegistry(t)
+
+	current, err := r.Create(ctx, acmeConfig(newFakeOdoo(t, nil).URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flow := &blockingFlow{started: make(chan struct{}, 1), release: make(chan struct{})}
+	current.Engine.SetFlows(flow)
+	run, err := current.Runner.Enqueue(ctx, "acme", flow.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, flow.started, "la ejecución")
+
+	deleted := make(chan error, 1)
+	go func() { deleted <- r.Delete(ctx, "acme") }()
+
+	eventually(t, "la baja del tenant", func() bool {
+		_, ok := r.Get("acme")
+		return !ok
+	})
+	select {
+	case err := <-deleted:
+		t.Fatalf("Delete() = %v antes de que terminara la ejecución en curso", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(flow.release)
+	if err := <-deleted; err != nil {
+		t.Fatal(err)
+	}
+	record, err := repo.GetSyncRun(ctx, run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != repository.RunStatusSucceeded {
+		t.Errorf("estado de la ejecución = %s, se esperaba %s", record.Status, repository.RunStatusSucceeded)
+	}
+	if _, err := repo.GetTenant(ctx, "acme"); err == nil {
+		t.Error("la configuración del tenant sigue guardada")
+	}
+	if err := r.Delete(ctx, "acme"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() de un tenant ya eliminado = %v, se esperaba %v", err, ErrNotFound)
+	}
+}
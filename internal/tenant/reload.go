@@ -0,0 +1,84 @@
+package tenant
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+)
+
+// ApplySyncDefaults cambia en caliente la política de reintentos, los flujos y el intervalo globales
+// y reconfigura el motor y la sincronización periódica de cada tenant sin detener sus ejecuciones en curso
+func (r *Registry) ApplySyncDefaults(policy syncer.RetryPolicy, flows []string, interval time.Duration) {
+	r.admin.Lock()
+	defer r.admin.Unlock()
+
+	r.SetSyncDefaults(policy, flows, interval)
+	for _, t := range r.List() {
+		if t.Engine != nil {
+			r.configureEngine(t, t.Config)
+		}
+	}
+}
+
+// Reload aplica en caliente un archivo de tenants ya validado (ver LoadFile)
+// El nombre, los flujos, los reintentos y el mapeo de campos de los tenants del archivo se aplican sin
+// detener sus ejecuciones; las credenciales, los webhooks, el estado y las altas o bajas de tenants
+// requieren reiniciar y se devuelven como pendientes sin aplicarse
+func (r *Registry) Reload(file *File) []string {
+	r.admin.Lock()
+	defer r.admin.Unlock()
+
+	var pending []string
+	if file.DefaultID() != r.DefaultID() {
+		pending = append(pending, fmt.Sprintf("tenant por defecto %s", file.DefaultID()))
+	}
+
+	inFile := map[string]bool{}
+	for _, next := range file.Tenants {
+		inFile[next.ID] = true
+		current, ok := r.Get(next.ID)
+		switch {
+		case !ok:
+			pending = append(pending, fmt.Sprintf("tenant %s agregado", next.ID))
+			continue
+		case current.Managed:
+			pending = append(pending, fmt.Sprintf("tenant %s agregado (ya existe en la base de datos)", next.ID))
+			continue
+		case !sameConnection(current.Config, next):
+			pending = append(pending, fmt.Sprintf("tenant %s: credenciales, webhooks o estado", next.ID))
+			continue
+		}
+
+		// Se reemplaza el tenant por una copia con la nueva configuración: quien ya tiene el anterior
+		// lo sigue usando y ambos comparten clientes, motor y procesos en segundo plano
+		reloaded := *current
+		reloaded.Name = next.Name
+		reloaded.Config = next
+		if reloaded.Engine != nil {
+			r.configureEngine(&reloaded, next)
+		}
+		r.mu.Lock()
+		r.tenants[next.ID] = &reloaded
+		r.mu.Unlock()
+	}
+
+	for _, t := range r.List() {
+		if !t.Managed && !inFile[t.ID] {
+			pending = append(pending, fmt.Sprintf("tenant %s eliminado", t.ID))
+		}
+	}
+	return pending
+}
+
+// sameConnection indica si dos configuraciones de un tenant solo difieren en lo que se puede recargar
+func sameConnection(current, next *Config) bool {
+	a, b := *current, *next
+	for _, c := range []*Config{&a, &b} {
+		c.Name = ""
+		c.Sync = SyncSettings{}
+		c.FieldMappings = nil
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,122 @@
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// sealerFromEnv crea el cifrador de los tenants guardados con TENANTS_ENCRYPTION_KEY (32 bytes en base64)
+// Sin la clave devuelve nil: los tenants solo se pueden configurar con TENANTS_FILE
+func sealerFromEnv() (*secret.Sealer, error) {
+	return secret.SealerFromEnv("TENANTS_ENCRYPTION_KEY")
+}
+
+// secretFields devuelve punteros a los campos secretos de la configuración, por nombre
+func (c *Config) secretFields() map[string]*secret.Value {
+	fields := map[string]*secret.Value{
+		"webhooks.odoo_secret":      &c.Webhooks.OdooSecret,
+		"webhooks.quickpass_secret": &c.Webhooks.QuickpassSecret,
+	}
+	if c.Odoo != nil {
+		fields["odoo.password"] = &c.Odoo.Password
+		fields["odoo.api_key"] = &c.Odoo.APIKey
+	}
+	if c.Quickpass != nil {
+		fields["quickpass.api_key"] = &c.Quickpass.APIKey
+		fields["quickpass.api_secret"] = &c.Quickpass.APISecret
+	}
+	return fields
+}
+
+// clone devuelve una copia independiente de la configuración
+func (c *Config) clone() *Config {
+	copied := *c
+	if c.Odoo != nil {
+		odoo := *c.Odoo
+		copied.Odoo = &odoo
+	}
+	if c.Quickpass != nil {
+		quickpass := *c.Quickpass
+		copied.Quickpass = &quickpass
+	}
+	copied.Sync.Flows = append([]string(nil), c.Sync.Flows...)
+	if c.FieldMappings != nil {
+		copied.FieldMappings = make(map[string]string, len(c.FieldMappings))
+		for target, source := range c.FieldMappings {
+			copied.FieldMappings[target] = source
+		}
+	}
+	return &copied
+}
+
+// KeepSecrets completa los secretos vacíos o enmascarados (secret.Mask) con los de la configuración anterior
+func (c *Config) KeepSecrets(previous *Config) {
+	if previous == nil {
+		return
+	}
+	old := previous.secretFields()
+	for name, field := range c.secretFields() {
+		if !field.IsZero() && field.Reveal() != secret.Mask {
+			continue
+		}
+		*field = secret.Value{}
+		if value, ok := old[name]; ok {
+			*field = *value
+		}
+	}
+}
+
+// encodeRecord separa la configuración en JSON sin secretos y los secretos cifrados
+func encodeRecord(config *Config, sealer *secret.Sealer) (*repository.TenantRecord, error) {
+	public := config.clone()
+	secrets := map[string]string{}
+	for name, field := range public.secretFields() {
+		if !field.IsZero() {
+			secrets[name] = field.Reveal()
+			*field = secret.Value{}
+		}
+	}
+
+	configJSON, err := json.Marshal(public)
+	if err != nil {
+		return nil, fmt.Errorf("error al serializar el tenant %s: %w", config.ID, err)
+	}
+	secretsJSON, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, fmt.Errorf("error al serializar los secretos del tenant %s: %w", config.ID, err)
+	}
+	sealed, err := sealer.Seal(secretsJSON, config.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error al cifrar los secretos del tenant %s: %w", config.ID, err)
+	}
+	return &repository.TenantRecord{ID: config.ID, Config: string(configJSON), Secrets: sealed}, nil
+}
+
+// decodeRecord arma la configuración de un tenant guardado, con sus secretos descifrados
+func decodeRecord(record *repository.TenantRecord, sealer *secret.Sealer) (*Config, error) {
+	var config Config
+	if err := json.Unmarshal([]byte(record.Config), &config); err != nil {
+		return nil, fmt.Errorf("error al leer el tenant %s: %w", record.ID, err)
+	}
+	if config.ID != record.ID {
+		return nil, fmt.Errorf("el tenant %s guardado tiene otro id en su configuración: %s", record.ID, config.ID)
+	}
+
+	secrets := map[string]string{}
+	if record.Secrets != "" {
+		plaintext, err := sealer.Open(record.Secrets, record.ID)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", record.ID, err)
+		}
+		if err := json.Unmarshal(plaintext, &secrets); err != nil {
+			return nil, fmt.Errorf("error al leer los secretos del tenant %s: %w", record.ID, err)
+		}
+	}
+	for name, field := range config.secretFields() {
+		*field = secret.New(secrets[name])
+	}
+	return &config, nil
+}
@@ -0,0 +1,56 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/odoo"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+)
+
+// CredentialError indica qué credenciales del tenant no pasaron la verificación
+type CredentialError struct {
+	Odoo      string `json:"odoo,omitempty"`
+	Quickpass string `json:"quickpass,omitempty"`
+}
+
+func (e *CredentialError) Error() string {
+	var problems []string
+	if e.Odoo != "" {
+		problems = append(problems, "odoo: "+e.Odoo)
+	}
+	if e.Quickpass != "" {
+		problems = append(problems, "quickpass: "+e.Quickpass)
+	}
+	return fmt.Sprintf("credenciales inválidas (%s)", strings.Join(problems, "; "))
+}
+
+// Verify autentica con Odoo y Quickpass usando las credenciales del tenant y hace una lectura de prueba
+// en cada uno (contar hr.employee y leer una página de usuarios) para confirmar que tienen acceso
+// Devuelve *CredentialError si alguna falla
+func Verify(ctx context.Context, config *Config) error {
+	result := &CredentialError{}
+
+	if odooConfig := config.odooConfig(); odooConfig == nil {
+		result.Odoo = "no configurado"
+	} else {
+		client := odoo.NewClient(odooConfig)
+		if err := client.Authenticate(ctx); err != nil {
+			result.Odoo = err.Error()
+		} else if _, err := client.ExecuteKW(ctx, "hr.employee", "search_count", []interface{}{[]interface{}{}}, nil); err != nil {
+			result.Odoo = fmt.Sprintf("sin acceso a hr.employee: %v", err)
+		}
+	}
+
+	if quickpassConfig := config.quickpassConfig(); quickpassConfig == nil {
+		result.Quickpass = "no configurado"
+	} else if err := quickpass.NewClient(quickpassConfig).Verify(ctx); err != nil {
+		result.Quickpass = err.Error()
+	}
+
+	if result.Odoo != "" || result.Quickpass != "" {
+		return result
+	}
+	return nil
+}
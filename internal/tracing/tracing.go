@@ -0,0 +1,124 @@
+// Package tracing configura las trazas OpenTelemetry del servicio: un span por petición HTTP, por
+// llamada a Odoo (execute_kw con su modelo, método y cantidad de registros) y por llamada a Quickpass,
+// exportados por OTLP/HTTP a un colector
+//
+// El contexto de la traza se propaga con W3C Trace Context (traceparent y tracestate) al recibir
+// peticiones y al llamar a Odoo y Quickpass. Con las trazas deshabilitadas no se exporta nada, pero el
+// traceparent recibido se sigue propagando para no cortar la traza de quien llama
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/redact"
+)
+
+// instrumentation es el prefijo del nombre de los tracers de cada paquete
+const instrumentation = "github.com/IamNewInThis/odoo-quickpass-sync/internal/"
+
+var logger = logging.For("tracing")
+
+// Config es la exportación de trazas a un colector OTLP/HTTP
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// Endpoint es la URL base del colector (ej: http://localhost:4318); las trazas se envían a
+	// <Endpoint>/v1/traces. Vacío usa el valor por defecto del SDK (http://localhost:4318)
+	Endpoint string `yaml:"endpoint"`
+	// ServiceName identifica al servicio en las trazas
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio es la fracción de trazas nuevas que se registran (0 a 1); las que llegan con
+	// traceparent respetan la decisión del servicio que llama
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Setup define la propagación W3C y, si las trazas están habilitadas, el exportador OTLP/HTTP
+// Devuelve la función que envía las trazas pendientes y detiene el exportador al apagar el servicio
+func Setup(ctx context.Context, config Config, environment string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var options []otlptracehttp.Option
+	if config.Endpoint != "" {
+		endpoint, err := url.Parse(config.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("error al leer la URL del colector de trazas: %w", err)
+		}
+		options = append(options,
+			otlptracehttp.WithEndpoint(endpoint.Host),
+			otlptracehttp.WithURLPath(strings.TrimRight(endpoint.Path, "/")+"/v1/traces"))
+		if endpoint.Scheme == "http" {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el exportador de trazas: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", config.ServiceName),
+		attribute.String("deployment.environment.name", environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error al describir el servicio en las trazas: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	// Los errores del exportador (ej: colector caído) no deben interrumpir el servicio, solo quedar en el log
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("⚠️ Error exportando trazas", "error", err)
+	}))
+
+	logger.Info("🔭 Trazas OpenTelemetry habilitadas", "endpoint", config.Endpoint, "service", config.ServiceName,
+		"sample_ratio", config.SampleRatio)
+	return provider.Shutdown, nil
+}
+
+// Tracer devuelve el tracer de un paquete (ej: Tracer("odoo"))
+// Se puede declarar como variable de paquete: usa el proveedor que defina Setup aunque se llame después
+func Tracer(pkg string) trace.Tracer {
+	return otel.Tracer(instrumentation + pkg)
+}
+
+// Inject agrega a los encabezados de una petición saliente el contexto de la traza (traceparent)
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract devuelve ctx con el contexto de la traza recibido en los encabezados de una petición
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// End cierra el span y, si err no es nil, lo marca como fallido
+// El mensaje se registra con los RUT y emails enmascarados, igual que en los logs
+func End(span trace.Span, err error) {
+	if err != nil {
+		message := redact.Text(err.Error())
+		span.RecordError(errors.New(message))
+		span.SetStatus(codes.Error, message)
+	}
+	span.End()
+}
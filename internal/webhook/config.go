@@ -0,0 +1,21 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/secret"
+)
+
+// DefaultTolerance es la diferencia máxima aceptada entre el timestamp firmado y la hora local
+const DefaultTolerance = 5 * time.Minute
+
+// Config contiene los secretos para verificar los webhooks entrantes (ver config.Config)
+// Un secreto vacío deshabilita el webhook correspondiente
+type Config struct {
+	// OdooSecret verifica los webhooks de Odoo (WEBHOOK_SECRET)
+	OdooSecret secret.Value `yaml:"odoo_secret"`
+	// QuickpassSecret verifica la firma HMAC de los webhooks de Quickpass (QUICKPASS_WEBHOOK_SECRET)
+	QuickpassSecret secret.Value `yaml:"quickpass_secret"`
+	// Tolerance es la antigüedad máxima de un webhook firmado (QUICKPASS_WEBHOOK_TOLERANCE, segundos)
+	Tolerance time.Duration `yaml:"quickpass_tolerance"`
+}
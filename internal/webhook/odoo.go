@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// SourceOdoo identifica los eventos recibidos desde Odoo
+const SourceOdoo = "odoo"
+
+// ErrInvalidPayload indica que el cuerpo del webhook no se pudo interpretar
+var ErrInvalidPayload = errors.New("payload de webhook inválido")
+
+// OdooEvent es una notificación de cambio enviada por una acción automatizada de Odoo
+//
+// Se aceptan dos formatos:
+//   - El de "Enviar notificación webhook" de Odoo 17+: {"_model": "hr.employee", "_id": 5, ...campos}
+//   - Uno explícito desde una acción de servidor: {"event_id": "...", "model": "hr.contract", "ids": [3], "employee_id": 5}
+type OdooEvent struct {
+	ID          string // Identificador para descartar duplicados
+	Model       string
+	RecordIDs   []int
+	EmployeeIDs []int // employee_id del registro, si vino en el payload (hr.contract, hr.leave)
+}
+
+// odooPayload son los campos conocidos de ambos formatos
+type odooPayload struct {
+	EventID      string          `json:"event_id"`
+	Model        string          `json:"model"`
+	IDs          []int           `json:"ids"`
+	WebhookModel string          `json:"_model"`
+	WebhookID    int             `json:"_id"`
+	ID           json.RawMessage `json:"id"`
+	EmployeeID   json.RawMessage `json:"employee_id"`
+}
+
+// ParseOdooEvent interpreta el cuerpo de un webhook de Odoo
+// Si no trae event_id (ni en el cuerpo ni en headerID) se usa el hash del cuerpo:
+// los reintentos de Odoo envían exactamente el mismo contenido
+func ParseOdooEvent(body []byte, headerID string) (*OdooEvent, error) {
+	var p odooPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	event := &OdooEvent{ID: headerID, Model: p.Model, RecordIDs: p.IDs}
+	if event.ID == "" {
+		event.ID = p.EventID
+	}
+	if event.ID == "" {
+		sum := sha256.Sum256(body)
+		event.ID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	if event.Model == "" {
+		event.Model = p.WebhookModel
+	}
+	if len(event.RecordIDs) == 0 {
+		if p.WebhookID > 0 {
+			event.RecordIDs = []int{p.WebhookID}
+		} else if id := many2oneID(p.ID); id > 0 {
+			event.RecordIDs = []int{id}
+		}
+	}
+	if id := many2oneID(p.EmployeeID); id > 0 {
+		event.EmployeeIDs = []int{id}
+	}
+
+	if event.Model == "" {
+		return nil, fmt.Errorf("%w: falta el modelo (_model o model)", ErrInvalidPayload)
+	}
+	if len(event.RecordIDs) == 0 {
+		return nil, fmt.Errorf("%w: faltan los IDs del registro (_id o ids)", ErrInvalidPayload)
+	}
+	return event, nil
+}
+
+// many2oneID extrae el ID de un campo de Odoo que puede venir como 5, "5", [5, "Nombre"] o {"id": 5}
+func many2oneID(raw json.RawMessage) int {
+	if len(raw) == 0 {
+		return 0
+	}
+
+	var number float64
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return int(number)
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		id, _ := strconv.Atoi(text)
+		return id
+	}
+	var pair []interface{}
+	if err := json.Unmarshal(raw, &pair); err == nil && len(pair) > 0 {
+		if id, ok := pair[0].(float64); ok {
+			return int(id)
+		}
+	}
+	var object struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &object); err == nil {
+		return object.ID
+	}
+	return 0
+}
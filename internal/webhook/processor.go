@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/logging"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/notify"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/quickpass"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/repository"
+	"github.com/IamNewInThis/odoo-quickpass-sync/internal/syncer"
+)
+
+// sweepInterval es cada cuánto se buscan eventos guardados que no llegaron a la cola
+const sweepInterval = time.Minute
+
+// errIgnored marca los eventos que no requieren acción
+var errIgnored = errors.New("evento ignorado")
+
+var logger = logging.For("webhook")
+
+// Processor procesa en segundo plano los eventos de Quickpass ya guardados
+// El webhook responde apenas el evento queda persistido, así Quickpass nunca espera a Odoo
+type Processor struct {
+	repo    repository.Repository
+	engine  *syncer.Engine
+	runner  *syncer.Runner
+	timeOff *syncer.TimeOffRecorder
+
+	queue    chan *repository.WebhookEvent
+	mu       sync.Mutex
+	inFlight map[int64]bool
+
+	ctx   context.Context
+	stop  context.CancelFunc
+	drain chan struct{} // Se cierra al pedir un apagado ordenado (ver Shutdown)
+	wg    sync.WaitGroup
+}
+
+// NewProcessor crea un procesador con una cola del tamaño indicado
+func NewProcessor(repo repository.Repository, engine *syncer.Engine, runner *syncer.Runner, timeOff *syncer.TimeOffRecorder, queueSize int) *Processor {
+	ctx, stop := context.WithCancel(context.Background())
+	return &Processor{
+		repo:     repo,
+		engine:   engine,
+		runner:   runner,
+		timeOff:  timeOff,
+		queue:    make(chan *repository.WebhookEvent, queueSize),
+		inFlight: map[int64]bool{},
+		ctx:      ctx,
+		stop:     stop,
+		drain:    make(chan struct{}),
+	}
+}
+
+// Start inicia el worker y retoma los eventos que quedaron sin procesar (ej: tras un reinicio)
+func (p *Processor) Start() {
+	p.wg.Add(1)
+	go p.work()
+
+	p.sweep()
+}
+
+// QueueDepth devuelve la cantidad de eventos que esperan en la cola
+func (p *Processor) QueueDepth() int {
+	return len(p.queue)
+}
+
+// Stop detiene el procesador; los eventos pendientes quedan guardados y se retoman en el próximo inicio
+func (p *Processor) Stop() {
+	p.stop()
+	p.wg.Wait()
+}
+
+// Shutdown deja de tomar eventos de la cola y espera a que termine el que se está procesando
+// Si ctx vence antes, se detiene como en Stop; los eventos pendientes se retoman en el próximo inicio
+func (p *Processor) Shutdown(ctx context.Context) error {
+	close(p.drain)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	defer p.stop()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Submit agrega un evento guardado a la cola sin bloquear
+// Si la cola está llena el evento queda en estado "received" y lo retoma el barrido periódico
+func (p *Processor) Submit(event *repository.WebhookEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight[event.ID] {
+		return
+	}
+
+	select {
+	case p.queue <- event:
+		p.inFlight[event.ID] = true
+	default:
+		logger.Warn("⚠️ Cola de webhooks llena: el evento se procesará en el próximo barrido", "tenant", event.Tenant, "event", event.ID)
+	}
+}
+
+// work procesa la cola y barre periódicamente los eventos pendientes
+func (p *Processor) work() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.drain:
+			return
+		case <-ticker.C:
+			p.sweep()
+		case event := <-p.queue:
+			p.process(event)
+			p.mu.Lock()
+			delete(p.inFlight, event.ID)
+			p.mu.Unlock()
+		}
+	}
+}
+
+// sweep encola los eventos de Quickpass guardados del tenant del motor que aún no se procesan
+func (p *Processor) sweep() {
+	events, err := p.repo.ListWebhookEvents(p.ctx, repository.WebhookEventFilter{
+		Tenant: p.engine.Tenant(),
+		Source: SourceQuickpass,
+		Status: repository.WebhookReceived,
+		Limit:  1000,
+	})
+	if err != nil {
+		logger.Warn("⚠️ Error buscando webhooks pendientes", "tenant", p.engine.Tenant(), "error", err)
+		return
+	}
+	// Se listan del más reciente al más antiguo; se encolan en orden de llegada
+	for i := len(events) - 1; i >= 0; i-- {
+		p.Submit(events[i])
+	}
+}
+
+// process ejecuta un evento y guarda el resultado
+func (p *Processor) process(record *repository.WebhookEvent) {
+	err := p.Process(p.ctx, record)
+	if p.ctx.Err() != nil {
+		// Detenido a mitad de camino: el evento sigue "received" y se retoma al reiniciar
+		return
+	}
+
+	processedAt := time.Now().UTC()
+	record.ProcessedAt = &processedAt
+	record.Attempts++
+	switch {
+	case errors.Is(err, errIgnored):
+		record.Status = repository.WebhookIgnored
+		record.Error = err.Error()
+	case err != nil:
+		record.Status = repository.WebhookFailed
+		record.Error = err.Error()
+		logger.Error("❌ Error procesando webhook", "tenant", record.Tenant, "event_id", record.EventID, "event_type", record.EventType, "error", err)
+	default:
+		record.Status = repository.WebhookProcessed
+		record.Error = ""
+	}
+	if err := p.repo.UpdateWebhookEvent(context.Background(), record); err != nil {
+		logger.Warn("⚠️ Error actualizando evento de webhook", "tenant", record.Tenant, "event", record.ID, "error", err)
+	}
+}
+
+// Process aplica un evento de Quickpass en Odoo
+func (p *Processor) Process(ctx context.Context, record *repository.WebhookEvent) error {
+	event, err := ParseQuickpassEvent(record.Payload)
+	if err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case QuickpassPunchRecorded:
+		var punch quickpass.Punch
+		if err := json.Unmarshal(event.Data, &punch); err != nil || punch.ID == "" {
+			return fmt.Errorf("%w: marcación inválida", ErrInvalidPayload)
+		}
+		attendanceID, err := p.engine.RecordPunch(ctx, record.Tenant, &punch)
+		if err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "🪝 Marcación registrada en hr.attendance", "tenant", record.Tenant, "punch_id", punch.ID, "attendance_id", attendanceID)
+
+	case QuickpassTimeOffRequested:
+		if _, ok := p.engine.Flow(syncer.FlowTimeOff); !ok {
+			return fmt.Errorf("%w: el flujo %s está deshabilitado (ENABLE_TIMEOFF_SYNC)", errIgnored, syncer.FlowTimeOff)
+		}
+		var request quickpass.TimeOffRequest
+		if err := json.Unmarshal(event.Data, &request); err != nil {
+			return fmt.Errorf("%w: solicitud inválida", ErrInvalidPayload)
+		}
+		leaveID, err := p.timeOff.Record(ctx, record.Tenant, &request)
+		if err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "🪝 Solicitud registrada en hr.leave", "tenant", record.Tenant, "timeoff_request", request.ID, "leave_id", leaveID)
+		p.engine.Notify(ctx, record.Tenant, notify.LeaveRequested, map[string]interface{}{
+			"leave_id":          leaveID,
+			"request_id":        request.ID,
+			"quickpass_user_id": request.UserID,
+			"leave_type":        request.LeaveType,
+			"date_from":         request.DateFrom,
+			"date_to":           request.DateTo,
+		})
+
+	case QuickpassUserUpdated:
+		// Odoo es la fuente de verdad de los datos del empleado: se vuelve a sincronizar
+		// para detectar y corregir diferencias introducidas desde Quickpass
+		var user quickpass.User
+		if err := json.Unmarshal(event.Data, &user); err != nil || user.ID == "" {
+			return fmt.Errorf("%w: usuario inválido", ErrInvalidPayload)
+		}
+		mapping, err := p.repo.GetEmployeeMappingByQuickpassID(ctx, record.Tenant, user.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: el usuario %s no tiene empleado asociado", errIgnored, user.ID)
+		}
+		if err != nil {
+			return err
+		}
+		run, err := p.runner.EnqueueTargets(ctx, record.Tenant, syncer.FlowEmployees, []int{mapping.OdooEmployeeID})
+		if err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "🪝 Usuario actualizado en Quickpass: sincronización encolada", "tenant", record.Tenant, "quickpass_user_id", user.ID, "run_id", run.ID)
+
+	default:
+		return fmt.Errorf("%w: tipo %s no soportado", errIgnored, event.Type)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SourceQuickpass identifica los eventos recibidos desde Quickpass
+const SourceQuickpass = "quickpass"
+
+// Tipos de evento de Quickpass
+const (
+	QuickpassPunchRecorded    = "punch.recorded"
+	QuickpassTimeOffRequested = "time_off.requested"
+	QuickpassUserUpdated      = "user.updated"
+)
+
+var (
+	// ErrInvalidSignature indica que la firma no corresponde al cuerpo y timestamp recibidos
+	ErrInvalidSignature = errors.New("firma del webhook inválida")
+	// ErrStaleTimestamp indica que el timestamp está fuera de la tolerancia (posible reenvío)
+	ErrStaleTimestamp = errors.New("timestamp del webhook fuera de la tolerancia")
+)
+
+// QuickpassEvent es el sobre común de los eventos de Quickpass
+type QuickpassEvent struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// ParseQuickpassEvent interpreta el cuerpo de un webhook de Quickpass
+func ParseQuickpassEvent(body []byte) (*QuickpassEvent, error) {
+	var event QuickpassEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if event.ID == "" || event.Type == "" {
+		return nil, fmt.Errorf("%w: faltan id o type", ErrInvalidPayload)
+	}
+	return &event, nil
+}
+
+// SignTimestamped firma "<timestamp>.<body>"; incluir el timestamp impide reutilizar una firma antigua
+func SignTimestamped(secret, timestamp string, body []byte) string {
+	return Sign(secret, timestampedMessage(timestamp, body))
+}
+
+// VerifyTimestamped verifica la firma de un webhook y que su timestamp (segundos Unix)
+// no se aleje de now más que tolerance, para bloquear reenvíos de peticiones capturadas
+func VerifyTimestamped(secret, timestamp string, body []byte, signature string, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrStaleTimestamp, timestamp)
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age < 0 {
+		age = -age
+	}
+	if age > tolerance {
+		return fmt.Errorf("%w: diferencia de %v", ErrStaleTimestamp, age.Round(time.Second))
+	}
+	if !VerifySignature(secret, timestampedMessage(timestamp, body), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// timestampedMessage arma el mensaje firmado
+func timestampedMessage(timestamp string, body []byte) []byte {
+	message := make([]byte, 0, len(timestamp)+1+len(body))
+	message = append(message, timestamp...)
+	message = append(message, '.')
+	return append(message, body...)
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyTimestamped(t *testing.T) {
+	const secret = "quickpass-secret"
+	now := time.Unix(1767225600, 0)
+	body := []byte(`{"id":"evt-1","type":"punch.recorded","data":{"user_id":"u-7"}}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := SignTimestamped(secret, timestamp, body)
+	at := func(offset time.Duration) string {
+		return strconv.FormatInt(now.Add(offset).Unix(), 10)
+	}
+
+	tests := []struct {
+		name      string
+		timestamp string
+		body      []byte
+		signature string
+		want      error
+	}{
+		{"válida", timestamp, body, signature, nil},
+		{"con prefijo sha256=", timestamp, body, "sha256=" + signature, nil},
+		{"dentro de la tolerancia", at(-4 * time.Minute), body, SignTimestamped(secret, at(-4*time.Minute), body), nil},
+		{"reloj del emisor adelantado", at(4 * time.Minute), body, SignTimestamped(secret, at(4*time.Minute), body), nil},
+		{"cuerpo modificado", timestamp, []byte(`{"id":"evt-1","type":"user.updated"}`), signature, ErrInvalidSignature},
+		{"timestamp cambiado sin volver a firmar", at(-time.Second), body, signature, ErrInvalidSignature},
+		{"otro secreto", timestamp, body, SignTimestamped("otro-secreto", timestamp, body), ErrInvalidSignature},
+		{"expirado", at(-10 * time.Minute), body, SignTimestamped(secret, at(-10*time.Minute), body), ErrStaleTimestamp},
+		{"en el futuro", at(10 * time.Minute), body, SignTimestamped(secret, at(10*time.Minute), body), ErrStaleTimestamp},
+		{"timestamp inválido", "ayer", body, SignTimestamped(secret, "ayer", body), ErrStaleTimestamp},
+		{"sin timestamp", "", body, signature, ErrStaleTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyTimestamped(secret, tt.timestamp, tt.body, tt.signature, 5*time.Minute, now)
+			if tt.want == nil && err != nil {
+				t.Fatalf("VerifyTimestamped() = %v, se esperaba una firma válida", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("VerifyTimestamped() = %v, se esperaba %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// signaturePrefix es el prefijo opcional de las firmas (ej: "sha256=ab12...")
+const signaturePrefix = "sha256="
+
+// Sign devuelve la firma HMAC-SHA256 de message en hexadecimal
+func Sign(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature verifica en tiempo constante una firma generada con Sign
+// Acepta la firma con o sin el prefijo "sha256="
+func VerifySignature(secret string, message []byte, signature string) bool {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), signaturePrefix)
+	provided, err := hex.DecodeString(signature)
+	if err != nil || secret == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hmac.Equal(mac.Sum(nil), provided)
+}
+
+// Equal compara dos secretos en tiempo constante
+// Se comparan sus hashes para no revelar la longitud del secreto esperado
+func Equal(expected, provided string) bool {
+	if expected == "" || provided == "" {
+		return false
+	}
+	a := sha256.Sum256([]byte(expected))
+	b := sha256.Sum256([]byte(provided))
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import "testing"
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "webhook-secret"
+	body := []byte(`{"event_id":"evt-1","model":"hr.employee","action":"write","record_ids":[7]}`)
+	signature := Sign(secret, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"válida", secret, body, signature, true},
+		{"con prefijo sha256=", secret, body, "sha256=" + signature, true},
+		{"con espacios", secret, body, " " + signature + "\n", true},
+		{"cuerpo modificado", secret, []byte(`{"event_id":"evt-1","model":"hr.employee","action":"unlink","record_ids":[7]}`), signature, false},
+		{"otro secreto", "otro-secreto", body, signature, false},
+		{"firma truncada", secret, body, signature[:len(signature)-2], false},
+		{"firma no hexadecimal", secret, body, "zz" + signature[2:], false},
+		{"sin firma", secret, body, "", false},
+		{"sin secreto", "", body, Sign("", body), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature(tt.secret, tt.body, tt.signature); got != tt.want {
+				t.Errorf("VerifySignature() = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		expected, provided string
+		want               bool
+	}{
+		{"token", "token", true},
+		{"token", "tokem", false},
+		{"token", "token-largo", false},
+		{"token", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := Equal(tt.expected, tt.provided); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, se esperaba %v", tt.expected, tt.provided, got, tt.want)
+		}
+	}
+}